@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// AddressMode defines how instruction addresses are displayed.
+type AddressMode int
+
+const (
+	// AddressNone hides the addresses.
+	AddressNone AddressMode = iota
+	// AddressAbsolute shows the virtual address.
+	AddressAbsolute
+	// AddressRelative shows the offset from the start of the func.
+	AddressRelative
+)
+
+var addressModeNames = [...]string{
+	AddressNone:     "none",
+	AddressAbsolute: "abs",
+	AddressRelative: "rel",
+}
+
+func (mode AddressMode) String() string { return addressModeNames[mode] }
+
+// Set implements flag.Value.
+func (mode *AddressMode) Set(value string) error {
+	for m, name := range addressModeNames {
+		if name == value {
+			*mode = AddressMode(m)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown address mode %q, expected none, abs or rel", value)
+}
+
+// Next returns the following mode for cycling through them.
+func (mode AddressMode) Next() AddressMode {
+	return (mode + 1) % AddressMode(len(addressModeNames))
+}
+
+var rxJumpTarget = regexp.MustCompile(`\s0x[\da-fA-F]+$`)
+
+// Format prefixes the instruction text with its address.
+// In relative mode the jump targets within the func are also
+// shown relative to start.
+func (mode AddressMode) Format(ix *disasm.Inst, start uint64, width int) string {
+	switch mode {
+	case AddressAbsolute:
+		return fmt.Sprintf("0x%0*x  %s", width-2, ix.PC, ix.Text)
+	case AddressRelative:
+		text := ix.Text
+		if ix.RefOffset != 0 && ix.RefPC >= start {
+			text = rxJumpTarget.ReplaceAllString(text, fmt.Sprintf(" +%#x", ix.RefPC-start))
+		}
+		return fmt.Sprintf("+%#-*x  %s", width, ix.PC-start, text)
+	default:
+		return ix.Text
+	}
+}
+
+// addressWidth returns the number of characters needed for formatting
+// the addresses of code in the specified mode.
+func addressWidth(code *disasm.Code, mode AddressMode) (start uint64, width int) {
+	var last uint64
+	first := true
+	for _, ix := range code.Insts {
+		if ix.Text == "" {
+			continue
+		}
+		if first {
+			start, first = ix.PC, false
+		}
+		last = ix.PC
+	}
+	if mode == AddressRelative {
+		last -= start
+	}
+	return start, len(fmt.Sprintf("%#x", last))
+}
+
+// formatAddress formats addr for pasting into a debugger or a hex viewer.
+func formatAddress(addr uint64) string { return fmt.Sprintf("0x%x", addr) }
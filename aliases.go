@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// aliasMark indicates that a name was assigned by the user.
+const aliasMark = "✎ "
+
+// Aliases are the readable names the user assigned to symbols. They
+// are only used for display, filtering still matches the real names.
+type Aliases struct {
+	names map[string]string
+	// version changes with every rename for invalidating caches.
+	version int
+}
+
+// NewAliases returns aliases that contain names, which maps the real
+// names to the aliases.
+func NewAliases(names map[string]string) *Aliases {
+	aliases := &Aliases{names: map[string]string{}}
+	for name, alias := range names {
+		aliases.Set(name, alias)
+	}
+	return aliases
+}
+
+// Set assigns alias to the symbol name, an empty alias removes it.
+func (aliases *Aliases) Set(name, alias string) {
+	alias = strings.TrimSpace(alias)
+	if alias == "" || alias == name {
+		delete(aliases.names, name)
+	} else {
+		aliases.names[name] = alias
+	}
+	aliases.version++
+}
+
+// Get returns the alias of the symbol name.
+func (aliases *Aliases) Get(name string) (string, bool) {
+	if aliases == nil {
+		return "", false
+	}
+	alias, ok := aliases.names[name]
+	return alias, ok
+}
+
+// Display returns the marked alias of name, or name when it has none.
+func (aliases *Aliases) Display(name string) string {
+	if alias, ok := aliases.Get(name); ok {
+		return aliasMark + alias
+	}
+	return name
+}
+
+// Names returns a copy of the aliases keyed by the real name.
+func (aliases *Aliases) Names() map[string]string {
+	if aliases == nil || len(aliases.names) == 0 {
+		return nil
+	}
+	names := make(map[string]string, len(aliases.names))
+	for name, alias := range aliases.names {
+		names[name] = alias
+	}
+	return names
+}
+
+// Version returns a number that changes whenever the aliases change.
+func (aliases *Aliases) Version() int {
+	if aliases == nil {
+		return 0
+	}
+	return aliases.version
+}
+
+// executableStorePath returns the file in the user config dir that stores
+// name, e.g. the aliases, for all executables.
+func executableStorePath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lensm", name), nil
+}
+
+// readExecutableStore reads the maps keyed by the absolute executable path.
+func readExecutableStore(path string) (map[string]map[string]string, error) {
+	all := map[string]map[string]string{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return all, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// loadExecutableStore reads the map of the executable from the store file
+// name, it's nil when the store doesn't contain it.
+func loadExecutableStore(name, exePath string) (map[string]string, error) {
+	path, err := executableStorePath(name)
+	if err != nil {
+		return nil, err
+	}
+	all, err := readExecutableStore(path)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(exePath)
+	if err != nil {
+		return nil, err
+	}
+	return all[abs], nil
+}
+
+// saveExecutableStore replaces the map of the executable in the store file
+// name, keeping the maps of the other executables.
+func saveExecutableStore(name, exePath string, values map[string]string) error {
+	path, err := executableStorePath(name)
+	if err != nil {
+		return err
+	}
+	all, err := readExecutableStore(path)
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(exePath)
+	if err != nil {
+		return err
+	}
+	if values != nil {
+		all[abs] = values
+	} else {
+		delete(all, abs)
+	}
+
+	data, err := json.MarshalIndent(all, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// LoadAliases reads the aliases stored for the executable.
+func LoadAliases(exePath string) (*Aliases, error) {
+	names, err := loadExecutableStore("aliases.json", exePath)
+	return NewAliases(names), err
+}
+
+// SaveAliases stores the aliases of the executable.
+func SaveAliases(exePath string, aliases *Aliases) error {
+	return saveExecutableStore("aliases.json", exePath, aliases.Names())
+}
+
+// RenameUI is the editor for assigning an alias to a symbol.
+type RenameUI struct {
+	// Name is the real name of the renamed symbol, empty when closed.
+	Name   string
+	Editor widget.Editor
+}
+
+// Open starts editing the alias of name.
+func (ui *RenameUI) Open(name string, aliases *Aliases) {
+	ui.Name = name
+	ui.Editor.SingleLine = true
+	ui.Editor.Submit = true
+	alias, _ := aliases.Get(name)
+	ui.Editor.SetText(alias)
+	ui.Editor.SetCaret(ui.Editor.Len(), 0)
+}
+
+// Close stops editing.
+func (ui *RenameUI) Close() { ui.Name = "" }
+
+// Active returns whether the editor is open.
+func (ui *RenameUI) Active() bool { return ui.Name != "" }
+
+// Layout draws the editor and reports the submitted alias.
+func (ui *RenameUI) Layout(th *material.Theme, gtx layout.Context) (alias string, submitted bool, dims layout.Dimensions) {
+	for _, ev := range ui.Editor.Events() {
+		if ev, ok := ev.(widget.SubmitEvent); ok {
+			alias, submitted = ev.Text, true
+		}
+	}
+	ui.Editor.Focus()
+	editor := material.Editor(th, &ui.Editor, "Alias for "+ui.Name+" (empty removes it)")
+	dims = layout.UniformInset(4).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return FocusBorder(th, true).Layout(gtx, editor.Layout)
+	})
+	return alias, submitted, dims
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"loov.dev/lensm/internal/goobj"
+)
+
+// archNotes describes the extra support of the architectures.
+var archNotes = map[string]string{
+	"amd64": "CPU features, -encoding",
+	"386":   "CPU features, -encoding",
+}
+
+// ListArchs writes the architectures that this build can disassemble,
+// one per line.
+func ListArchs(w io.Writer) error {
+	for _, arch := range goobj.Archs() {
+		line := arch
+		if note := archNotes[arch]; note != "" {
+			line = fmt.Sprintf("%-8s %s", arch, note)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%-8s %s\n", "wasm", "experimental, with LENSM_EXPERIMENT_WASM=1")
+	return err
+}
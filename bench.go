@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// RunBenchmark loads the executable and all the funcs that match filter,
+// and writes the timings and memory stats to w as a single line of
+// space separated key=value pairs.
+func RunBenchmark(w io.Writer, config FileUIConfig, filter string) error {
+	rx, err := CompileFilter(filter, FilterMode{})
+	if err != nil {
+		return err
+	}
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	file, err := config.Load()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	symbols := time.Since(start)
+
+	var timings disasm.Timings
+	opts := config.LoadOptions()
+	opts.Timings = &timings
+
+	funcs := file.Funcs()
+	matched := FilterItems(nil, funcs, rx, config.Exclude)
+	instructions := 0
+	for _, fn := range matched {
+		code := fn.Load(opts)
+		instructions += len(code.Insts)
+	}
+	total := time.Since(start)
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	_, err = fmt.Fprintf(w, "funcs=%d matched=%d insts=%d symbols_ns=%d disasm_ns=%d source_ns=%d total_ns=%d alloc_bytes=%d mallocs=%d heap_bytes=%d sys_bytes=%d\n",
+		len(funcs), len(matched), instructions,
+		symbols.Nanoseconds(), timings.Disassemble.Nanoseconds(), timings.Source.Nanoseconds(), total.Nanoseconds(),
+		after.TotalAlloc-before.TotalAlloc, after.Mallocs-before.Mallocs, after.HeapAlloc, after.Sys)
+	return err
+}
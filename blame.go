@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// blamePollInterval is how often the code is redrawn while git blame runs.
+const blamePollInterval = 100 * time.Millisecond
+
+// GitBlame runs "git blame" for the source files in the background and
+// caches the last commit of each line. Files outside of a git repository
+// don't have any blame.
+type GitBlame struct {
+	mu    sync.Mutex
+	files map[string]*blameFile
+}
+
+// blameFile is the blame of a single file, which is done after git exits.
+type blameFile struct {
+	done  bool
+	lines map[int]string
+}
+
+// NewGitBlame returns an empty cache.
+func NewGitBlame() *GitBlame {
+	return &GitBlame{files: map[string]*blameFile{}}
+}
+
+// Reset forgets the blame of all the files, e.g. after a rebuild.
+func (blame *GitBlame) Reset() {
+	blame.mu.Lock()
+	defer blame.mu.Unlock()
+	blame.files = map[string]*blameFile{}
+}
+
+// Line returns the commit, author and date of the line in file. It starts
+// loading the blame on the first request for the file and reports pending
+// until it's done.
+func (blame *GitBlame) Line(file string, line int) (text string, pending bool) {
+	blame.mu.Lock()
+	defer blame.mu.Unlock()
+
+	loaded, ok := blame.files[file]
+	if !ok {
+		loaded = &blameFile{}
+		blame.files[file] = loaded
+		go func() {
+			lines := runGitBlame(file)
+			blame.mu.Lock()
+			defer blame.mu.Unlock()
+			loaded.lines, loaded.done = lines, true
+		}()
+	}
+	if !loaded.done {
+		return "", true
+	}
+	return loaded.lines[line], false
+}
+
+// runGitBlame returns the descriptions of the lines of file, which is nil
+// when git isn't available or the file isn't in a repository.
+func runGitBlame(file string) map[int]string {
+	cmd := exec.Command("git", "blame", "--line-porcelain", "--", filepath.Base(file))
+	cmd.Dir = filepath.Dir(file)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return parseBlame(out)
+}
+
+// parseBlame parses the output of "git blame --line-porcelain" into
+// "<commit> <author> <date>" for each line.
+func parseBlame(out []byte) map[int]string {
+	lines := map[int]string{}
+
+	var commit, author string
+	var line int
+	var date time.Time
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			// the content of the line ends the entry
+			desc := "uncommitted"
+			if strings.Trim(commit, "0") != "" {
+				desc = commit[:min(len(commit), 8)] + " " + author + " " + date.Format("2006-01-02")
+			}
+			lines[line] = desc
+		case strings.HasPrefix(text, "author "):
+			author = strings.TrimPrefix(text, "author ")
+		case strings.HasPrefix(text, "author-time "):
+			if sec, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+				date = time.Unix(sec, 0)
+			}
+		default:
+			// header "<commit> <original line> <final line> [<lines>]"
+			fields := strings.Fields(text)
+			if len(fields) >= 3 && (len(fields[0]) == 40 || len(fields[0]) == 64) {
+				if n, err := strconv.Atoi(fields[2]); err == nil {
+					commit, line = fields[0], n
+				}
+			}
+		}
+	}
+	return lines
+}
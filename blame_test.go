@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBlame(t *testing.T) {
+	const (
+		commit = "1debc76a0b6c8f0e5d4c3b2a1f0e9d8c7b6a5f4e"
+		zero   = "0000000000000000000000000000000000000000"
+	)
+	date := time.Unix(1700000000, 0).Format("2006-01-02")
+
+	tests := []struct {
+		name string
+		out  []string
+		want map[int]string
+	}{
+		{
+			name: "empty",
+			want: map[int]string{},
+		},
+		{
+			name: "committed",
+			out: []string{
+				commit + " 1 1 2",
+				"author Jane Doe",
+				"author-mail <jane@example.com>",
+				"author-time 1700000000",
+				"author-tz +0000",
+				"summary Add main",
+				"filename main.go",
+				"\tpackage main",
+				commit + " 2 2",
+				"author Jane Doe",
+				"author-time 1700000000",
+				"filename main.go",
+				"\t",
+			},
+			want: map[int]string{
+				1: "1debc76a Jane Doe " + date,
+				2: "1debc76a Jane Doe " + date,
+			},
+		},
+		{
+			name: "uncommitted",
+			out: []string{
+				zero + " 3 5 1",
+				"author Not Committed Yet",
+				"author-time 1700000000",
+				"filename main.go",
+				"\tfunc main() {}",
+			},
+			want: map[int]string{5: "uncommitted"},
+		},
+		{
+			name: "sha256",
+			out: []string{
+				strings.Repeat("ab", 32) + " 1 7 1",
+				"author Jane Doe",
+				"author-time 1700000000",
+				"\t// the summary isn't a header 1 2 3",
+			},
+			want: map[int]string{7: "abababab Jane Doe " + date},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := strings.Join(test.out, "\n")
+			if got := parseBlame([]byte(out)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
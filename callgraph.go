@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// CallGraph is the graph of the calls between the matched funcs.
+type CallGraph struct {
+	Nodes []CallNode `json:"nodes"`
+	Edges []CallEdge `json:"edges"`
+}
+
+// CallNode is a func of the call graph.
+type CallNode struct {
+	Name string `json:"name"`
+	Addr uint64 `json:"addr,omitempty"`
+	Size uint64 `json:"size,omitempty"`
+	// External is set for the callees that weren't matched.
+	External bool `json:"external,omitempty"`
+}
+
+// CallEdge is a caller calling the callee from one or more call sites.
+type CallEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Sites int    `json:"sites"`
+}
+
+// BuildCallGraph disassembles funcs and collects the calls between them,
+// with external the callees outside of funcs are included as well.
+func BuildCallGraph(funcs []disasm.Func, opts disasm.Options, external bool) *CallGraph {
+	graph := &CallGraph{}
+	matched := map[string]bool{}
+	for _, fn := range funcs {
+		matched[fn.Name()] = true
+		node := CallNode{Name: fn.Name()}
+		if sym, ok := fn.(disasm.Symbol); ok {
+			node.Addr, node.Size = sym.Addr(), sym.Size()
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	externals := map[string]bool{}
+	for _, fn := range funcs {
+		code := fn.Load(opts)
+		if code == nil {
+			continue
+		}
+		sites := map[string]int{}
+		for _, ix := range code.Insts {
+			if ix.Call == "" || !matched[ix.Call] && !external {
+				continue
+			}
+			sites[ix.Call]++
+		}
+		for callee, n := range sites {
+			graph.Edges = append(graph.Edges, CallEdge{From: fn.Name(), To: callee, Sites: n})
+			if !matched[callee] {
+				externals[callee] = true
+			}
+		}
+	}
+	for name := range externals {
+		graph.Nodes = append(graph.Nodes, CallNode{Name: name, External: true})
+	}
+
+	sort.SliceStable(graph.Nodes, func(i, k int) bool {
+		a, b := graph.Nodes[i], graph.Nodes[k]
+		if a.External != b.External {
+			return !a.External
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(graph.Edges, func(i, k int) bool {
+		a, b := graph.Edges[i], graph.Edges[k]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return graph
+}
+
+// WriteDOT writes the graph in Graphviz DOT format, the external callees
+// are dashed and the edges with several call sites are labeled.
+func (graph *CallGraph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph calls {\n")
+	b.WriteString("\tnode [shape=box, fontname=\"Go Mono\"];\n")
+	for _, node := range graph.Nodes {
+		style := ""
+		if node.External {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "\t\"%s\" [label=\"%s\"%s];\n", dotEscape(node.Name), dotEscape(node.Name), style)
+	}
+	for _, edge := range graph.Edges {
+		label := ""
+		if edge.Sites > 1 {
+			label = fmt.Sprintf(" [label=\"%d\"]", edge.Sites)
+		}
+		fmt.Fprintf(&b, "\t\"%s\" -> \"%s\"%s;\n", dotEscape(edge.From), dotEscape(edge.To), label)
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the graph as JSON.
+func (graph *CallGraph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(graph)
+}
+
+// ExportCallGraph writes the call graph of the funcs matching filter to
+// path, as JSON when path ends with .json and in DOT format otherwise.
+func ExportCallGraph(path string, config FileUIConfig, filter string, external bool) error {
+	file, matches, err := loadMatches(config, filter)
+	if err != nil {
+		return fmt.Errorf("-callgraph: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	graph := BuildCallGraph(matches, config.LoadOptions(), external)
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = graph.WriteJSON(out)
+	} else {
+		err = graph.WriteDOT(out)
+	}
+	if err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"path/filepath"
+
+	"gioui.org/font"
+	"gioui.org/layout"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// Comparison shows the funcs with the same name from another executable,
+// e.g. the same program compiled for a different architecture.
+type Comparison struct {
+	Path string
+
+	File      disasm.File
+	LoadError error
+	byName    map[string]disasm.Func
+
+	Code CodeUI
+
+	// changes contains only the instructions that differ.
+	changes struct {
+		base, other *disasm.Code
+		left, right CodeUI
+	}
+}
+
+// changesContext is the number of unchanged instructions shown around
+// the changed ones.
+const changesContext = 3
+
+// comparisonLoaded is the result of loading the compared executable.
+type comparisonLoaded struct {
+	file disasm.File
+	err  error
+}
+
+// SetFile replaces the compared executable.
+func (cmp *Comparison) SetFile(file disasm.File, err error) {
+	if cmp.File != nil {
+		_ = cmp.File.Close()
+	}
+	cmp.File, cmp.LoadError = file, err
+	cmp.byName = map[string]disasm.Func{}
+	cmp.Code.Code = nil
+	if file == nil {
+		return
+	}
+	for _, fn := range file.Funcs() {
+		cmp.byName[fn.Name()] = fn
+	}
+}
+
+// Update loads the func with the specified name.
+func (cmp *Comparison) Update(name string, opts disasm.Options) {
+	if cmp.Code.Loaded() && cmp.Code.Name == name {
+		return
+	}
+	cmp.Code.Code = nil
+	if fn, ok := cmp.byName[name]; ok {
+		cmp.Code.Code = fn.Load(opts)
+		cmp.Code.ResetScroll()
+	}
+}
+
+// Changes returns base and the compared code reduced to the instructions
+// that differ between them, or nil when the compared func isn't loaded.
+func (cmp *Comparison) Changes(base *disasm.Code) (left, right *CodeUI) {
+	if !cmp.Code.Loaded() || base == nil {
+		return nil, nil
+	}
+	if cmp.changes.base != base || cmp.changes.other != cmp.Code.Code {
+		changedBase, changedOther := disasm.ChangedInsts(base, cmp.Code.Code)
+		cmp.changes.base, cmp.changes.other = base, cmp.Code.Code
+		cmp.changes.left.Code = base.Changes(changedBase, changesContext)
+		cmp.changes.right.Code = cmp.Code.Code.Changes(changedOther, changesContext)
+		cmp.changes.left.ResetScroll()
+		cmp.changes.right.ResetScroll()
+	}
+	return &cmp.changes.left, &cmp.changes.right
+}
+
+// Layout draws the compared code below the name of the executable.
+func (cmp *Comparison) Layout(gtx layout.Context, style CodeUIStyle, code *CodeUI, name string) layout.Dimensions {
+	gtx.Constraints.Min = gtx.Constraints.Max
+	header := "compare: " + filepath.Base(cmp.Path)
+	switch {
+	case cmp.LoadError != nil:
+		header += ": " + cmp.LoadError.Error()
+	case cmp.File == nil:
+		header += " (loading)"
+	case !cmp.Code.Loaded():
+		header += ": " + name + " not found"
+	}
+
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			txt := material.Body1(style.Theme, header)
+			txt.Font.Style = font.Italic
+			return layout.UniformInset(4).Layout(gtx, txt.Layout)
+		}),
+		layout.Rigid(HorizontalLine{Height: palette.LineWidth, Color: palette.Splitter}.Layout),
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			style.CodeUI = code
+			return style.Layout(gtx)
+		}),
+	)
+}
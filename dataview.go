@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+const (
+	// dataViewSize is the number of bytes shown in the data view.
+	dataViewSize = 256
+	// dataViewRow is the number of bytes in a row.
+	dataViewRow = 8
+	// dataViewColumns is the width of the data view in characters.
+	dataViewColumns = 56
+)
+
+// DataView shows the bytes at the address referenced by an instruction.
+type DataView struct {
+	Theme      *material.Theme
+	TextHeight unit.Sp
+	LineHeight unit.Sp
+
+	// Data are the bytes at Addr read by readDataPrefix.
+	Data []byte
+	Addr uint64
+}
+
+// Layout draws the data as rows of hex and text followed by the first
+// bytes decoded as numbers.
+func (view DataView) Layout(gtx layout.Context) layout.Dimensions {
+	advance := monospaceAdvance(view.Theme, gtx, view.TextHeight)
+	size := image.Pt(dataViewColumns*advance, gtx.Constraints.Max.Y)
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	paint.FillShape(gtx.Ops, palette.SecondaryBackground, clip.Rect{Max: size}.Op())
+
+	lineHeight := gtx.Metric.Sp(view.LineHeight)
+	pad := lineHeight / 2
+	lines := view.lines()
+	for i, text := range lines {
+		line := SourceLine{
+			TopLeft:    image.Pt(pad, pad+i*lineHeight),
+			Width:      size.X - 2*pad,
+			Text:       text,
+			TextHeight: view.TextHeight,
+			Color:      palette.Foreground,
+		}
+		if i == 0 {
+			line.Bold = true
+		}
+		line.Layout(view.Theme, gtx)
+	}
+	return layout.Dimensions{Size: size}
+}
+
+// lines returns the rows of text in the view.
+func (view DataView) lines() []string {
+	header := fmt.Sprintf("data at 0x%x", view.Addr)
+	data := view.Data
+	if len(data) == 0 {
+		return []string{header, "not in the file, e.g. zero initialized"}
+	}
+
+	lines := []string{header}
+	if len(data) >= 8 {
+		value := binary.LittleEndian.Uint64(data)
+		lines = append(lines,
+			fmt.Sprintf("u64 %d", value),
+			fmt.Sprintf("f64 %g", math.Float64frombits(value)),
+		)
+	}
+	if len(data) >= 4 {
+		value := binary.LittleEndian.Uint32(data)
+		lines = append(lines, fmt.Sprintf("u32 %d, f32 %g", value, math.Float32frombits(value)))
+	}
+	lines = append(lines, "")
+
+	for off := 0; off < len(data); off += dataViewRow {
+		row := data[off:min(off+dataViewRow, len(data))]
+		var hex, text strings.Builder
+		for _, b := range row {
+			fmt.Fprintf(&hex, "%02x ", b)
+			if ' ' <= b && b < 0x7f {
+				text.WriteByte(b)
+			} else {
+				text.WriteByte('.')
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%#x  %-*s %s", view.Addr+uint64(off), 3*dataViewRow, hex.String(), text.String()))
+	}
+	return lines
+}
+
+// readDataPrefix reads up to size bytes at addr, reading less when
+// the data ends before, e.g. at the end of a section.
+func readDataPrefix(reader disasm.DataReader, addr uint64, size int) []byte {
+	for ; size > 0; size /= 2 {
+		data := make([]byte, size)
+		if err := reader.ReadData(addr, data); err == nil {
+			return data
+		}
+	}
+	return nil
+}
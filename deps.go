@@ -0,0 +1,65 @@
+package main
+
+import (
+	"image"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// dependencyWindow is the number of preceding instructions searched for
+// the one that writes a register.
+const dependencyWindow = 8
+
+// dependencies returns the register dependencies of the code, which are
+// cached until the code changes.
+func (ui *CodeUI) dependencies() []disasm.Dependency {
+	if ui.deps.code != ui.Code {
+		ui.deps.code = ui.Code
+		ui.deps.list = ui.Code.Dependencies(dependencyWindow)
+	}
+	return ui.deps.list
+}
+
+// layoutDependencies draws an arc at x from each instruction that writes
+// a register to the instructions reading it. The arcs of the hovered
+// instruction are emphasized and labeled with the register.
+func (ui CodeUIStyle) layoutDependencies(gtx layout.Context, x float32, rowY func(int) float32, visible disasm.LineRange, hovered int) {
+	lineHeight := float32(gtx.Metric.Sp(ui.LineHeight))
+	lineWidth := float32(gtx.Metric.Dp(palette.LineWidth))
+	for _, dep := range ui.dependencies() {
+		if dep.To < visible.From || dep.From >= visible.To {
+			continue
+		}
+		from := rowY(dep.From) + lineHeight/2
+		to := rowY(dep.To) + lineHeight/2
+		bulge := min(lineHeight/2*float32(dep.To-dep.From), lineHeight*2)
+
+		var path clip.Path
+		path.Begin(gtx.Ops)
+		path.MoveTo(f32.Pt(x, from))
+		path.CubeTo(f32.Pt(x+bulge, from), f32.Pt(x+bulge, to), f32.Pt(x, to))
+
+		color, width := palette.Splitter, lineWidth
+		color.A = 0x60
+		emphasized := hovered == dep.From || hovered == dep.To
+		if emphasized {
+			color, width = palette.Foreground, lineWidth*2
+		}
+		paint.FillShape(gtx.Ops, color, clip.Stroke{Path: path.End(), Width: width}.Op())
+
+		if emphasized {
+			SourceLine{
+				TopLeft:    image.Pt(int(x+bulge*3/4)+2, int((from+to-lineHeight)/2)),
+				Text:       dep.Register,
+				TextHeight: ui.TextHeight * 8 / 10,
+				Color:      palette.Foreground,
+				Plain:      ui.NoLigatures,
+			}.Layout(ui.Theme, gtx)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// WriteDOT writes the basic block graph of code in Graphviz DOT format.
+func WriteDOT(w io.Writer, code *disasm.Code) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", code.Name)
+	fmt.Fprintf(&b, "\tnode [shape=box, fontname=\"Go Mono\"];\n")
+
+	blocks := disasm.BasicBlocks(code)
+	for k, block := range blocks {
+		var label strings.Builder
+		for _, ix := range code.Insts[block.From:block.To] {
+			if ix.Text == "" {
+				continue
+			}
+			fmt.Fprintf(&label, "0x%x: %s\\l", ix.PC, dotEscape(ix.Text))
+		}
+		fmt.Fprintf(&b, "\tb%d [label=\"%s\"];\n", k, label.String())
+	}
+	for k, block := range blocks {
+		for _, edge := range block.Succs {
+			style := ""
+			if edge.Kind == disasm.Fallthrough {
+				style = ", style=dashed"
+			}
+			fmt.Fprintf(&b, "\tb%d -> b%d [label=%q%s];\n", k, edge.Block, edge.Kind.String(), style)
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotEscape escapes text for use inside a quoted DOT label.
+func dotEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text)
+}
+
+// ExportDOT writes the basic block graph of the single func matching
+// filter to path.
+func ExportDOT(path string, config FileUIConfig, filter string) error {
+	rx, err := CompileFilter(filter, FilterMode{})
+	if err != nil {
+		return err
+	}
+
+	file, err := config.Load()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	matches := FilterItems(nil, file.Funcs(), rx, config.Exclude)
+	if len(matches) != 1 {
+		return fmt.Errorf("-dot needs filter to match a single func, matched %d", len(matches))
+	}
+
+	return writeDOTFile(path, matches[0].Load(config.LoadOptions()))
+}
+
+func writeDOTFile(path string, code *disasm.Code) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteDOT(out, code); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+var rxUnsafeFileName = regexp.MustCompile(`[^\w.-]+`)
+
+// dotFileName returns a file name for the DOT export of the func.
+func dotFileName(funcname string) string {
+	return rxUnsafeFileName.ReplaceAllString(funcname, "_") + ".dot"
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// IsURL returns whether the path should be downloaded before opening.
+func IsURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// IsStdin returns whether the executable should be read from stdin.
+func IsStdin(path string) bool { return path == "-" }
+
+// ReadStdinExe copies the executable from stdin into a temporary file and
+// returns its path, because loading it needs random access.
+//
+// The caller is responsible for removing the file.
+func ReadStdinExe() (string, error) {
+	out, err := os.CreateTemp("", "lensm-stdin-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(out, os.Stdin)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(out.Name())
+		return "", fmt.Errorf("reading executable from stdin: %w", err)
+	}
+	return out.Name(), nil
+}
+
+// DownloadExe downloads url into a temporary file and returns its path.
+// When token is not empty, it's sent as a bearer token.
+//
+// The caller is responsible for removing the file.
+func DownloadExe(url, token string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download %v: %v", url, resp.Status)
+	}
+
+	out, err := os.CreateTemp("", "lensm-*")
+	if err != nil {
+		return "", err
+	}
+
+	progress := &downloadProgress{total: resp.ContentLength}
+	_, err = io.Copy(out, io.TeeReader(resp.Body, progress))
+	progress.finish()
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(out.Name())
+		return "", fmt.Errorf("download %v: %w", url, err)
+	}
+
+	return out.Name(), nil
+}
+
+// downloadProgress prints the download progress to stderr.
+type downloadProgress struct {
+	total   int64
+	written int64
+	printed time.Time
+}
+
+func (p *downloadProgress) Write(data []byte) (int, error) {
+	p.written += int64(len(data))
+	if now := time.Now(); now.Sub(p.printed) > 100*time.Millisecond {
+		p.printed = now
+		p.print()
+	}
+	return len(data), nil
+}
+
+func (p *downloadProgress) print() {
+	if p.total > 0 {
+		fmt.Fprintf(os.Stderr, "\rdownloading %d / %d KiB", p.written>>10, p.total>>10)
+	} else {
+		fmt.Fprintf(os.Stderr, "\rdownloading %d KiB", p.written>>10)
+	}
+}
+
+func (p *downloadProgress) finish() {
+	p.print()
+	fmt.Fprintln(os.Stderr)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// WriteDump writes the code as plain text in the order of the source
+// pane: each source line followed by the instructions compiled from it.
+// Instructions of several lines are repeated under each of them, like the
+// relation shapes of the window connect them to each line.
+func WriteDump(w io.Writer, code *disasm.Code) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, code.Name)
+	if code.Signature != "" {
+		fmt.Fprintln(bw, code.Signature)
+	}
+
+	writeInst := func(ix *disasm.Inst) {
+		if ix.Text == "" {
+			return
+		}
+		text := ix.Text
+		if ix.PCRelative != "" {
+			text += "  " + ix.PCRelative
+		}
+		fmt.Fprintf(bw, "\t\t0x%x  %s\n", ix.PC, text)
+	}
+
+	if len(code.Source) == 0 {
+		for i := range code.Insts {
+			writeInst(&code.Insts[i])
+		}
+		return bw.Flush()
+	}
+
+	for _, src := range code.Source {
+		fmt.Fprintf(bw, "\n%s\n", src.File)
+		for k, block := range src.Blocks {
+			if k > 0 {
+				fmt.Fprintln(bw, "\t...")
+			}
+			for i, line := range block.Lines {
+				fmt.Fprintf(bw, "%5d\t%s\n", block.From+i, strings.TrimRight(line, " \t"))
+				if i >= len(block.Related) {
+					continue
+				}
+				for _, r := range block.Related[i] {
+					for pc := r.From; pc < r.To; pc++ {
+						writeInst(&code.Insts[pc])
+					}
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// DumpFuncs writes the code of the funcs matching filter to w as plain
+// text, separated by blank lines.
+func DumpFuncs(w io.Writer, config FileUIConfig, filter string) error {
+	file, matches, err := loadMatches(config, filter)
+	if err != nil {
+		return fmt.Errorf("-dump: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	for i, fn := range matches {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		code := fn.Load(config.LoadOptions())
+		if code == nil {
+			continue
+		}
+		if err := WriteDump(w, code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// OpenInEditor starts command for editing file at line.
+// The "{file}" and "{line}" in command are replaced with the location,
+// when command doesn't contain them, "+line file" is appended.
+// When command is empty, $EDITOR is used.
+func OpenInEditor(command, file string, line int) error {
+	if command == "" {
+		command = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return errors.New("no editor configured, use -editor or $EDITOR")
+	}
+
+	replaced := false
+	for i, arg := range args {
+		next := strings.NewReplacer("{file}", file, "{line}", strconv.Itoa(line)).Replace(arg)
+		replaced = replaced || next != arg
+		args[i] = next
+	}
+	if !replaced {
+		args = append(args, "+"+strconv.Itoa(line), file)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// definitionLine returns the smallest line of code within its own file,
+// which usually is the func declaration.
+func definitionLine(code *disasm.Code) int {
+	line := 0
+	for _, ix := range code.Insts {
+		if ix.File == code.File && ix.Line > 0 && (line == 0 || ix.Line < line) {
+			line = ix.Line
+		}
+	}
+	return line
+}
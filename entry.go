@@ -0,0 +1,37 @@
+package main
+
+import "loov.dev/lensm/internal/disasm"
+
+// mainFuncName is the func where the user code of a program starts.
+const mainFuncName = "main.main"
+
+// selectMain selects main.main regardless of the filter. When it's already
+// selected, or the file doesn't have one, the func with the entry point of
+// the program is selected instead, e.g. _rt0_amd64_linux.
+func (ui *FileUI) selectMain() {
+	if ui.File == nil {
+		return
+	}
+
+	var main, entry disasm.Func
+	for _, fn := range ui.File.Funcs() {
+		if fn.Name() == mainFuncName {
+			main = fn
+			break
+		}
+	}
+	if started, ok := ui.File.(disasm.Started); ok {
+		entry = started.EntryFunc()
+	}
+
+	target, status := main, mainFuncName
+	if entry != nil && (main == nil || ui.Funcs.SelectedItem == main) {
+		target, status = entry, "entry point"
+	}
+	if target == nil {
+		ui.Funcs.Status = "no main.main or entry point"
+		return
+	}
+	ui.selectFunc(target)
+	ui.Funcs.Status = status
+}
@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"image"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"gioui.org/app"
 	"gioui.org/font"
+	"gioui.org/io/key"
 	"gioui.org/io/system"
 	"gioui.org/layout"
 	"gioui.org/op"
@@ -20,10 +27,123 @@ import (
 
 var workInProgressWASM bool
 
+// compactLineHeight is the line height of the code relative to the text
+// size in the compact mode.
+const compactLineHeight = 1.05
+
 type FileUIConfig struct {
-	Path    string
-	Watch   bool
-	Context int
+	Path  string
+	Watch bool
+	// Origin is the URL that Path was downloaded from.
+	Origin string
+	// DebugFile is a separate file with the symbols and DWARF missing from Path.
+	DebugFile string
+	// ModuleCache is the module cache for reading the sources of the modules
+	// that aren't available at the paths recorded in the executable.
+	ModuleCache string
+	// ContextBefore and ContextAfter are the source lines of context.
+	ContextBefore int
+	ContextAfter  int
+	MergeLines    bool
+	SourceJump    int
+	// MaxJumpLanes limits the lanes used for jump lines.
+	MaxJumpLanes int
+	// Anonymize replaces the addresses that depend on the link layout.
+	Anonymize bool
+	// Offset and Length limit the instructions to a window of the funcs.
+	Offset uint64
+	Length uint64
+	// VectorLanes annotates vector registers with their lanes.
+	VectorLanes bool
+	// Addresses defines how instruction addresses are shown.
+	Addresses AddressMode
+	// Immediates defines the base of immediate operands.
+	Immediates ImmediateBase
+	// JumpTargets defines how the targets of the jumps are shown.
+	JumpTargets JumpTargets
+	// LongLines defines how asm lines wider than the column are shown.
+	LongLines LongLines
+	// SelectScroll defines where the code is scrolled to after selecting a func.
+	SelectScroll SelectScroll
+	// DataView shows the data referenced by the hovered instruction.
+	DataView bool
+	// InstFrequency shows the instruction counts of the func in a sidebar.
+	InstFrequency bool
+	// TextOverview shows where the func is in the text section.
+	TextOverview bool
+	// OptHints notes where the compiler optimized the arithmetic of a source line.
+	OptHints bool
+	// Audit marks the security relevant instructions.
+	Audit bool
+	// Instrumentation dims or highlights the instructions added by the race
+	// detector, the sanitizers and the coverage instrumentation.
+	Instrumentation Instrumentation
+	// Dependencies marks the register dependencies between nearby instructions.
+	Dependencies bool
+	// Encoding notes the bytes of the hovered x86 instruction by their role.
+	Encoding bool
+	// InlineStack shows the inlined calls of the hovered instruction.
+	InlineStack bool
+	// Notes shows the notes of the selected func in a panel.
+	Notes bool
+	// Blame notes the last commit of the source lines from git blame.
+	Blame bool
+	// Coverage marks the executed instructions, ShowCoverage tints them.
+	Coverage     *disasm.CoverageProfile
+	ShowCoverage bool
+	// Patterns are labeled instruction sequences from -patterns.
+	Patterns []disasm.Pattern
+	// Marks are the addresses from -marks.
+	Marks []uint64
+	// CollapsePatterns shows the matched patterns as a single line.
+	CollapsePatterns bool
+	// SelectedOnly shows only the instructions of the selected source lines.
+	SelectedOnly bool
+
+	// Listing shows the code as a single column listing like objdump.
+	Listing bool
+	// NoJumps notes the jump targets instead of drawing the jump lines.
+	NoJumps bool
+	// NoLigatures draws the code without the ligatures of the font.
+	NoLigatures bool
+	// ShowPackages shows the package of each func in the lists.
+	ShowPackages bool
+	// HideSymbolList hides the func lists to give the code the full width.
+	HideSymbolList bool
+	// Compact removes most of the padding between the lines of the lists
+	// and the code, which fits more lines on small screens.
+	Compact bool
+
+	// FilterMode defines how the filters of the func lists match.
+	FilterMode FilterMode
+	// Exclude, when not nil, drops the matching funcs from all the lists.
+	Exclude *regexp.Regexp
+	// Select is a regexp for a func that is opened in a separate
+	// window after loading.
+	Select string
+	// SelectMain selects main.main, or the entry point, after loading.
+	SelectMain bool
+	// GrepAsm is a regexp for listing only funcs with matching instructions.
+	GrepAsm *regexp.Regexp
+	// Show limits the number of funcs initially listed.
+	Show int
+	// Preselect picks the func that is selected when there's no selection.
+	Preselect Preselect
+	// FollowCalls lists the callees of matched funcs up to this depth.
+	FollowCalls int
+	// Editor is the command for editing source files.
+	Editor string
+	// Compare is an executable whose funcs are shown side by side.
+	Compare string
+	// ChangesOnly shows only the instructions that differ from Compare.
+	ChangesOnly bool
+
+	// FollowPC is a file, or "-" for stdin, with a stream of addresses
+	// that are highlighted as they arrive.
+	FollowPC string
+
+	// SaveSession is the file where the session is written on close.
+	SaveSession string
 }
 
 type FileUI struct {
@@ -34,22 +154,123 @@ type FileUI struct {
 
 	LoadError error
 
+	// Preloaded is the file at Config.Path loaded before the window was
+	// opened, e.g. for the preflight, which is used instead of loading it.
+	Preloaded disasm.File
+
 	// Currently loaded executable.
 	File  disasm.File
 	Funcs *FilterList[disasm.Func]
+	// Groups contains a list for each filter, Funcs is the active one.
+	Groups []*FilterList[disasm.Func]
 
 	// Active code view.
 	Code CodeUI
+	// Compare contains the same func from Config.Compare.
+	Compare *Comparison
+	// Restore is a session that is restored after the file is loaded.
+	Restore *Session
+	// Aliases are the names assigned by the user to the funcs.
+	Aliases *Aliases
+	// Marks are the marked addresses, e.g. breakpoints.
+	Marks *Marks
+	// Notes are the notes of the user about the funcs.
+	Notes *Notes
 
 	// Other FileUI elements.
 	OpenInNew widget.Clickable
+	// Keys maps the keyboard shortcuts to actions.
+	Keys KeyBindings
+
+	// reload requests the file to be loaded again.
+	reload chan struct{}
+	// reloadedAt is the time when the file was last reloaded.
+	reloadedAt time.Time
+
+	// rename edits the alias of the selected func.
+	rename RenameUI
+	// gotoSym is the quick switcher for jumping to any func.
+	gotoSym GotoUI
+	// notesPanel edits the notes of the selected func for Config.Notes.
+	notesPanel NotesPanel
+
+	// frequency is the sidebar for Config.InstFrequency.
+	frequency InstFrequency
+	// data caches the data referenced by the hovered instruction for
+	// Config.DataView.
+	data struct {
+		file  disasm.File
+		addr  uint64
+		bytes []byte
+	}
+	// inline caches the inline stack of the hovered instruction for
+	// Config.InlineStack.
+	inline struct {
+		file   disasm.File
+		pc     uint64
+		frames []disasm.InlineFrame
+	}
+
+	// tabs are the funcs kept open in the tab strip.
+	tabs Tabs
+	// find is the find bar for searching the instructions.
+	find FindUI
+
+	// pcs finds the funcs for Config.FollowPC and the overview.
+	pcs pcIndex
+	// overview is the strip for Config.TextOverview.
+	overview TextOverview
+	// blame caches the git blame of the sources for Config.Blame.
+	blame *GitBlame
+
+	// follower lists the callees for Config.FollowCalls, followed is
+	// signalled after it found more calls.
+	follower *CallFollower
+	followed chan struct{}
+
+	// hottest is the search for the func of PreselectHottest, which is
+	// done once per file after the grep finished.
+	hottest struct {
+		cancel   context.CancelFunc
+		found    chan disasm.Func
+		done     chan struct{}
+		searched bool
+	}
+
+	// summaries are the runs of Summarize for the summary windows of
+	// the file, which are stopped before it's closed.
+	summaries struct {
+		ctx    context.Context
+		cancel context.CancelFunc
+		active sync.WaitGroup
+	}
+
+	// grep is the search for funcs that match Config.GrepAsm.
+	grep struct {
+		cancel  context.CancelFunc
+		results chan grepResult
+		// done is closed when the search has returned.
+		done chan struct{}
+	}
 }
 
 func NewExeUI(windows *Windows, theme *material.Theme) *FileUI {
 	ui := &FileUI{}
 	ui.Windows = windows
 	ui.Theme = theme
+	ui.Aliases = NewAliases(nil)
+	ui.Marks = NewMarks(nil)
+	ui.Notes = NewNotes(nil)
 	ui.Funcs = NewFilterList[disasm.Func](theme)
+	ui.Funcs.Aliases = ui.Aliases
+	ui.Funcs.Notes = ui.Notes
+	ui.Groups = []*FilterList[disasm.Func]{ui.Funcs}
+	ui.Keys = DefaultKeyBindings()
+	ui.reload = make(chan struct{}, 1)
+	ui.grep.results = make(chan grepResult)
+	ui.followed = make(chan struct{}, 1)
+	ui.hottest.found = make(chan disasm.Func)
+	ui.blame = NewGitBlame()
 	return ui
 }
 
@@ -58,6 +279,9 @@ func (ui *FileUI) Run(w *app.Window) error {
 
 	exited := make(chan struct{})
 	defer close(exited)
+	defer ui.stopGrep()
+	defer ui.stopFollower()
+	defer ui.stopHottest()
 
 	fileLoaded := make(chan disasm.File, 1)
 	fileLoadError := make(chan error, 1)
@@ -94,79 +318,316 @@ func (ui *FileUI) Run(w *app.Window) error {
 				}
 				lastModTime = stat.ModTime()
 
-				if workInProgressWASM {
-					loadFinished(wasmobj.Load(ui.Config.Path))
-				} else {
-					loadFinished(goobj.Load(ui.Config.Path))
-				}
+				loadFinished(ui.loadFile())
 			}()
 
-			if !ui.Config.Watch {
-				break
+			var watch <-chan time.Time
+			if ui.Config.Watch {
+				watch = tick.C
 			}
 
 			select {
-			case <-tick.C:
+			case <-watch:
+			case <-ui.reload:
+				lastModTime = time.Time{}
 			case <-exited:
 				return
 			}
 		}
 	}()
 
+	compareLoaded := make(chan comparisonLoaded, 1)
+	if ui.Config.Compare != "" {
+		ui.Compare = &Comparison{Path: ui.Config.Compare}
+		go func() {
+			file, err := LoadFile(ui.Config.Compare, "", ui.Config.ModuleCache)
+			compareLoaded <- comparisonLoaded{file: file, err: err}
+		}()
+		defer func() {
+			if ui.Compare.File != nil {
+				_ = ui.Compare.File.Close()
+			}
+		}()
+	}
+
+	followedPCs := make(chan uint64, 64)
+	if ui.Config.FollowPC != "" {
+		go func() {
+			r, err := openPCs(ui.Config.FollowPC)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return
+			}
+			defer func() { _ = r.Close() }()
+			if err := ReadPCs(r, followedPCs); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}()
+	}
+
+	selectPending := ui.Config.Select != ""
+	mainPending := ui.Config.SelectMain
 	for {
 		select {
+		case loaded := <-compareLoaded:
+			ui.Compare.SetFile(loaded.file, loaded.err)
+			w.Invalidate()
 		case err := <-fileLoadError:
 			ui.LoadError = err
 			w.Invalidate()
 		case file := <-fileLoaded:
+			if ui.File != nil {
+				ui.reloadedAt = time.Now()
+				ui.blame.Reset()
+			}
+			ui.LoadError = nil
 			ui.SetFile(file)
+			if ui.Restore != nil {
+				ui.restoreSession(ui.Restore, file)
+				ui.Restore = nil
+			}
+			if selectPending {
+				selectPending = false
+				ui.openSelect()
+			}
+			if mainPending {
+				mainPending = false
+				ui.selectMain()
+			}
+			w.Invalidate()
+		case pc := <-followedPCs:
+			ui.followPC(pc)
+			w.Invalidate()
+		case fn := <-ui.hottest.found:
+			ui.stopHottest()
+			if ui.Funcs.Selected == "" {
+				for i, listed := range ui.Funcs.Filtered {
+					if listed == fn {
+						ui.Funcs.SelectIndex(i)
+						break
+					}
+				}
+			}
+			w.Invalidate()
+		case <-ui.followed:
+			for _, group := range ui.Groups {
+				group.SetItems(group.All)
+			}
+			w.Invalidate()
+		case result := <-ui.grep.results:
+			if result.file != ui.File {
+				break
+			}
+			for _, group := range ui.Groups {
+				if result.fn != nil {
+					group.SetItems(append(group.All, result.fn))
+				}
+				group.Status = fmt.Sprintf("searched %d / %d", result.done, result.total)
+			}
+			if result.done == result.total {
+				ui.stopGrep()
+			}
 			w.Invalidate()
 		case e := <-w.Events():
 			switch e := e.(type) {
 			case system.FrameEvent:
-				gtx := layout.NewContext(&ops, e)
+				gtx := newContext(&ops, e)
 				ui.Layout(gtx)
 				e.Frame(gtx.Ops)
 
 			case system.DestroyEvent:
+				if ui.notesPanel.edited {
+					ui.saveNotes()
+				}
+				if ui.Config.SaveSession != "" {
+					ui.saveSession()
+				}
 				return e.Err
 			}
 		}
 	}
 }
 
+// saveSession writes the current session to Config.SaveSession.
+func (ui *FileUI) saveSession() {
+	session, err := ui.Session()
+	if err == nil {
+		err = SaveSession(ui.Config.SaveSession, session)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save session: %v\n", err)
+	}
+}
+
+// LoadFile loads the executable or object file at path, with the symbols
+// from debugFile when it's not empty and the sources from moduleCache.
+func LoadFile(path, debugFile, moduleCache string) (disasm.File, error) {
+	if workInProgressWASM {
+		file, err := wasmobj.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	file, err := goobj.LoadWithDebugFile(path, debugFile)
+	if err != nil {
+		return nil, err
+	}
+	if moduleCache != "" {
+		if err := file.UseModuleCache(moduleCache); err != nil {
+			fmt.Fprintln(os.Stderr, "using module cache:", err)
+		}
+	}
+	return file, nil
+}
+
+// NoFuncsError describes why filter didn't match any funcs of file, which
+// is distinct for executables without any funcs.
+func NoFuncsError(file disasm.File, filter string) error {
+	if len(file.Funcs()) == 0 {
+		return errors.New("the executable doesn't contain any funcs, it may be stripped")
+	}
+	return fmt.Errorf("filter %q did not match any funcs", filter)
+}
+
+// loadMatches loads config.Path for the exporters and returns the funcs
+// matching filter. It fails when none of them match, otherwise the caller
+// closes the file.
+func loadMatches(config FileUIConfig, filter string) (disasm.File, []disasm.Func, error) {
+	rx, err := CompileFilter(filter, FilterMode{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	matches := FilterItems(nil, file.Funcs(), rx, config.Exclude)
+	if len(matches) == 0 {
+		err := NoFuncsError(file, filter)
+		_ = file.Close()
+		return nil, nil, err
+	}
+	return file, matches, nil
+}
+
+// SetFilters creates a separate list for each of the filters.
+func (ui *FileUI) SetFilters(filters []string) {
+	if len(filters) == 0 {
+		filters = []string{""}
+	}
+	ui.Groups = nil
+	for i, filter := range filters {
+		group := NewFilterList[disasm.Func](ui.Theme)
+		if len(filters) > 1 {
+			group.Label = fmt.Sprintf("Filter %d", i+1)
+		}
+		group.Show = ui.Config.Show
+		group.Mode = ui.Config.FilterMode
+		group.Exclude = ui.Config.Exclude
+		group.Aliases = ui.Aliases
+		group.Notes = ui.Notes
+		group.SetFilter(filter)
+		ui.Groups = append(ui.Groups, group)
+	}
+	ui.Funcs = ui.Groups[0]
+}
+
 func (ui *FileUI) SetFile(file disasm.File) {
+	// the searches must not disassemble the closed file
+	ui.stopGrep()
+	ui.stopFollower()
+	ui.stopHottest()
+	ui.stopSummaries()
+	ui.hottest.searched = false
 	if ui.File != nil {
 		_ = ui.File.Close()
 	}
 	ui.File = file
-	ui.Funcs.SetItems(file.Funcs())
+	if ui.Config.FollowCalls > 0 {
+		ui.follower = NewCallFollower(file, ui.Config.FollowCalls, ui.Config.LoadOptions(), ui.followed)
+	}
+	for _, group := range ui.Groups {
+		if ui.follower != nil {
+			group.Expand = ui.follower.Expand
+		}
+		if ui.Config.GrepAsm != nil {
+			group.SetItems(nil)
+		} else {
+			group.SetItems(file.Funcs())
+		}
+	}
+	if ui.Config.GrepAsm != nil {
+		ui.startGrep()
+	}
+	ui.tabs.Resolve(file.Funcs())
 	if ui.Funcs.Selected != "" {
 		for _, fn := range file.Funcs() {
 			if fn.Name() == ui.Funcs.Selected {
-				ui.Code.Code = fn.Load(ui.loadOptions())
+				ui.Code.Code = fn.Load(ui.Config.LoadOptions())
 			}
 		}
 	}
 }
 
-func (ui *FileUI) loadOptions() disasm.Options {
-	return disasm.Options{Context: ui.Config.Context}
+// loadFile returns the Preloaded file once, otherwise it loads the file.
+func (ui *FileUI) loadFile() (disasm.File, error) {
+	if file := ui.Preloaded; file != nil {
+		ui.Preloaded = nil
+		return file, nil
+	}
+	return ui.Config.Load()
+}
+
+// Load loads the executable at Path.
+func (config *FileUIConfig) Load() (disasm.File, error) {
+	return LoadFile(config.Path, config.DebugFile, config.ModuleCache)
+}
+
+// LoadOptions returns the options for loading funcs.
+func (config *FileUIConfig) LoadOptions() disasm.Options {
+	return disasm.Options{
+		ContextBefore: config.ContextBefore,
+		ContextAfter:  config.ContextAfter,
+		MergeLines:    config.MergeLines,
+
+		MaxJumpLanes: config.MaxJumpLanes,
+		Anonymize:    config.Anonymize,
+		Offset:       config.Offset,
+		Length:       config.Length,
+
+		Coverage: config.Coverage,
+	}
 }
 
 func (ui *FileUI) Layout(gtx layout.Context) {
+	ui.handleKeys(gtx)
+
 	for ui.OpenInNew.Clicked() {
 		ui.openInNew(gtx)
 	}
+	for _, group := range ui.Groups {
+		for group.Cancel.Clicked() {
+			ui.cancelGrep()
+		}
+		if group.List.Submitted() && group == ui.Funcs {
+			// show the selected func from the start again
+			ui.Code.ResetScroll()
+		}
+		group.Cancellable = ui.grep.cancel != nil
+		group.ShowPackages = ui.Config.ShowPackages
+		group.Compact = ui.Config.Compact
+	}
 
 	if ui.Funcs.Selected == "" {
-		ui.Funcs.SelectIndex(0)
+		ui.preselect()
 	}
 
 	if !ui.Code.Loaded() || ui.Code.Name != ui.Funcs.Selected {
 		selected := ui.Funcs.SelectedItem
 		if selected != nil {
-			ui.Code.Code = selected.Load(ui.loadOptions())
+			ui.Code.Code = selected.Load(ui.Config.LoadOptions())
 		}
 	}
 
@@ -174,72 +635,465 @@ func (ui *FileUI) Layout(gtx layout.Context) {
 		Axis: layout.Horizontal,
 	}.Layout(gtx,
 		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if ui.Config.HideSymbolList {
+				return layout.Dimensions{}
+			}
 			gtx.Constraints = layout.Exact(image.Point{
 				X: gtx.Metric.Sp(10 * 20),
 				Y: gtx.Constraints.Max.Y,
 			})
-			return ui.Funcs.Layout(ui.Theme, gtx)
+			return ui.layoutGroups(gtx)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if ui.Config.HideSymbolList {
+				return layout.Dimensions{}
+			}
+			return VerticalLine{Width: palette.LineWidth, Color: palette.Splitter}.Layout(gtx)
 		}),
-		layout.Rigid(VerticalLine{Width: 1, Color: splitterColor}.Layout),
 		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
 			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if ui.LoadError != nil {
+						return layout.Dimensions{}
+					}
+					picked, dims := ui.tabs.Layout(ui.Theme, gtx, ui.Aliases, ui.Funcs.Selected, ui.tabHits())
+					if picked != nil {
+						ui.selectFunc(picked)
+						op.InvalidateOp{}.Add(gtx.Ops)
+					}
+					return dims
+				}),
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 					if ui.LoadError != nil {
 						return material.Body1(ui.Theme, ui.LoadError.Error()).Layout(gtx)
 					}
+					if ui.gotoSym.Active() && ui.File != nil {
+						fn, dims := ui.gotoSym.Layout(ui.Theme, gtx, ui.File.Funcs())
+						if fn != nil {
+							ui.gotoSym.Close()
+							ui.selectFunc(fn)
+						}
+						return dims
+					}
 					if !ui.Code.Loaded() {
+						if ui.File != nil && len(ui.Funcs.Filtered) == 0 {
+							filter := ui.Funcs.Filter.Text()
+							return material.Body1(ui.Theme, NoFuncsError(ui.File, filter).Error()).Layout(gtx)
+						}
 						return layout.Dimensions{}
 					}
-					txt := material.Body1(ui.Theme, ui.Code.Code.Name)
+					if ui.rename.Active() {
+						alias, submitted, dims := ui.rename.Layout(ui.Theme, gtx)
+						if submitted {
+							ui.setAlias(ui.rename.Name, alias)
+							ui.rename.Close()
+						}
+						return dims
+					}
+					header := ui.Code.Code.Name
+					if ui.Code.Signature != "" {
+						header = ui.Code.Signature
+					}
+					if alias, ok := ui.Aliases.Get(ui.Code.Code.Name); ok {
+						header = aliasMark + alias + "  " + header
+					}
+					if ui.Code.Marker != "" {
+						header += "  [" + ui.Code.Marker + "]"
+					}
+					txt := material.Body1(ui.Theme, header)
 					txt.TextSize *= 1.2
 
+					const reloadedDuration = 2 * time.Second
+					if since := gtx.Now.Sub(ui.reloadedAt); since < reloadedDuration {
+						txt.Text += " (reloaded)"
+						op.InvalidateOp{At: ui.reloadedAt.Add(reloadedDuration)}.Add(gtx.Ops)
+					}
+
 					inset := layout.Inset{Top: 4, Left: 4, Right: 4, Bottom: 2}
-					return inset.Layout(gtx, txt.Layout)
+					return ui.compactInset(inset).Layout(gtx, txt.Layout)
 				}),
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 					if ui.LoadError != nil || !ui.Code.Loaded() {
 						return layout.Dimensions{}
 					}
-					txt := material.Body1(ui.Theme, "file: "+ui.Code.Code.File)
+					info := "file: " + ui.Code.Code.File
+					if ui.Code.Package != "" {
+						info += "  package: " + ui.Code.Package
+					}
+					if ui.Code.Window != "" {
+						info += "  window: " + ui.Code.Window
+					}
+					if ui.Config.SelectedOnly && ui.Code.Selection.Valid(ui.Code.Code) {
+						info += "  only " + ui.Code.Selection.String()
+					}
+					if ui.Code.Memory.Valid(ui.Code.Code) {
+						info += "  memory " + ui.Code.Memory.Operand.String()
+					}
+					if features := disasm.DescribeFeatures(ui.Code.Features()); features != "" {
+						info += "  " + features
+					}
+					txt := material.Body1(ui.Theme, info)
 					txt.Font.Style = font.Italic
+					if n := ui.Code.Unsupported(); n > 0 {
+						txt.Text += fmt.Sprintf("  ⚠ %d unsupported, shown as raw bytes", n)
+						txt.Color = palette.Bad
+					}
 
 					inset := layout.Inset{Top: 2, Left: 4, Right: 4, Bottom: 4}
-					return inset.Layout(gtx, txt.Layout)
+					return ui.compactInset(inset).Layout(gtx, txt.Layout)
 				}),
-				layout.Rigid(HorizontalLine{Height: 1, Color: splitterColor}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if !ui.find.Active() || ui.LoadError != nil || !ui.Code.Loaded() {
+						return layout.Dimensions{}
+					}
+					submitted, dims := ui.find.Layout(ui.Theme, gtx, ui.findStatus())
+					if submitted {
+						ui.findNext(false)
+						op.InvalidateOp{}.Add(gtx.Ops)
+					}
+					return dims
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if !ui.Config.TextOverview || ui.LoadError != nil || ui.File == nil || ui.Funcs.SelectedItem == nil {
+						return layout.Dimensions{}
+					}
+					picked, dims := ui.overview.Layout(ui.Theme, gtx, &ui.pcs, ui.File, ui.Funcs.SelectedItem)
+					if picked != nil {
+						ui.selectFunc(picked)
+						op.InvalidateOp{}.Add(gtx.Ops)
+					}
+					return dims
+				}),
+				layout.Rigid(HorizontalLine{Height: palette.LineWidth, Color: palette.Splitter}.Layout),
 				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
 					if ui.LoadError != nil {
 						return layout.Dimensions{}
 					}
 
-					gtx.Constraints = layout.Exact(gtx.Constraints.Max)
-					return layout.Stack{
-						Alignment: layout.SE,
-					}.Layout(gtx,
-						layout.Expanded(func(gtx layout.Context) layout.Dimensions {
-							return CodeUIStyle{
-								CodeUI: &ui.Code,
+					children := []layout.FlexChild{layout.Flexed(1, ui.layoutCode)}
+					if reader, ok := ui.File.(disasm.DataReader); ok && ui.Config.DataView && ui.Code.DataPC != 0 {
+						children = append(children,
+							layout.Rigid(VerticalLine{Width: palette.LineWidth, Color: palette.Splitter}.Layout),
+							layout.Rigid(DataView{
+								Theme:      ui.Theme,
+								TextHeight: ui.Theme.TextSize * 8 / 10,
+								LineHeight: ui.Theme.TextSize,
+								Data:       ui.dataAt(reader),
+								Addr:       ui.Code.DataPC,
+							}.Layout),
+						)
+					}
+					if frames := ui.inlineStack(gtx); len(frames) > 0 {
+						children = append(children,
+							layout.Rigid(VerticalLine{Width: palette.LineWidth, Color: palette.Splitter}.Layout),
+							layout.Rigid(InlineView{
+								Theme:      ui.Theme,
+								TextHeight: ui.Theme.TextSize * 8 / 10,
+								LineHeight: ui.Theme.TextSize,
+								PC:         ui.Code.HoveredPC,
+								Frames:     frames,
+							}.Layout),
+						)
+					}
+					if ui.Config.Notes && ui.Funcs.Selected != "" {
+						children = append(children,
+							layout.Rigid(VerticalLine{Width: palette.LineWidth, Color: palette.Splitter}.Layout),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								save, dims := ui.notesPanel.Layout(ui.Theme, gtx, ui.Funcs.Selected, ui.Notes)
+								if save {
+									ui.saveNotes()
+								}
+								return dims
+							}),
+						)
+					}
+					if ui.Config.InstFrequency && ui.Code.Loaded() {
+						children = append(children,
+							layout.Rigid(VerticalLine{Width: palette.LineWidth, Color: palette.Splitter}.Layout),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return ui.frequency.Layout(ui.Theme, gtx, ui.Code.Code)
+							}),
+						)
+					}
+					return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, children...)
+				}),
+			)
+		}),
+	)
+}
+
+// dataAt returns the data referenced by the hovered instruction for
+// Config.DataView.
+func (ui *FileUI) dataAt(reader disasm.DataReader) []byte {
+	if ui.data.file != ui.File || ui.data.addr != ui.Code.DataPC {
+		ui.data.file = ui.File
+		ui.data.addr = ui.Code.DataPC
+		ui.data.bytes = readDataPrefix(reader, ui.Code.DataPC, dataViewSize)
+	}
+	return ui.data.bytes
+}
 
-								TryOpen: ui.tryOpen,
+// inlineStack returns the inline stack of the hovered instruction, when
+// Config.InlineStack is set. It's empty while the stacks are pending.
+func (ui *FileUI) inlineStack(gtx layout.Context) []disasm.InlineFrame {
+	stacker, ok := ui.File.(disasm.InlineStacker)
+	if !ok || !ui.Config.InlineStack || !ui.Code.Loaded() || ui.Code.HoveredPC == 0 {
+		return nil
+	}
+	if ui.inline.file != ui.File || ui.inline.pc != ui.Code.HoveredPC {
+		frames, pending := stacker.InlineStack(ui.Code.HoveredPC)
+		if pending {
+			op.InvalidateOp{At: gtx.Now.Add(inlinePollInterval)}.Add(gtx.Ops)
+			return nil
+		}
+		ui.inline.file = ui.File
+		ui.inline.pc = ui.Code.HoveredPC
+		ui.inline.frames = frames
+	}
+	return ui.inline.frames
+}
 
-								Theme:      ui.Theme,
-								TextHeight: ui.Theme.TextSize,
-								LineHeight: ui.Theme.TextSize * 1.2,
-							}.Layout(gtx)
-						}),
-						layout.Stacked(func(gtx layout.Context) layout.Dimensions {
-							button := material.IconButton(ui.Theme, &ui.OpenInNew, OpenInNewIcon, "Open in separate window")
-							button.Size = 16
-							button.Inset = layout.UniformInset(12)
-							return layout.UniformInset(2).Layout(gtx, button.Layout)
-						}),
-					)
+// layoutCode draws the code with the button for opening it in a new window.
+func (ui *FileUI) layoutCode(gtx layout.Context) layout.Dimensions {
+	gtx.Constraints = layout.Exact(gtx.Constraints.Max)
+	return layout.Stack{
+		Alignment: layout.SE,
+	}.Layout(gtx,
+		layout.Expanded(func(gtx layout.Context) layout.Dimensions {
+			style := ui.codeStyle(&ui.Code)
+			if ui.Compare == nil {
+				return style.Layout(gtx)
+			}
+			ui.Compare.Update(ui.Code.Name, ui.Config.LoadOptions())
+			compared := &ui.Compare.Code
+			if ui.Config.ChangesOnly {
+				if left, right := ui.Compare.Changes(ui.Code.Code); left != nil {
+					style.CodeUI, compared = left, right
+				}
+			}
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Flexed(1, style.Layout),
+				layout.Rigid(VerticalLine{Width: palette.LineWidth, Color: palette.Splitter}.Layout),
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					return ui.Compare.Layout(gtx, style, compared, ui.Code.Name)
 				}),
 			)
 		}),
+		layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+			button := material.IconButton(ui.Theme, &ui.OpenInNew, OpenInNewIcon, "Open in separate window")
+			button.Size = 16
+			button.Inset = layout.UniformInset(12)
+			return layout.UniformInset(2).Layout(gtx, button.Layout)
+		}),
 	)
 }
 
+// startGrep starts searching instructions in the current file,
+// cancelling any previous search.
+func (ui *FileUI) startGrep() {
+	ui.stopGrep()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	ui.grep.cancel, ui.grep.done = cancel, done
+	file, rx, opts := ui.File, ui.Config.GrepAsm, ui.Config.LoadOptions()
+	go func() {
+		defer close(done)
+		GrepAsm(ctx, file, rx, opts, ui.grep.results)
+	}()
+}
+
+// cancelGrep stops the search in progress at the user's request,
+// the funcs found so far remain listed.
+func (ui *FileUI) cancelGrep() {
+	if ui.grep.cancel != nil {
+		ui.stopGrep()
+		for _, group := range ui.Groups {
+			group.Status += " (cancelled)"
+		}
+	}
+}
+
+// stopGrep cancels the search in progress and waits for it to return.
+func (ui *FileUI) stopGrep() {
+	if ui.grep.cancel != nil {
+		ui.grep.cancel()
+		<-ui.grep.done
+		ui.grep.cancel, ui.grep.done = nil, nil
+	}
+}
+
+// stopFollower stops finding the calls for Config.FollowCalls.
+func (ui *FileUI) stopFollower() {
+	if ui.follower != nil {
+		ui.follower.Stop()
+		ui.follower = nil
+	}
+}
+
+// requestReload requests the file to be loaded again.
+func (ui *FileUI) requestReload() {
+	select {
+	case ui.reload <- struct{}{}:
+	default:
+	}
+}
+
+// layoutGroups draws the func lists and activates the focused one.
+func (ui *FileUI) layoutGroups(gtx layout.Context) layout.Dimensions {
+	if len(ui.Groups) == 1 {
+		return ui.Funcs.Layout(ui.Theme, gtx)
+	}
+
+	children := make([]layout.FlexChild, 0, 2*len(ui.Groups))
+	for i, group := range ui.Groups {
+		if i > 0 {
+			children = append(children, layout.Rigid(HorizontalLine{Height: palette.LineWidth, Color: palette.Splitter}.Layout))
+		}
+		group := group
+		children = append(children, layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min = gtx.Constraints.Max
+			return group.Layout(ui.Theme, gtx)
+		}))
+	}
+	dims := layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+
+	for _, group := range ui.Groups {
+		if group != ui.Funcs && (group.List.Focused() || group.Filter.Focused()) {
+			ui.Funcs = group
+			op.InvalidateOp{}.Add(gtx.Ops)
+		}
+	}
+	return dims
+}
+
+// handleKeys handles the keyboard shortcuts that are not handled by
+// the focused widget.
+func (ui *FileUI) handleKeys(gtx layout.Context) {
+	keys := ui.Keys.Set()
+	if ui.rename.Active() || ui.notesPanel.Editor.Focused() || ui.find.Active() {
+		keys += "|" + key.NameEscape
+	}
+	if ui.gotoSym.Active() {
+		keys += "|" + key.NameEscape + "|" + key.NameUpArrow + "|" + key.NameDownArrow + "|(Shift)-" + key.NameTab
+	} else if ui.listFocus() >= 0 {
+		keys += "|(Shift)-" + key.NameTab
+	}
+	key.InputOp{Tag: ui, Keys: keys}.Add(gtx.Ops)
+	for _, ev := range gtx.Events(ui) {
+		ev, ok := ev.(key.Event)
+		if !ok || ev.State != key.Press {
+			continue
+		}
+		if ui.rename.Active() && ev.Name == key.NameEscape {
+			ui.rename.Close()
+			continue
+		}
+		if ui.notesPanel.Editor.Focused() && ev.Name == key.NameEscape {
+			key.FocusOp{}.Add(gtx.Ops)
+			continue
+		}
+		if ui.gotoSym.Active() {
+			switch {
+			case ev.Name == key.NameEscape:
+				ui.gotoSym.Close()
+				continue
+			case ev.Name == key.NameUpArrow, ev.Name == key.NameTab && ev.Modifiers.Contain(key.ModShift):
+				ui.gotoSym.Move(-1)
+				continue
+			case ev.Name == key.NameDownArrow, ev.Name == key.NameTab:
+				ui.gotoSym.Move(1)
+				continue
+			}
+		}
+		if ev.Name == key.NameTab && !ui.gotoSym.Active() {
+			if ui.moveListFocus(ev.Modifiers.Contain(key.ModShift)) {
+				op.InvalidateOp{}.Add(gtx.Ops)
+				continue
+			}
+		}
+		if ui.find.Active() && ev.Name == key.NameEscape {
+			ui.find.Close()
+			continue
+		}
+		binding, ok := ui.Keys.Find(ev)
+		if !ok {
+			continue
+		}
+		// The filter editor receives the text separately,
+		// so avoid triggering shortcuts while typing.
+		if ui.filterFocused() && !binding.WhileTyping() {
+			continue
+		}
+		fileUIActions[binding.Action](ui, gtx)
+	}
+}
+
+// listFocus returns the position of the focused filter or list among the
+// func lists, where each group has its filter followed by its list, or -1
+// when none of them is focused.
+func (ui *FileUI) listFocus() int {
+	for i, group := range ui.Groups {
+		switch {
+		case group.Filter.Focused():
+			return 2 * i
+		case group.List.Focused():
+			return 2*i + 1
+		}
+	}
+	return -1
+}
+
+// moveListFocus moves the focus to the next filter or list of the func
+// lists, or to the previous one with backward. It reports whether one of
+// them was focused.
+func (ui *FileUI) moveListFocus(backward bool) bool {
+	current := ui.listFocus()
+	if current < 0 || ui.Config.HideSymbolList {
+		return false
+	}
+	count := 2 * len(ui.Groups)
+	next := (current + 1) % count
+	if backward {
+		next = (current + count - 1) % count
+	}
+	group := ui.Groups[next/2]
+	if next%2 == 0 {
+		group.Filter.Focus()
+	} else {
+		group.List.Focus()
+	}
+	return true
+}
+
+// filterFocused returns whether any of the filter editors is focused.
+func (ui *FileUI) filterFocused() bool {
+	if ui.rename.Active() || ui.gotoSym.Active() || ui.notesPanel.Editor.Focused() || ui.find.Active() {
+		return true
+	}
+	for _, group := range ui.Groups {
+		if group.Filter.Focused() {
+			return true
+		}
+	}
+	return false
+}
+
+// saveNotes stores the notes for the executable.
+func (ui *FileUI) saveNotes() {
+	ui.notesPanel.edited = false
+	if err := SaveNotes(ui.Config.Path, ui.Notes); err != nil {
+		ui.Funcs.Status = "saving notes: " + err.Error()
+	}
+}
+
+// setAlias assigns alias to the func name and stores it for the executable.
+func (ui *FileUI) setAlias(name, alias string) {
+	ui.Aliases.Set(name, alias)
+	if err := SaveAliases(ui.Config.Path, ui.Aliases); err != nil {
+		ui.Funcs.Status = "saving aliases: " + err.Error()
+	}
+}
+
 func (ui *FileUI) tryOpen(gtx layout.Context, call string) {
 	var fn disasm.Func
 	for _, target := range ui.File.Funcs() {
@@ -251,8 +1105,12 @@ func (ui *FileUI) tryOpen(gtx layout.Context, call string) {
 	if fn == nil {
 		return
 	}
+	ui.selectFunc(fn)
+}
 
-	load := fn.Load(ui.loadOptions())
+// selectFunc selects fn, even when it doesn't match the filter.
+func (ui *FileUI) selectFunc(fn disasm.Func) {
+	load := fn.Load(ui.Config.LoadOptions())
 	ui.Funcs.Selected = load.Name
 	ui.Funcs.SelectedItem = fn
 	ui.Funcs.List.Selected = -1
@@ -266,24 +1124,179 @@ func (ui *FileUI) tryOpen(gtx layout.Context, call string) {
 	ui.Code.Code = load
 
 	if ui.Funcs.Selected == "" {
-		ui.Funcs.SelectIndex(0)
+		ui.preselect()
 	}
 
 	ui.Code.ResetScroll()
 }
 
-func (ui *FileUI) openInNew(gtx layout.Context) {
-	state := ui.Code
-	style := CodeUIStyle{
-		Theme:  ui.Theme,
-		CodeUI: &state,
+// preselect selects the listed func that Config.Preselect picks. The
+// hottest func is searched in the background and selected when it's found,
+// the largest one is selected only when the search didn't select any.
+func (ui *FileUI) preselect() {
+	listed := ui.Funcs.Filtered[:ui.Funcs.visible()]
+	if ui.Config.Preselect == PreselectHottest && ui.Config.Coverage != nil && !ui.hottest.searched {
+		if ui.hottest.cancel == nil && ui.grep.cancel == nil && len(listed) > 0 {
+			ui.startHottest(append([]disasm.Func(nil), listed...))
+		}
+		return
+	}
+	ui.Funcs.SelectIndex(ui.Config.Preselect.Pick(listed))
+}
 
-		TextHeight: ui.Theme.TextSize,
-		LineHeight: ui.Theme.TextSize * 14 / 12,
+// startHottest starts the search for the hottest of funcs.
+func (ui *FileUI) startHottest(funcs []disasm.Func) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	ui.hottest.cancel, ui.hottest.done = cancel, done
+	ui.hottest.searched = true
+	opts := ui.Config.LoadOptions()
+	go func() {
+		defer close(done)
+		if i := HottestFunc(ctx, funcs, opts); i >= 0 {
+			select {
+			case ui.hottest.found <- funcs[i]:
+			case <-ctx.Done():
+			}
+		}
+	}()
+}
+
+// stopHottest cancels the search for the hottest func and waits for it
+// to return.
+func (ui *FileUI) stopHottest() {
+	if ui.hottest.cancel != nil {
+		ui.hottest.cancel()
+		<-ui.hottest.done
+		ui.hottest.cancel, ui.hottest.done = nil, nil
 	}
+}
 
+func (ui *FileUI) openInNew(gtx layout.Context) {
 	size := gtx.Constraints.Max
 	size.X = int(float32(size.X) / gtx.Metric.PxPerDp)
 	size.Y = int(float32(size.Y) / gtx.Metric.PxPerDp)
-	ui.Windows.Open(ui.Code.Name, size, WidgetWindow(style.Layout))
+	ui.openCodeInNew(ui.Code, size)
+}
+
+// openSelect opens the func matching Config.Select in a new window.
+func (ui *FileUI) openSelect() {
+	rx, err := CompileFilter(ui.Config.Select, FilterMode{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -select: %v\n", err)
+		return
+	}
+	matches := FilterItems(nil, ui.File.Funcs(), rx, ui.Config.Exclude)
+	if len(matches) != 1 {
+		fmt.Fprintf(os.Stderr, "-select %q matched %d funcs, expected 1\n", ui.Config.Select, len(matches))
+		return
+	}
+
+	ui.selectFunc(matches[0])
+	ui.openCodeInNew(ui.Code, image.Pt(1000, 800))
+}
+
+// OpenSingle opens only the code of the single func matching filter in
+// a window, without the func lists.
+func (ui *FileUI) OpenSingle(filter string) error {
+	rx, err := CompileFilter(filter, FilterMode{})
+	if err != nil {
+		return err
+	}
+	file, err := ui.loadFile()
+	if err != nil {
+		return err
+	}
+
+	matches := FilterItems(nil, file.Funcs(), rx, ui.Config.Exclude)
+	if len(matches) != 1 {
+		_ = file.Close()
+		names := make([]string, 0, preflightNames)
+		for _, fn := range matches {
+			if len(names) >= preflightNames {
+				names = append(names, "...")
+				break
+			}
+			names = append(names, fn.Name())
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("-single needs -filter to match a single func, matched none")
+		}
+		return fmt.Errorf("-single needs -filter to match a single func, matched %d: %s", len(matches), strings.Join(names, ", "))
+	}
+
+	ui.File = file
+	ui.selectFunc(matches[0])
+	ui.openCodeInNew(ui.Code, image.Pt(1000, 800))
+	return nil
+}
+
+// openCodeInNew opens the code in a new window with the specified size in dp.
+func (ui *FileUI) openCodeInNew(state CodeUI, sizeDp image.Point) {
+	style := ui.codeStyle(&state)
+	style.TryOpen = nil
+	if !ui.Config.Compact {
+		style.LineHeight = ui.Theme.TextSize * 14 / 12
+	}
+	ui.Windows.Open(ui.Aliases.Display(state.Name), sizeDp, WidgetWindow(style.Layout))
+}
+
+// codeStyle returns the style for drawing state using the current config.
+func (ui *FileUI) codeStyle(state *CodeUI) CodeUIStyle {
+	highlight := ui.Config.GrepAsm
+	if rx := ui.find.Regexp(); rx != nil {
+		highlight = rx
+	}
+	reader, _ := ui.File.(disasm.DataReader)
+	var blame *GitBlame
+	if ui.Config.Blame {
+		blame = ui.blame
+	}
+	lineHeight := ui.Theme.TextSize * 1.2
+	if ui.Config.Compact {
+		lineHeight = ui.Theme.TextSize * compactLineHeight
+	}
+	return CodeUIStyle{
+		CodeUI: state,
+
+		TryOpen:    ui.tryOpen,
+		SourceJump: ui.Config.SourceJump,
+		Highlight:  highlight,
+
+		VectorLanes: ui.Config.VectorLanes,
+		Addresses:   ui.Config.Addresses,
+		Immediates:  ui.Config.Immediates,
+		JumpTargets: ui.Config.JumpTargets,
+		LongLines:   ui.Config.LongLines,
+		OptHints:    ui.Config.OptHints,
+		Coverage:    ui.Config.ShowCoverage,
+		Blame:       blame,
+		Audit:       ui.Config.Audit,
+		Aliases:     ui.Aliases,
+		Marks:       ui.Marks,
+		Listing:     ui.Config.Listing,
+		NoJumps:     ui.Config.NoJumps,
+		NoLigatures: ui.Config.NoLigatures,
+		Bytes:       reader,
+
+		Patterns:         ui.Config.Patterns,
+		CollapsePatterns: ui.Config.CollapsePatterns,
+		Dependencies:     ui.Config.Dependencies,
+		Instrumentation:  ui.Config.Instrumentation,
+		SelectedOnly:     ui.Config.SelectedOnly,
+		Encoding:         ui.Config.Encoding,
+		SelectScroll:     ui.Config.SelectScroll,
+
+		Theme:      ui.Theme,
+		TextHeight: ui.Theme.TextSize,
+		LineHeight: lineHeight,
+	}
+}
+
+// compactInset removes the vertical padding of inset in the compact mode.
+func (ui *FileUI) compactInset(inset layout.Inset) layout.Inset {
+	if ui.Config.Compact {
+		inset.Top, inset.Bottom = 0, 0
+	}
+	return inset
 }
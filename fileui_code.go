@@ -4,11 +4,17 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"gioui.org/f32"
 	"gioui.org/gesture"
+	"gioui.org/io/clipboard"
+	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
 	"gioui.org/op"
@@ -19,7 +25,6 @@ import (
 	"gioui.org/widget/material"
 
 	"loov.dev/lensm/internal/disasm"
-	"loov.dev/lensm/internal/f32color"
 )
 
 type CodeUI struct {
@@ -30,6 +35,11 @@ type CodeUI struct {
 		gesture gesture.Scroll
 		bar     widget.Scrollbar
 		anim    ScrollAnimation
+
+		hscroll  float32
+		hgesture gesture.Scroll
+
+		rows asmRows
 	}
 	src struct {
 		scroll  float32
@@ -37,6 +47,75 @@ type CodeUI struct {
 		bar     widget.Scrollbar
 	}
 
+	// PinnedFile limits the source pane to a single file, when it's
+	// used by the code.
+	PinnedFile string
+	// Selection is the source lines selected by clicking, which limit
+	// the asm pane with SelectedOnly.
+	Selection SourceSelection
+	// Memory is the memory location selected by clicking an instruction.
+	Memory MemorySlot
+
+	// LinkScroll scrolls the asm and source panes together.
+	LinkScroll bool
+	// LinkSource scrolls the panes together, keeping the source line in
+	// the middle of the view aligned with its instructions.
+	LinkSource bool
+	// linkDriver is the pane scrolled by the user when LinkSource is set.
+	linkDriver scrollPane
+
+	// DataPC is the data referenced by the last hovered instruction.
+	DataPC uint64
+	// HoveredPC is the last hovered instruction.
+	HoveredPC uint64
+
+	// CurrentPC is the highlighted instruction, e.g. from -follow-pc.
+	CurrentPC uint64
+	// scrollToCurrent scrolls to CurrentPC on the next layout.
+	scrollToCurrent bool
+	// scrollToSelection positions the panes of newly selected code by
+	// CodeUIStyle.SelectScroll on the next layout.
+	scrollToSelection bool
+	// scrollToSourceSelection scrolls the source pane to the selected
+	// source lines on the next layout, e.g. after pinning a file.
+	scrollToSourceSelection bool
+
+	// listing caches the rows of the objdump style listing.
+	listing struct {
+		code       *disasm.Code
+		immediates ImmediateBase
+		lines      []string
+	}
+
+	// deps caches the register dependencies of the code.
+	deps struct {
+		code *disasm.Code
+		list []disasm.Dependency
+	}
+
+	// instrumentation caches the instructions added by the instrumentation.
+	instrumentation struct {
+		code   *disasm.Code
+		marked []bool
+	}
+
+	// labeled caches the code with the patterns labeled.
+	labeled struct {
+		source   *disasm.Code
+		collapse bool
+		code     *disasm.Code
+	}
+
+	// selectedOnly caches the code with the unselected lines folded.
+	selectedOnly struct {
+		source    *disasm.Code
+		selection SourceSelection
+		code      *disasm.Code
+	}
+	// shown is the code drawn by the last layout, which differs from
+	// Code when the patterns are labeled or the selection is folded.
+	shown *disasm.Code
+
 	mousePosition f32.Point
 }
 
@@ -44,9 +123,145 @@ func (ui *CodeUI) Loaded() bool {
 	return ui.Code != nil
 }
 
+// Sources returns the sources that should be shown.
+func (ui *CodeUI) Sources() []disasm.Source {
+	if ui.PinnedFile == "" {
+		return ui.Code.Source
+	}
+	for i := range ui.Code.Source {
+		if ui.Code.Source[i].File == ui.PinnedFile {
+			return ui.Code.Source[i : i+1]
+		}
+	}
+	return ui.Code.Source
+}
+
+// TogglePin pins the source pane to file or unpins it.
+func (ui *CodeUI) TogglePin(file string) {
+	if ui.PinnedFile == file {
+		ui.PinnedFile = ""
+	} else {
+		ui.PinnedFile = file
+	}
+	ui.src.scroll = 0
+	ui.scrollToSourceSelection = true
+}
+
+// FollowPC highlights the instruction at pc and scrolls to it.
+func (ui *CodeUI) FollowPC(pc uint64) {
+	ui.CurrentPC = pc
+	ui.scrollToCurrent = true
+}
+
+// NextReturn highlights the first return instruction after the current
+// instruction, or the first one after the last, and scrolls to it. It
+// returns the position of the return and the number of returns.
+func (ui *CodeUI) NextReturn() (int, int) {
+	returns := ui.Code.Returns()
+	if len(returns) == 0 {
+		return 0, 0
+	}
+	next := 0
+	for k, i := range returns {
+		if ui.Code.Insts[i].PC > ui.CurrentPC {
+			next = k
+			break
+		}
+	}
+	ui.FollowPC(ui.Code.Insts[returns[next]].PC)
+	return next + 1, len(returns)
+}
+
+// scrollAsm scrolls the asm pane by distance pixels,
+// and the source pane as well when they are linked.
+func (ui *CodeUI) scrollAsm(distance float32) {
+	ui.asm.scroll -= distance
+	if ui.LinkSource {
+		ui.linkDriver = paneAsm
+	} else if ui.LinkScroll {
+		ui.src.scroll -= distance
+	}
+}
+
+// scrollSource scrolls the source pane by distance pixels,
+// and the asm pane as well when they are linked.
+func (ui *CodeUI) scrollSource(distance float32) {
+	ui.src.scroll -= distance
+	if ui.LinkSource {
+		ui.linkDriver = paneSource
+	} else if ui.LinkScroll {
+		ui.asm.anim.Stop()
+		ui.asm.scroll -= distance
+	}
+}
+
+// scrollPane is one of the scrolled panes of the code.
+type scrollPane byte
+
+const (
+	paneNone scrollPane = iota
+	paneAsm
+	paneSource
+)
+
+// sourceAnchor is a source line and the instructions compiled from it.
+type sourceAnchor struct {
+	// top is the position of the line in the source pane content.
+	top    int
+	ranges []disasm.LineRange
+	// relation picks the color of the relation to the instructions.
+	relation int
+}
+
+// alignLinkedSource scrolls the pane opposite of the one scrolled by the
+// user, so that the source line in the middle of the view is at the same
+// height as its first instruction. The anchors must be sorted by top.
+func (ui *CodeUI) alignLinkedSource(anchors []sourceAnchor, rows *asmRows, lineHeight, height int) {
+	driver := ui.linkDriver
+	ui.linkDriver = paneNone
+	if driver == paneNone || len(anchors) == 0 {
+		return
+	}
+
+	anchor, from := anchors[0], anchors[0].ranges[0].From
+	switch driver {
+	case paneSource:
+		center := int(-ui.src.scroll) + height/2
+		for _, candidate := range anchors {
+			if candidate.top > center {
+				break
+			}
+			anchor, from = candidate, candidate.ranges[0].From
+		}
+		ui.asm.anim.Stop()
+		ui.asm.scroll = ui.src.scroll + float32(anchor.top-rows.Top(from)*lineHeight)
+
+	case paneAsm:
+		center := rows.Index(int(-ui.asm.scroll+float32(height/2)) / lineHeight)
+		best := -1
+		for _, candidate := range anchors {
+			for _, r := range candidate.ranges {
+				distance := 0
+				if center < r.From {
+					distance = r.From - center
+				} else if center >= r.To {
+					distance = center - r.To + 1
+				}
+				if best < 0 || distance < best {
+					anchor, from, best = candidate, r.From, distance
+				}
+			}
+		}
+		ui.src.scroll = ui.asm.scroll + float32(rows.Top(from)*lineHeight-anchor.top)
+	}
+}
+
+// ResetScroll positions the panes for newly selected code on the next
+// layout, see SelectScroll.
 func (ui *CodeUI) ResetScroll() {
-	ui.asm.scroll = 100000
-	ui.src.scroll = 100000
+	ui.asm.anim.Stop()
+	ui.asm.scroll, ui.src.scroll = 0, 0
+	ui.scrollToSelection = true
 }
 
 type CodeUIStyle struct {
@@ -55,6 +270,65 @@ type CodeUIStyle struct {
 	TryOpen func(gtx layout.Context, funcname string)
 	Theme   *material.Theme
 
+	// SourceJump is the minimum distance in lines between the sources of
+	// consecutive instructions that is marked with a separator.
+	// Zero disables the separators.
+	SourceJump int
+	// Highlight marks the instructions that match.
+	Highlight *regexp.Regexp
+	// VectorLanes annotates vector registers with their lanes.
+	VectorLanes bool
+	// Addresses defines how instruction addresses are shown.
+	Addresses AddressMode
+	// Immediates defines the base of immediate operands.
+	Immediates ImmediateBase
+	// JumpTargets defines how the targets of the jumps are shown.
+	JumpTargets JumpTargets
+	// LongLines defines how asm lines wider than the column are shown.
+	LongLines LongLines
+	// OptHints notes where the compiler used a different operation
+	// than the arithmetic of the source line suggests.
+	OptHints bool
+	// Coverage tints the instructions by their disasm.Coverage.
+	Coverage bool
+	// Blame, when not nil, notes the last commit of each source line.
+	Blame *GitBlame
+	// Audit marks the security relevant instructions.
+	Audit bool
+	// Instrumentation defines how the instructions added by the race
+	// detector, the sanitizers and the coverage instrumentation are shown.
+	Instrumentation Instrumentation
+	// Dependencies connects the instructions that write a register with
+	// the nearby instructions that read it.
+	Dependencies bool
+	// NoJumps hides the jump lines and notes the jump targets after the
+	// instructions instead, which leaves more room for the code.
+	NoJumps bool
+	// NoLigatures draws the code character by character, because the
+	// ligatures of some fonts make the operands hard to read.
+	NoLigatures bool
+	// Listing shows a single column listing like objdump instead of
+	// the source correlated view, which includes the instruction bytes
+	// read from Bytes, when it's not nil.
+	Listing bool
+	Bytes   disasm.DataReader
+	// Aliases replace the names of the called funcs.
+	Aliases *Aliases
+	// Marks are drawn as dots in front of the instructions.
+	Marks *Marks
+	// Patterns are labeled instruction sequences, which are collapsed
+	// into a single line when CollapsePatterns is set.
+	Patterns         []disasm.Pattern
+	CollapsePatterns bool
+	// SelectedOnly folds the instructions that weren't compiled from the
+	// selected source lines.
+	SelectedOnly bool
+	// Encoding notes the prefixes, opcode, ModRM, SIB, displacement and
+	// immediate of the hovered x86 instruction, read from Bytes.
+	Encoding bool
+	// SelectScroll positions the panes after ResetScroll.
+	SelectScroll SelectScroll
+
 	TextHeight unit.Sp
 	LineHeight unit.Sp
 }
@@ -65,20 +339,44 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 		return layout.Dimensions{Size: gtx.Constraints.Max}
 	}
 
+	if len(ui.Patterns) > 0 {
+		// draw the labeled code, while keeping the rest of the state
+		source := ui.CodeUI.Code
+		ui.CodeUI.Code = ui.labeledCode(source)
+		defer func() { ui.CodeUI.Code = source }()
+	}
+	if ui.SelectedOnly && ui.Selection.Valid(ui.CodeUI.Code) {
+		source := ui.CodeUI.Code
+		ui.CodeUI.Code = ui.selectedCode(source)
+		defer func() { ui.CodeUI.Code = source }()
+	}
+
 	defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
 
-	mouseClicked := false
+	if ui.Listing {
+		return ui.layoutListing(gtx)
+	}
+
+	mouseClicked, mouseCopied, mouseExtend := false, false, false
 	pointer.InputOp{
 		Tag:   ui.Code,
-		Types: pointer.Move | pointer.Press,
+		Types: pointer.Move | pointer.Press | pointer.Leave,
 	}.Add(gtx.Ops)
 	for _, ev := range gtx.Queue.Events(ui.Code) {
 		if ev, ok := ev.(pointer.Event); ok {
 			switch ev.Type {
 			case pointer.Move:
 				ui.mousePosition = ev.Position
+			case pointer.Leave:
+				ui.mousePosition = f32.Pt(-1, -1)
 			case pointer.Press:
-				mouseClicked = true
+				// the secondary button copies the address instead of following
+				if ev.Buttons == pointer.ButtonSecondary {
+					mouseCopied = true
+				} else {
+					mouseClicked = true
+					mouseExtend = ev.Modifiers.Contain(key.ModShift)
+				}
 			}
 		}
 	}
@@ -90,6 +388,14 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 	pad := lineHeight
 	jumpStep := lineHeight / 2
 	jumpWidth := jumpStep * ui.Code.MaxJump
+	if n := overflowLabelLen(ui.Code); n > 0 {
+		// the labels of the jumps that didn't fit are drawn in the lanes
+		labelWidth := n * monospaceAdvance(ui.Theme, gtx, ui.TextHeight*8/10)
+		jumpWidth = max(jumpWidth, labelWidth)
+	}
+	if ui.NoJumps {
+		jumpWidth = 0
+	}
 	gutterWidth := lineHeight * 8
 	blocksWidth := gtx.Constraints.Max.X - gutterWidth - jumpWidth - 4*pad - pad/2
 
@@ -98,14 +404,57 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 	gutter := BoundsWidth(int(asm.Max)+pad, gutterWidth)
 	source := BoundsWidth(int(gutter.Max)+pad, blocksWidth*7/10)
 
+	addrStart, addrWidth := addressWidth(ui.Code, ui.Addresses)
+	asmTextWidth := int(gutter.Min) - int(asm.Min) - pad/2
+	advance := monospaceAdvance(ui.Theme, gtx, ui.TextHeight)
+	rows := &ui.asm.rows
+	// the indices differ between the collapsed and the full code, hence
+	// the instruction at the top is kept in place by its address
+	topPC, topOffset, keepTop := ui.topInst(rows, lineHeight)
+	rows.Update(ui, addrStart, addrWidth, asmTextWidth/advance)
+	if keepTop {
+		if i := instAt(ui.Code, topPC); i >= 0 {
+			ui.asm.scroll = topOffset - float32(rows.Top(i)*lineHeight)
+		}
+	}
+	ui.shown = ui.Code
+	// rowY returns the top of the instruction i.
+	rowY := func(i int) float32 {
+		return float32(rows.Top(i)*lineHeight) + ui.asm.scroll
+	}
+
 	// draw gutter
-	paint.FillShape(gtx.Ops, f32color.Gray8(0xE8), clip.Rect{
+	paint.FillShape(gtx.Ops, palette.Gutter, clip.Rect{
 		Min: image.Pt(int(gutter.Min), 0),
 		Max: image.Pt(int(gutter.Max), gtx.Constraints.Max.Y),
 	}.Op())
 
+	if ui.scrollToSelection {
+		ui.scrollToSelection = false
+		ui.positionSelection(ui.SelectScroll, rows, lineHeight, gtx.Constraints.Max.Y)
+	}
+	if ui.scrollToSourceSelection {
+		ui.scrollToSourceSelection = false
+		if top, ok := ui.selectionTop(lineHeight); ok {
+			ui.src.scroll = float32(lineHeight - top)
+		}
+	}
+
+	current := -1
+	if ui.CurrentPC != 0 {
+		current = instAt(ui.Code, ui.CurrentPC)
+	}
+	if current >= 0 && ui.scrollToCurrent {
+		ui.scrollToCurrent = false
+		target := float32(gtx.Constraints.Max.Y/2 - rows.Top(current)*lineHeight)
+		ui.asm.anim.Start(gtx, ui.asm.scroll, target, 100*time.Millisecond)
+	}
+
 	if scroll, ok := ui.asm.anim.Update(gtx); ok {
 		ui.asm.scroll = scroll
+		if ui.LinkSource {
+			ui.linkDriver = paneAsm
+		}
 	}
 
 	mousePosition := ui.mousePosition
@@ -113,12 +462,32 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 	mouseInSource := source.Contains(mousePosition.X)
 	highlightAsmIndex := -1
 	if mouseInAsm {
-		highlightAsmIndex = int(mousePosition.Y-ui.asm.scroll) / lineHeight
+		highlightAsmIndex = rows.Index(int(mousePosition.Y-ui.asm.scroll) / lineHeight)
 	}
 	var highlightRanges []disasm.LineRange
 
+	// Only the visible lines are laid out to keep large funcs responsive.
+	visibleAsm := disasm.LineRange{
+		From: rows.Index(int(-ui.asm.scroll)/lineHeight) - 1,
+		To:   rows.Index(int(-ui.asm.scroll+float32(gtx.Constraints.Max.Y))/lineHeight) + 2,
+	}
+	sourceVisible := func(top int) bool {
+		return -lineHeight <= top && top < gtx.Constraints.Max.Y+lineHeight
+	}
+
 	if InRange(highlightAsmIndex, len(ui.Code.Insts)) {
 		ix := &ui.Code.Insts[highlightAsmIndex]
+		if ix.DataPC != 0 && ix.DataPC != ui.DataPC {
+			ui.DataPC = ix.DataPC
+			op.InvalidateOp{}.Add(gtx.Ops)
+		}
+		if ix.Text != "" && ix.PC != ui.HoveredPC {
+			ui.HoveredPC = ix.PC
+			op.InvalidateOp{}.Add(gtx.Ops)
+		}
+		if mouseCopied && ix.DataPC != 0 {
+			clipboard.WriteOp{Text: formatAddress(ix.DataPC)}.Add(gtx.Ops)
+		}
 		if ui.TryOpen != nil && ix.Call != "" {
 			pointer.CursorPointer.Add(gtx.Ops)
 			if mouseClicked {
@@ -130,76 +499,70 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 			if mouseClicked {
 				// TODO: smooth scroll
 				// highlightAsmIndex -= ix.RefOffset
-				ui.asm.anim.Start(gtx, ui.asm.scroll, ui.asm.scroll-float32(ix.RefOffset*lineHeight), 150*time.Millisecond)
+				ui.asm.anim.Start(gtx, ui.asm.scroll, ui.asm.scroll-(rowY(highlightAsmIndex+ix.RefOffset)-rowY(highlightAsmIndex)), 150*time.Millisecond)
 			}
 		}
+		if mouseClicked && ix.Call == "" && ix.RefOffset == 0 && ix.Text != "" {
+			// clicking other instructions selects their memory operand
+			ui.SelectMemory(ui.Code, ix)
+			op.InvalidateOp{}.Add(gtx.Ops)
+		}
 	}
 
 	// relations underlay
-	top := int(ui.src.scroll)
+	anchors := ui.sourceAnchors(lineHeight)
 	var highlightPath *clip.PathSpec
 	var highlightColor color.NRGBA
-	for i, src := range ui.Code.Source {
-		if i > 0 {
-			top += lineHeight
-		}
-		top += lineHeight
-		for i, block := range src.Blocks {
-			if i > 0 {
-				top += lineHeight
+	for _, anchor := range anchors {
+		top, ranges := anchor.top+int(ui.src.scroll), anchor.ranges
+		if sourceVisible(top) || disasm.LineRangesIntersect(ranges, visibleAsm) {
+			highlight := false
+			if mouseInSource {
+				if float32(top) <= mousePosition.Y && mousePosition.Y < float32(top+lineHeight) {
+					highlight = true
+					highlightRanges = ranges
+				}
 			}
-			for off, ranges := range block.Related {
-				if len(ranges) > 0 {
-					highlight := false
-					if mouseInSource {
-						if float32(top) <= mousePosition.Y && mousePosition.Y < float32(top+lineHeight) {
-							highlight = true
-							highlightRanges = ranges
-						}
-					}
 
-					var p clip.Path
-					p.Begin(gtx.Ops)
-					p.MoveTo(f32.Pt(gutter.Max, float32(top+lineHeight)))
-					p.LineTo(f32.Pt(source.Max, float32(top+lineHeight)))
-					p.LineTo(f32.Pt(source.Max, float32(top)))
-					p.LineTo(f32.Pt(gutter.Max, float32(top)))
-					pin := float32(top)
-					for i, r := range ranges {
-						if mouseInAsm {
-							if float32(r.From*lineHeight)+ui.asm.scroll <= mousePosition.Y && mousePosition.Y < float32(r.To*lineHeight)+ui.asm.scroll {
-								highlight = true
-								highlightRanges = ranges
-							}
-						}
-						const S = 0.1
-						p.CubeTo(
-							f32.Pt(gutter.Lerp(0.5-S), pin),
-							f32.Pt(gutter.Lerp(0.5+S), float32(r.From*lineHeight)+ui.asm.scroll),
-							f32.Pt(gutter.Min, float32(r.From*lineHeight)+ui.asm.scroll))
-						p.LineTo(f32.Pt(asm.Min, float32(r.From*lineHeight)+ui.asm.scroll))
-						p.LineTo(f32.Pt(asm.Min, float32(r.To*lineHeight)+ui.asm.scroll))
-						p.LineTo(f32.Pt(gutter.Min, float32(r.To*lineHeight)+ui.asm.scroll))
-						pin = float32(top) + float32(lineHeight)*float32(i+1)/float32(len(ranges))
-						p.CubeTo(
-							f32.Pt(gutter.Lerp(0.5+S), float32(r.To*lineHeight)+ui.asm.scroll),
-							f32.Pt(gutter.Lerp(0.5-S), pin),
-							f32.Pt(gutter.Max, pin))
-					}
-					alpha := float32(0.4)
-					pathSpec := p.End()
-					if highlight {
-						alpha = 0.8
-					}
-					relationColor := f32color.HSLA(float32(math.Mod(float64((i+1)*(off+1))*math.Phi, 1)), 0.9, 0.8, alpha)
-					if !highlight {
-						paint.FillShape(gtx.Ops, relationColor, clip.Outline{Path: pathSpec}.Op())
-					} else {
-						highlightPath = &pathSpec
-						highlightColor = relationColor
+			var p clip.Path
+			p.Begin(gtx.Ops)
+			p.MoveTo(f32.Pt(gutter.Max, float32(top+lineHeight)))
+			p.LineTo(f32.Pt(source.Max, float32(top+lineHeight)))
+			p.LineTo(f32.Pt(source.Max, float32(top)))
+			p.LineTo(f32.Pt(gutter.Max, float32(top)))
+			pin := float32(top)
+			for i, r := range ranges {
+				if mouseInAsm {
+					if rowY(r.From) <= mousePosition.Y && mousePosition.Y < rowY(r.To) {
+						highlight = true
+						highlightRanges = ranges
 					}
 				}
-				top += lineHeight
+				const S = 0.1
+				p.CubeTo(
+					f32.Pt(gutter.Lerp(0.5-S), pin),
+					f32.Pt(gutter.Lerp(0.5+S), rowY(r.From)),
+					f32.Pt(gutter.Min, rowY(r.From)))
+				p.LineTo(f32.Pt(asm.Min, rowY(r.From)))
+				p.LineTo(f32.Pt(asm.Min, rowY(r.To)))
+				p.LineTo(f32.Pt(gutter.Min, rowY(r.To)))
+				pin = float32(top) + float32(lineHeight)*float32(i+1)/float32(len(ranges))
+				p.CubeTo(
+					f32.Pt(gutter.Lerp(0.5+S), rowY(r.To)),
+					f32.Pt(gutter.Lerp(0.5-S), pin),
+					f32.Pt(gutter.Max, pin))
+			}
+			alpha := float32(0.4)
+			pathSpec := p.End()
+			if highlight {
+				alpha = 0.8
+			}
+			relationColor := palette.RelationColor(anchor.relation, alpha)
+			if !highlight {
+				paint.FillShape(gtx.Ops, relationColor, clip.Outline{Path: pathSpec}.Op())
+			} else {
+				highlightPath = &pathSpec
+				highlightColor = relationColor
 			}
 		}
 	}
@@ -211,50 +574,217 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 	// assembly
 	asmClip := clip.Rect{
 		Min: image.Pt(int(jump.Min), 0),
-		Max: image.Pt(int(gutter.Min), gtx.Constraints.Max.Y),
+		Max: image.Pt(int(gutter.Max), gtx.Constraints.Max.Y),
 	}.Push(gtx.Ops)
-	for i, ix := range ui.Code.Insts {
-		SourceLine{
-			TopLeft:    image.Pt(int(asm.Min)+pad/2, i*lineHeight+int(ui.asm.scroll)),
-			Text:       ix.Text,
-			TextHeight: ui.TextHeight,
-			Italic:     ix.Call != "",
-			Bold:       highlightAsmIndex == i,
-			Color:      f32color.Black,
-		}.Layout(ui.Theme, gtx)
-
-		// jump line
-		if ix.RefOffset != 0 {
-			lineWidth := gtx.Metric.Dp(1)
+	var prevInst *disasm.Inst
+	longestText := 0
+	memorySelected := ui.Memory.Valid(ui.Code)
+	for i := range ui.Code.Insts {
+		ix := &ui.Code.Insts[i]
+		if ix.Text == "" {
+			continue
+		}
+		prev := prevInst
+		prevInst = ix
+
+		// jump line, which needs to be drawn even when only the target is visible
+		targets := ix.Targets()
+		if ui.NoJumps {
+			// the targets are noted after the instruction
+		} else if len(targets) > 0 && ix.RefOverflow {
+			// jump didn't fit into the lanes, only mark the endpoints
+			jumpColor := palette.JumpColor(ix.PC, 1)
+			marks := []jumpMark{{i, jumpNote(ui.Code, i)}}
+			for _, off := range targets {
+				marks = append(marks, jumpMark{i + off, fmt.Sprintf("← 0x%x", ix.PC)})
+			}
+			for _, mark := range marks {
+				if visibleAsm.Contains(mark.row) {
+					SourceLine{
+						TopLeft:    image.Pt(int(jump.Min), int(rowY(mark.row))),
+						Width:      int(jump.Max - jump.Min),
+						Text:       mark.text,
+						TextHeight: ui.TextHeight * 8 / 10,
+						Bold:       highlightAsmIndex == i || highlightAsmIndex == mark.row,
+						Color:      jumpColor,
+						Plain:      ui.NoLigatures,
+					}.Layout(ui.Theme, gtx)
+				}
+			}
+		} else if len(targets) > 0 && jumpVisible(i, targets, visibleAsm) {
+			lineWidth := gtx.Metric.Dp(palette.LineWidth)
 			align := float32(lineWidth%2) / 2
 			stack := op.Affine(f32.Affine2D{}.Offset(
-				f32.Pt(jump.Max+align, float32(i*lineHeight)+align+ui.asm.scroll))).Push(gtx.Ops)
+				f32.Pt(jump.Max+align, rowY(i)+align))).Push(gtx.Ops)
+
+			width := float32(lineWidth)
+			alpha := float32(0.7)
 
 			var path clip.Path
 			path.Begin(gtx.Ops)
 			path.MoveTo(f32.Pt(float32(pad/2), float32(lineHeight*2/3)))
 			path.LineTo(f32.Pt(float32(-jumpStep*ix.RefStack), float32(lineHeight*2/3)))
-			path.LineTo(f32.Pt(float32(-jumpStep*ix.RefStack), float32(lineHeight/3+ix.RefOffset*lineHeight)))
-			path.LineTo(f32.Pt(float32(-jumpStep/2), float32(lineHeight/3+ix.RefOffset*lineHeight)))
-			// draw arrow
-			path.Line(f32.Pt(0, float32(lineHeight/4)))
-			path.Line(f32.Pt(float32(lineHeight/3), float32(-lineHeight/4)))
-			path.Line(f32.Pt(float32(-lineHeight/3), float32(-lineHeight/4)))
-			path.Line(f32.Pt(0, float32(lineHeight/4)))
+			for k, off := range targets {
+				if k > 0 {
+					path.MoveTo(f32.Pt(float32(-jumpStep*ix.RefStack), float32(lineHeight*2/3)))
+				}
+				targetY := float32(lineHeight/3) + rowY(i+off) - rowY(i)
+				path.LineTo(f32.Pt(float32(-jumpStep*ix.RefStack), targetY))
+				path.LineTo(f32.Pt(float32(-jumpStep/2), targetY))
+				// draw arrow
+				path.Line(f32.Pt(0, float32(lineHeight/4)))
+				path.Line(f32.Pt(float32(lineHeight/3), float32(-lineHeight/4)))
+				path.Line(f32.Pt(float32(-lineHeight/3), float32(-lineHeight/4)))
+				path.Line(f32.Pt(0, float32(lineHeight/4)))
 
-			width := float32(lineWidth)
-			alpha := float32(0.7)
-			if highlightAsmIndex >= 0 && (highlightAsmIndex == i || highlightAsmIndex == i+ix.RefOffset) {
-				width *= 3
-				alpha = 1
-			} else if disasm.LineRangesContain(highlightRanges, i, i+ix.RefOffset) {
-				width *= 3
+				if highlightAsmIndex >= 0 && (highlightAsmIndex == i || highlightAsmIndex == i+off) {
+					width = float32(lineWidth) * 3
+					alpha = 1
+				} else if disasm.LineRangesContain(highlightRanges, i, i+off) {
+					width = float32(lineWidth) * 3
+				}
 			}
-			jumpColor := f32color.HSLA(float32(math.Mod(float64(ix.PC)*math.Phi, 1)), 0.8, 0.4, alpha)
+			jumpColor := palette.JumpColor(ix.PC, alpha)
 			paint.FillShape(gtx.Ops, jumpColor, clip.Stroke{Path: path.End(), Width: width}.Op())
 
 			stack.Pop()
 		}
+
+		if !visibleAsm.Contains(i) {
+			continue
+		}
+
+		if prev != nil && ui.SourceJump > 0 {
+			if note := sourceJump(prev, ix, ui.SourceJump); note != "" {
+				y := int(rowY(i))
+				paint.FillShape(gtx.Ops, palette.Splitter, clip.Rect{
+					Min: image.Pt(int(asm.Min), y),
+					Max: image.Pt(int(asm.Max), y+gtx.Dp(palette.LineWidth)),
+				}.Op())
+				SourceLine{
+					TopLeft:    image.Pt(int(asm.Max)+pad/2, y),
+					Width:      int(gutter.Max) - int(asm.Max) - pad/2,
+					Text:       note,
+					TextHeight: ui.TextHeight * 8 / 10,
+					Italic:     true,
+					Color:      palette.Splitter,
+					Plain:      ui.NoLigatures,
+				}.Layout(ui.Theme, gtx)
+			}
+		}
+
+		if ui.Coverage {
+			if tint, ok := palette.CoverageColor(ix.Coverage); ok {
+				paint.FillShape(gtx.Ops, tint, clip.Rect{
+					Min: image.Pt(int(asm.Min), int(rowY(i))),
+					Max: image.Pt(int(asm.Max), int(rowY(i+1))),
+				}.Op())
+			}
+		}
+		audit := disasm.AuditNone
+		if ui.Audit {
+			audit = disasm.Audit(ix)
+		}
+		if audit != disasm.AuditNone {
+			paint.FillShape(gtx.Ops, palette.Warning, clip.Rect{
+				Min: image.Pt(int(asm.Min), int(rowY(i))),
+				Max: image.Pt(int(asm.Max), int(rowY(i+1))),
+			}.Op())
+		}
+		instrumented := ui.Instrumentation != InstrumentationShown && ui.isInstrumented(i)
+		if instrumented && ui.Instrumentation == InstrumentationHighlight {
+			paint.FillShape(gtx.Ops, palette.Instrumented, clip.Rect{
+				Min: image.Pt(int(asm.Min), int(rowY(i))),
+				Max: image.Pt(int(asm.Max), int(rowY(i+1))),
+			}.Op())
+		}
+		accessing := memorySelected && ui.Memory.Operand.Accesses(ix)
+		if i == current || accessing {
+			paint.FillShape(gtx.Ops, palette.Highlight, clip.Rect{
+				Min: image.Pt(int(asm.Min), int(rowY(i))),
+				Max: image.Pt(int(asm.Max), int(rowY(i+1))),
+			}.Op())
+		} else if ui.Highlight != nil && ui.Highlight.MatchString(ix.Text) {
+			paint.FillShape(gtx.Ops, palette.Highlight, clip.Rect{
+				Min: image.Pt(int(asm.Min), int(rowY(i))),
+				Max: image.Pt(int(asm.Max), int(rowY(i+1))),
+			}.Op())
+		}
+
+		if ui.Marks.Contains(ix) {
+			// the dot is in the gap between the jump lines and the text
+			radius := lineHeight * 3 / 10
+			center := image.Pt(int(asm.Min), int(rowY(i))+lineHeight/2)
+			paint.FillShape(gtx.Ops, palette.Mark, clip.Ellipse{
+				Min: center.Sub(image.Pt(radius, radius)),
+				Max: center.Add(image.Pt(radius, radius)),
+			}.Op(gtx.Ops))
+		}
+
+		textColor := palette.Foreground
+		if ix.Bad {
+			textColor = palette.Bad
+		} else if instrumented && ui.Instrumentation == InstrumentationDim {
+			textColor = palette.Dimmed
+		}
+		lines := rows.Text(i)
+		if lines == nil {
+			lines = []string{ui.instText(ix, addrStart, addrWidth)}
+		}
+		for k, text := range lines {
+			textLength := utf8.RuneCountInString(text)
+			line := SourceLine{
+				TopLeft:    image.Pt(int(asm.Min)+pad/2, int(rowY(i))+k*lineHeight),
+				Width:      asmTextWidth,
+				Text:       text,
+				TextHeight: ui.TextHeight,
+				Italic:     ix.Call != "" || ix.Bad,
+				Bold:       highlightAsmIndex == i || current == i || accessing || audit != disasm.AuditNone,
+				Color:      textColor,
+				Plain:      ui.NoLigatures,
+			}
+			switch ui.LongLines {
+			case LongLinesScroll:
+				line.Offset = int(ui.asm.hscroll)
+				longestText = max(longestText, textLength)
+			case LongLinesTruncate:
+				line.Truncate = true
+			}
+			line.Layout(ui.Theme, gtx)
+
+			annotation := ix.Annotation
+			if ix.PCRelative != "" {
+				annotation = strings.TrimSpace(strings.TrimSpace(ix.PCRelative+" "+ix.Preview) + "  " + annotation)
+			}
+			if ui.NoJumps {
+				annotation = strings.TrimSpace(jumpNote(ui.Code, i) + "  " + annotation)
+			}
+			if audit != disasm.AuditNone {
+				annotation = strings.TrimSpace("⚠ " + audit.String() + "  " + annotation)
+			}
+			if ui.Encoding && highlightAsmIndex == i {
+				annotation = strings.TrimSpace(ui.encodingNote(ix) + "  " + annotation)
+			}
+			if ix.Feature != disasm.FeatureNone && highlightAsmIndex == i {
+				annotation = strings.TrimSpace("requires " + ix.Feature.String() + "  " + annotation)
+			}
+			if annotation != "" && k == len(lines)-1 {
+				const annotationGap = 2
+				note := line
+				note.Text = annotation
+				note.Truncate = false
+				note.Italic = true
+				note.Color = palette.Splitter
+				note.Offset = line.Offset - (textLength+annotationGap)*advance
+				note.Layout(ui.Theme, gtx)
+				if ui.LongLines == LongLinesScroll {
+					longestText = max(longestText, textLength+annotationGap+utf8.RuneCountInString(annotation))
+				}
+			}
+		}
+	}
+	if ui.Dependencies {
+		ui.layoutDependencies(gtx, gutter.Min, rowY, visibleAsm, highlightAsmIndex)
 	}
 	asmClip.Pop()
 
@@ -263,32 +793,92 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 		Min: image.Pt(int(source.Min), 0),
 		Max: image.Pt(int(source.Max), gtx.Constraints.Max.Y),
 	}.Push(gtx.Ops)
-	top = int(ui.src.scroll)
-	for i, src := range ui.Code.Source {
+	top := int(ui.src.scroll)
+	sources := ui.Sources()
+	pinned := len(sources) == 1 && ui.PinnedFile == sources[0].File
+	for i, src := range sources {
 		if i > 0 {
 			top += lineHeight
 		}
-		SourceLine{
-			TopLeft:    image.Pt(int(source.Min), top),
-			Text:       src.File,
-			TextHeight: ui.TextHeight,
-			Bold:       highlightAsmIndex == i,
-			Color:      f32color.Black,
-		}.Layout(ui.Theme, gtx)
+		if sourceVisible(top) {
+			// clicking the file name pins the source pane to it
+			headerHovered := mouseInSource && float32(top) <= mousePosition.Y && mousePosition.Y < float32(top+lineHeight)
+			if headerHovered {
+				pointer.CursorPointer.Add(gtx.Ops)
+				if mouseClicked {
+					ui.TogglePin(src.File)
+					op.InvalidateOp{}.Add(gtx.Ops)
+				}
+			}
+			text := src.File
+			if pinned {
+				text += "  (pinned)"
+			}
+			SourceLine{
+				TopLeft:    image.Pt(int(source.Min), top),
+				Text:       text,
+				TextHeight: ui.TextHeight,
+				Bold:       headerHovered,
+				Color:      palette.Foreground,
+				Plain:      ui.NoLigatures,
+			}.Layout(ui.Theme, gtx)
+		}
 		top += lineHeight
 		for i, block := range src.Blocks {
 			if i > 0 {
 				top += lineHeight
 			}
 			for off, line := range block.Lines {
+				if !sourceVisible(top) {
+					top += lineHeight
+					continue
+				}
 				highlight := mouseInSource && float32(top) <= mousePosition.Y && mousePosition.Y < float32(top+lineHeight)
+				if highlight && mouseClicked {
+					// clicking the lines selects them for SelectedOnly
+					ui.SelectLine(ui.Code, src.File, block.From+off, mouseExtend, ui.SelectedOnly)
+					op.InvalidateOp{}.Add(gtx.Ops)
+				}
+				if ui.Selection.Valid(ui.Code) && ui.Selection.Contains(src.File, block.From+off) {
+					paint.FillShape(gtx.Ops, palette.Highlight, clip.Rect{
+						Min: image.Pt(int(source.Min), top),
+						Max: image.Pt(int(source.Max), top+lineHeight),
+					}.Op())
+				}
+				text := fmt.Sprintf("%-4d %s", block.From+off, line)
 				SourceLine{
 					TopLeft:    image.Pt(int(source.Min), top),
-					Text:       fmt.Sprintf("%-4d %s", block.From+off, line),
+					Text:       text,
 					TextHeight: ui.TextHeight,
 					Bold:       highlight,
-					Color:      f32color.Black,
+					Color:      palette.Foreground,
+					Plain:      ui.NoLigatures,
 				}.Layout(ui.Theme, gtx)
+				var notes []string
+				if ui.OptHints && off < len(block.Related) {
+					if hint := OptimizationHint(line, ui.Code, block.Related[off]); hint != "" {
+						notes = append(notes, "// "+hint)
+					}
+				}
+				if ui.Blame != nil {
+					blame, pending := ui.Blame.Line(src.File, block.From+off)
+					if pending {
+						op.InvalidateOp{At: gtx.Now.Add(blamePollInterval)}.Add(gtx.Ops)
+					} else if blame != "" {
+						notes = append(notes, "⎇ "+blame)
+					}
+				}
+				if len(notes) > 0 {
+					const noteGap = 2
+					SourceLine{
+						TopLeft:    image.Pt(int(source.Min)+(utf8.RuneCountInString(text)+noteGap)*advance, top),
+						Text:       strings.Join(notes, "  "),
+						TextHeight: ui.TextHeight,
+						Italic:     true,
+						Color:      palette.Splitter,
+						Plain:      ui.NoLigatures,
+					}.Layout(ui.Theme, gtx)
+				}
 				top += lineHeight
 			}
 		}
@@ -305,7 +895,7 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 		// overflow := gtx.Constraints.Max.Y / 3
 		overflow := lineHeight
 		contentTop := float32(-overflow)
-		contentBot := float32(len(ui.Code.Insts)*lineHeight + overflow)
+		contentBot := float32(rows.Top(len(ui.Code.Insts))*lineHeight + overflow)
 		viewTop := -ui.asm.scroll
 		viewBot := -ui.asm.scroll + float32(gtx.Constraints.Max.Y)
 
@@ -323,11 +913,19 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 		}
 
 		if distance := ui.asm.bar.ScrollDistance(); distance != 0 {
-			ui.asm.scroll -= distance * (contentBot - contentTop)
+			ui.scrollAsm(distance * (contentBot - contentTop))
 		}
 		if distance := ui.asm.gesture.Scroll(gtx.Metric, gtx, gtx.Now, gesture.Vertical); distance != 0 {
-			ui.asm.scroll -= float32(distance)
+			ui.scrollAsm(float32(distance))
+		}
+
+		// scrolling long lines horizontally, limited to the visible lines
+		ui.asm.hgesture.Add(gtx.Ops, image.Rect(-1000, 0, 1000, 0))
+		if distance := ui.asm.hgesture.Scroll(gtx.Metric, gtx, gtx.Now, gesture.Horizontal); distance != 0 {
+			ui.asm.hscroll += float32(distance)
 		}
+		ui.asm.hscroll = min(ui.asm.hscroll, float32(longestText*advance-asmTextWidth))
+		ui.asm.hscroll = max(ui.asm.hscroll, 0)
 
 		if -ui.asm.scroll < contentTop {
 			ui.asm.scroll = -contentTop
@@ -371,10 +969,10 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 		}
 
 		if distance := ui.src.bar.ScrollDistance(); distance != 0 {
-			ui.src.scroll -= distance * (contentBot - contentTop)
+			ui.scrollSource(distance * (contentBot - contentTop))
 		}
 		if distance := ui.src.gesture.Scroll(gtx.Metric, gtx, gtx.Now, gesture.Vertical); distance != 0 {
-			ui.src.scroll -= float32(distance)
+			ui.scrollSource(float32(distance))
 		}
 
 		if -ui.src.scroll < contentTop {
@@ -390,7 +988,230 @@ func (ui CodeUIStyle) Layout(gtx layout.Context) layout.Dimensions {
 		stack.Pop()
 	}
 
+	if ui.linkDriver != paneNone {
+		ui.alignLinkedSource(anchors, rows, lineHeight, gtx.Constraints.Max.Y)
+		op.InvalidateOp{}.Add(gtx.Ops)
+	}
+
+	if InRange(highlightAsmIndex, len(ui.Code.Insts)) {
+		ix := &ui.Code.Insts[highlightAsmIndex]
+		if ix.Text != "" {
+			text := fmt.Sprintf("0x%x", ix.PC)
+			if ix.File != "" {
+				text += fmt.Sprintf("  %s:%d", filepath.Base(ix.File), ix.Line)
+			}
+			Tooltip{
+				Position:   image.Pt(int(mousePosition.X)+pad/2, int(mousePosition.Y)+lineHeight),
+				Text:       text,
+				TextHeight: ui.TextHeight * 8 / 10,
+				Color:      palette.Foreground,
+				Background: palette.SecondaryBackground,
+			}.Layout(ui.Theme, gtx)
+		}
+	}
+
 	return layout.Dimensions{
 		Size: gtx.Constraints.Max,
 	}
 }
+
+// topInst returns the address of the instruction at the top of the asm
+// pane in the code of the last layout and the offset of its top, when that
+// code is a different variant of the same func than the code to draw.
+func (ui CodeUIStyle) topInst(rows *asmRows, lineHeight int) (pc uint64, offset float32, ok bool) {
+	shown := ui.shown
+	if shown == nil || shown == ui.Code || shown.Name != ui.Code.Name || len(shown.Insts) == 0 {
+		return 0, 0, false
+	}
+	row := max(int(-ui.asm.scroll)/lineHeight, 0)
+	for i := min(rows.Index(row), len(shown.Insts)-1); i < len(shown.Insts); i++ {
+		if ix := &shown.Insts[i]; ix.Text != "" {
+			return ix.PC, ui.asm.scroll + float32(rows.Top(i)*lineHeight), true
+		}
+	}
+	return 0, 0, false
+}
+
+// labeledCode returns source with the patterns labeled.
+func (ui CodeUIStyle) labeledCode(source *disasm.Code) *disasm.Code {
+	labeled := &ui.CodeUI.labeled
+	if labeled.source != source || labeled.collapse != ui.CollapsePatterns || labeled.code == nil {
+		labeled.source = source
+		labeled.collapse = ui.CollapsePatterns
+		labeled.code = source.LabelPatterns(source.MatchPatterns(ui.Patterns), ui.CollapsePatterns)
+	}
+	return labeled.code
+}
+
+// instText returns the displayed text of the instruction.
+func (ui CodeUIStyle) instText(ix *disasm.Inst, addrStart uint64, addrWidth int) string {
+	if ui.JumpTargets != JumpTargetsDecoded {
+		// the targets are shown by JumpTargets instead of the relative Addresses
+		formatted := *ix
+		formatted.Text, formatted.RefOffset = ui.JumpTargets.Format(ix, ui.Code.X86Mode != 0), 0
+		ix = &formatted
+	}
+	text := ui.Addresses.Format(ix, addrStart, addrWidth)
+	text = ui.Immediates.Format(text)
+	if alias, ok := ui.Aliases.Get(ix.Call); ok && ix.Call != "" {
+		text = strings.Replace(text, ix.Call, aliasMark+alias, 1)
+	}
+	if ui.VectorLanes {
+		text = disasm.AnnotateVectorLanes(text)
+	}
+	return text
+}
+
+// asmRows maps instructions to rows, which differ when long lines
+// are wrapped.
+type asmRows struct {
+	key asmRowsKey
+	// text contains the wrapped rows of each instruction.
+	text [][]string
+	// top is the first row of each instruction followed by the
+	// total number of rows, nil when every instruction is a single row.
+	top []int
+}
+
+// asmRowsKey identifies the settings used for wrapping.
+type asmRowsKey struct {
+	code        *disasm.Code
+	addresses   AddressMode
+	immediates  ImmediateBase
+	jumpTargets JumpTargets
+	vectorLanes bool
+	aliases     int
+	width       int
+}
+
+// Update wraps the instructions to width characters, when needed.
+func (rows *asmRows) Update(ui CodeUIStyle, addrStart uint64, addrWidth, width int) {
+	if ui.LongLines != LongLinesWrap {
+		*rows = asmRows{}
+		return
+	}
+	key := asmRowsKey{ui.Code, ui.Addresses, ui.Immediates, ui.JumpTargets, ui.VectorLanes, ui.Aliases.Version(), width}
+	if rows.key == key && rows.top != nil {
+		return
+	}
+
+	rows.key = key
+	rows.text = make([][]string, len(ui.Code.Insts))
+	rows.top = make([]int, len(ui.Code.Insts)+1)
+	row := 0
+	for i := range ui.Code.Insts {
+		rows.top[i] = row
+		ix := &ui.Code.Insts[i]
+		if ix.Text == "" {
+			row++
+			continue
+		}
+		rows.text[i] = wrapLine(ui.instText(ix, addrStart, addrWidth), width)
+		row += len(rows.text[i])
+	}
+	rows.top[len(ui.Code.Insts)] = row
+}
+
+// Top returns the first row of instruction i.
+func (rows *asmRows) Top(i int) int {
+	if rows.top == nil || i < 0 {
+		return i
+	}
+	if last := len(rows.top) - 1; i > last {
+		return rows.top[last] + i - last
+	}
+	return rows.top[i]
+}
+
+// Index returns the instruction at row.
+func (rows *asmRows) Index(row int) int {
+	if rows.top == nil || row < 0 {
+		return row
+	}
+	if last := len(rows.top) - 1; row >= rows.top[last] {
+		return last + row - rows.top[last]
+	}
+	return sort.Search(len(rows.top), func(i int) bool { return rows.top[i] > row }) - 1
+}
+
+// Text returns the wrapped rows of instruction i, nil when not wrapping.
+func (rows *asmRows) Text(i int) []string {
+	if rows.text == nil {
+		return nil
+	}
+	return rows.text[i]
+}
+
+// jumpNote describes the targets of the jump at i, e.g. "→ 0x4a1c20".
+func jumpNote(code *disasm.Code, i int) string {
+	targets := code.Insts[i].Targets()
+	switch {
+	case len(targets) > 1:
+		return fmt.Sprintf("→ table[%d]", len(targets))
+	case len(targets) == 1 && InRange(i+targets[0], len(code.Insts)):
+		return fmt.Sprintf("→ 0x%x", code.Insts[i+targets[0]].PC)
+	default:
+		return ""
+	}
+}
+
+// overflowLabelLen returns the length of the longest label of the jumps
+// that didn't fit into the lanes, or 0 when all of them fit.
+func overflowLabelLen(code *disasm.Code) int {
+	n := 0
+	for i := range code.Insts {
+		ix := &code.Insts[i]
+		if !ix.RefOverflow || len(ix.Targets()) == 0 {
+			continue
+		}
+		n = max(n, utf8.RuneCountInString(jumpNote(code, i)))
+		n = max(n, utf8.RuneCountInString(fmt.Sprintf("← 0x%x", ix.PC)))
+	}
+	return n
+}
+
+// jumpMark is a label for a jump that didn't fit into the lanes.
+type jumpMark struct {
+	row  int
+	text string
+}
+
+// jumpVisible checks whether any of the jump lines from index i to
+// targets intersects the visible range.
+func jumpVisible(i int, targets []int, visible disasm.LineRange) bool {
+	for _, off := range targets {
+		if disasm.NewLineRange(i, i+off).Intersects(visible) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceJump describes the jump in source between consecutive instructions
+// prev and ix, when they are in different files or further apart than limit.
+func sourceJump(prev, ix *disasm.Inst, limit int) string {
+	if prev.File == "" || ix.File == "" {
+		return ""
+	}
+	if prev.File != ix.File {
+		return fmt.Sprintf("%s:%d", filepath.Base(ix.File), ix.Line)
+	}
+	distance := ix.Line - prev.Line
+	if distance < limit && -distance < limit {
+		return ""
+	}
+	return fmt.Sprintf("%+d lines", distance)
+}
+
+// encodingNote describes the bytes of the x86 instruction, see
+// disasm.X86Encoding. It's empty for the other architectures.
+func (ui CodeUIStyle) encodingNote(ix *disasm.Inst) string {
+	if ui.Code.X86Mode == 0 || ui.Bytes == nil || ix.Bad || ix.Size == 0 {
+		return ""
+	}
+	data := make([]byte, ix.Size)
+	if err := ui.Bytes.ReadData(ix.PC, data); err != nil {
+		return ""
+	}
+	return disasm.DescribeEncoding(disasm.X86Encoding(data, ui.Code.X86Mode))
+}
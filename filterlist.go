@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
+	"gioui.org/font"
 	"gioui.org/layout"
 	"gioui.org/op"
 	"gioui.org/op/clip"
@@ -11,22 +13,62 @@ import (
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
 )
 
 type FilterListItem interface {
 	Name() string
 }
 
+// FilterListIndented is implemented by items that are shown indented
+// under the previous item.
+type FilterListIndented interface {
+	Indent() int
+}
+
 // FilterList lists symbols for filtering and selection.
 type FilterList[T FilterListItem] struct {
+	// Label is shown above the filter, when not empty.
+	Label string
+
 	All         []T
 	Filter      widget.Editor
 	FilterError string
 	Filtered    []T
+	// Mode defines how Filter matches the names.
+	Mode FilterMode
+	// Exclude, when not nil, drops the matching items from the list.
+	Exclude *regexp.Regexp
+	// Expand, when not nil, can add items to the filtered list.
+	Expand func(filtered []T) []T
 
 	Selected     string
 	SelectedItem T
 
+	// Aliases, when not nil, replace the shown names.
+	Aliases *Aliases
+	// Notes, when not nil, mark the names that have notes.
+	Notes *Notes
+	// ShowPackages shows the package of the disasm.Packaged items
+	// under their name.
+	ShowPackages bool
+	// Compact drops the padding between the rows.
+	Compact bool
+
+	// Status is additional information shown below the list.
+	Status string
+	// Cancellable shows the Cancel button next to the status,
+	// while a background search is filling the list.
+	Cancellable bool
+	Cancel      widget.Clickable
+
+	// Show limits the number of listed items until ShowMore is clicked,
+	// zero lists all of them.
+	Show     int
+	ShowMore widget.Clickable
+	showAll  bool
+
 	List SelectList
 }
 
@@ -34,7 +76,8 @@ type FilterList[T FilterListItem] struct {
 func NewFilterList[T FilterListItem](theme *material.Theme) *FilterList[T] {
 	ui := &FilterList[T]{}
 	ui.Filter.SingleLine = true
-	ui.List = NewVerticalSelectList(unit.Dp(theme.TextSize) + 4)
+	ui.Filter.Submit = true
+	ui.List = NewVerticalSelectList(unit.Dp(theme.TextSize) + palette.RowPadding)
 	return ui
 }
 
@@ -75,32 +118,119 @@ func (ui *FilterList[T]) updateFiltered() {
 		}
 	}()
 
-	rx, err := regexp.Compile("(?i)" + ui.Filter.Text())
+	rx, err := CompileFilter(ui.Filter.Text(), ui.Mode)
 	ui.FilterError = ""
 	if err != nil {
 		ui.FilterError = err.Error()
 		return
 	}
 
-	ui.Filtered = ui.Filtered[:0]
-	for _, item := range ui.All {
-		if rx.MatchString(item.Name()) {
-			ui.Filtered = append(ui.Filtered, item)
+	ui.Filtered = FilterItems(ui.Filtered[:0], ui.All, rx, ui.Exclude)
+	if ui.Expand != nil {
+		ui.Filtered = ui.Expand(ui.Filtered)
+	}
+}
+
+// FilterMode defines how a filter matches the names, which is set by
+// -filter-exact and -filter-case-insensitive.
+type FilterMode struct {
+	// Exact takes the filter literally as the full name,
+	// e.g. "main.(*Server).Handle", instead of as a regexp.
+	Exact bool `json:"exact,omitempty"`
+	// MatchCase matches the case of the names, which is ignored otherwise.
+	MatchCase bool `json:"matchCase,omitempty"`
+}
+
+// Regexp returns the regexp that matches the names like filter does with
+// mode, when it's compiled by CompileFilter without a mode. An empty
+// filter matches all the names.
+func (mode FilterMode) Regexp(filter string) string {
+	if mode.Exact && filter != "" {
+		filter = "^" + regexp.QuoteMeta(filter) + "$"
+	}
+	if mode.MatchCase {
+		filter = "(?-i:" + filter + ")"
+	}
+	return filter
+}
+
+// UnionFilter combines filters into a single regexp that matches any of
+// them with mode.
+func UnionFilter(filters []string, mode FilterMode) string {
+	if len(filters) == 1 {
+		return mode.Regexp(filters[0])
+	}
+	var union []string
+	for _, filter := range filters {
+		union = append(union, "(?:"+mode.Regexp(filter)+")")
+	}
+	return strings.Join(union, "|")
+}
+
+// CompileFilter compiles the filter used for matching item names.
+func CompileFilter(filter string, mode FilterMode) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + mode.Regexp(filter))
+}
+
+// FilterItems appends items from all whose name match rx to dst,
+// except the ones matching exclude, when it's not nil.
+func FilterItems[T FilterListItem](dst, all []T, rx, exclude *regexp.Regexp) []T {
+	for _, item := range all {
+		name := item.Name()
+		if rx.MatchString(name) && (exclude == nil || !exclude.MatchString(name)) {
+			dst = append(dst, item)
 		}
 	}
+	return dst
+}
+
+// visible returns the number of listed items.
+func (ui *FilterList[T]) visible() int {
+	if ui.Show > 0 && !ui.showAll && len(ui.Filtered) > ui.Show {
+		return ui.Show
+	}
+	return len(ui.Filtered)
+}
+
+// itemLabel returns the text shown for the filtered item at index.
+func (ui *FilterList[T]) itemLabel(index int) string {
+	item := ui.Filtered[index]
+	name := ui.Aliases.Display(item.Name())
+	if marked, ok := any(item).(disasm.Marked); ok && marked.Marker() != "" {
+		name += "  [" + marked.Marker() + "]"
+	}
+	if ui.Notes.Has(item.Name()) {
+		name += "  " + noteMark
+	}
+	if indented, ok := any(item).(FilterListIndented); ok && indented.Indent() > 0 {
+		return strings.Repeat("  ", indented.Indent()-1) + "↳ " + name
+	}
+	return name
 }
 
 // Layout draws the list.
 func (ui *FilterList[T]) Layout(th *material.Theme, gtx layout.Context) layout.Dimensions {
-	paint.FillShape(gtx.Ops, secondaryBackground, clip.Rect{Max: gtx.Constraints.Min}.Op())
+	for ui.ShowMore.Clicked() {
+		ui.showAll = true
+	}
+
+	paint.FillShape(gtx.Ops, palette.SecondaryBackground, clip.Rect{Max: gtx.Constraints.Min}.Op())
 
 	defer func() {
 		ui.SelectIndex(ui.List.Selected)
 
 		changed := false
 		for _, ev := range ui.Filter.Events() {
-			if _, ok := ev.(widget.ChangeEvent); ok {
+			switch ev.(type) {
+			case widget.ChangeEvent:
 				changed = true
+			case widget.SubmitEvent:
+				// continue with the arrows in the list
+				ui.List.Focus()
+				if ui.List.Selected < 0 && ui.visible() > 0 {
+					ui.SelectIndex(0)
+				}
+				op.InvalidateOp{}.Add(gtx.Ops)
 			}
 		}
 
@@ -113,6 +243,15 @@ func (ui *FilterList[T]) Layout(th *material.Theme, gtx layout.Context) layout.D
 	return layout.Flex{
 		Axis: layout.Vertical,
 	}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if ui.Label == "" {
+				return layout.Dimensions{}
+			}
+			label := material.Body1(th, ui.Label)
+			label.TextSize *= 0.8
+			label.Font.Weight = font.Bold
+			return layout.UniformInset(2).Layout(gtx, label.Layout)
+		}),
 		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 			return FocusBorder(th, ui.Filter.Focused()).Layout(gtx,
 				material.Editor(th, &ui.Filter, "Filter (regexp)").Layout)
@@ -124,15 +263,53 @@ func (ui *FilterList[T]) Layout(th *material.Theme, gtx layout.Context) layout.D
 			return material.Body1(th, ui.FilterError).Layout(gtx)
 		}),
 		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-			return ui.List.Layout(th, gtx, len(ui.Filtered),
-				StringListItem(th, &ui.List, func(index int) string {
-					return ui.Filtered[index].Name()
+			ui.List.ItemHeight = unit.Dp(th.TextSize) + palette.RowPadding
+			if ui.Compact {
+				ui.List.ItemHeight = unit.Dp(th.TextSize)
+			}
+			if !ui.ShowPackages {
+				return ui.List.Layout(th, gtx, ui.visible(), StringListItem(th, &ui.List, ui.itemLabel))
+			}
+			ui.List.ItemHeight += unit.Dp(th.TextSize * 7 / 10)
+			return ui.List.Layout(th, gtx, ui.visible(),
+				SubtitledListItem(th, &ui.List, func(index int) (string, string) {
+					pkg := ""
+					if packaged, ok := any(ui.Filtered[index]).(disasm.Packaged); ok {
+						pkg = packaged.Package()
+					}
+					return ui.itemLabel(index), pkg
 				}))
 		}),
 		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-			body := material.Body1(th, fmt.Sprintf("%d / %d", len(ui.Filtered), len(ui.All)))
+			hidden := len(ui.Filtered) - ui.visible()
+			if hidden == 0 {
+				return layout.Dimensions{}
+			}
+			button := material.Button(th, &ui.ShowMore, fmt.Sprintf("Show %d more", hidden))
+			button.TextSize *= 0.8
+			button.Inset = layout.UniformInset(4)
+			return layout.UniformInset(2).Layout(gtx, button.Layout)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			status := fmt.Sprintf("%d / %d", len(ui.Filtered), len(ui.All))
+			if ui.Status != "" {
+				status += ", " + ui.Status
+			}
+			body := material.Body1(th, status)
 			body.TextSize *= 0.8
-			return layout.Center.Layout(gtx, body.Layout)
+			if !ui.Cancellable {
+				return layout.Center.Layout(gtx, body.Layout)
+			}
+			button := material.Button(th, &ui.Cancel, "Cancel")
+			button.TextSize *= 0.8
+			button.Inset = layout.UniformInset(4)
+			return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(body.Layout),
+					layout.Rigid(layout.Spacer{Width: 4}.Layout),
+					layout.Rigid(button.Layout),
+				)
+			})
 		}),
 	)
 }
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// FindUI is the find bar for searching the instructions of the selected
+// func, or of the funcs of all the tabs in the global mode.
+type FindUI struct {
+	active bool
+	Editor widget.Editor
+	// Global searches the funcs of all the tabs, jumping between them.
+	Global widget.Bool
+
+	rx  *regexp.Regexp
+	err error
+	// current caches the hits of the selected func, when it has no tab.
+	current findHits
+}
+
+// Open shows the find bar with the previous query.
+func (ui *FindUI) Open() {
+	ui.active = true
+	ui.Editor.SingleLine = true
+	ui.Editor.Submit = true
+	ui.Editor.SetCaret(ui.Editor.Len(), 0)
+}
+
+// Close hides the find bar, which stops highlighting the hits.
+func (ui *FindUI) Close() { ui.active = false }
+
+// Active returns whether the find bar is shown.
+func (ui *FindUI) Active() bool { return ui.active }
+
+// Regexp returns the query, or nil when the bar is closed or the query
+// is empty or invalid.
+func (ui *FindUI) Regexp() *regexp.Regexp {
+	if !ui.active {
+		return nil
+	}
+	return ui.rx
+}
+
+// Layout draws the query with status and reports whether it was submitted.
+func (ui *FindUI) Layout(th *material.Theme, gtx layout.Context, status string) (submitted bool, dims layout.Dimensions) {
+	for _, ev := range ui.Editor.Events() {
+		switch ev.(type) {
+		case widget.ChangeEvent:
+			ui.rx, ui.err = nil, nil
+			if query := ui.Editor.Text(); query != "" {
+				ui.rx, ui.err = CompileFilter(query, FilterMode{})
+			}
+			op.InvalidateOp{}.Add(gtx.Ops)
+		case widget.SubmitEvent:
+			submitted = true
+		}
+	}
+	ui.Editor.Focus()
+
+	if ui.err != nil {
+		status = ui.err.Error()
+	}
+	dims = layout.UniformInset(4).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				editor := material.Editor(th, &ui.Editor, "Find instructions (regexp), Enter for the next match")
+				return FocusBorder(th, true).Layout(gtx, editor.Layout)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Inset{Left: 8}.Layout(gtx, material.CheckBox(th, &ui.Global, "all tabs").Layout)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				txt := material.Body2(th, status)
+				if ui.err != nil {
+					txt.Color = palette.Bad
+				}
+				return layout.Inset{Left: 8}.Layout(gtx, txt.Layout)
+			}),
+		)
+	})
+	return submitted, dims
+}
+
+// findHits caches the addresses of the instructions of a func that match
+// the find query.
+type findHits struct {
+	fn  disasm.Func
+	rx  *regexp.Regexp
+	pcs []uint64
+}
+
+// Update returns the addresses of the instructions of fn matching rx,
+// which are searched again only after fn or rx changed.
+func (hits *findHits) Update(fn disasm.Func, rx *regexp.Regexp, opts disasm.Options) []uint64 {
+	if hits.fn != fn || hits.rx != rx {
+		hits.fn, hits.rx = fn, rx
+		hits.pcs = matchingPCs(fn.Load(opts), rx)
+	}
+	return hits.pcs
+}
+
+// matchingPCs returns the addresses of the instructions of code matching
+// rx, like CodeMatches.
+func matchingPCs(code *disasm.Code, rx *regexp.Regexp) []uint64 {
+	if code == nil {
+		return nil
+	}
+	var pcs []uint64
+	for _, ix := range code.Insts {
+		if ix.Text != "" && rx.MatchString(ix.Text) {
+			pcs = append(pcs, ix.PC)
+		}
+	}
+	return pcs
+}
+
+// funcHits returns the addresses of the instructions of fn that match the
+// find query, using the cache of its tab.
+func (ui *FileUI) funcHits(fn disasm.Func) []uint64 {
+	rx := ui.find.Regexp()
+	if rx == nil || fn == nil {
+		return nil
+	}
+	if i := ui.tabs.Index(fn.Name()); i >= 0 {
+		tab := ui.tabs.List[i]
+		return tab.hits.Update(tab.Func, rx, ui.Config.LoadOptions())
+	}
+	return ui.find.current.Update(fn, rx, ui.Config.LoadOptions())
+}
+
+// findNext highlights the next hit of the find query after the current
+// instruction, or the previous one with backward, and scrolls to it. After
+// the last hit of the func, the global mode continues with the next tab
+// that has hits, otherwise the search wraps around within the func.
+func (ui *FileUI) findNext(backward bool) {
+	if ui.find.Regexp() == nil || !ui.Code.Loaded() {
+		return
+	}
+	pcs := ui.funcHits(ui.Funcs.SelectedItem)
+	if pc, ok := nextHit(pcs, ui.Code.Code, ui.Code.CurrentPC, backward); ok {
+		ui.Code.FollowPC(pc)
+		return
+	}
+	if ui.find.Global.Value && ui.findInTabs(backward) {
+		return
+	}
+	if len(pcs) == 0 {
+		ui.Funcs.Status = "no matches"
+		return
+	}
+	pc := pcs[0]
+	if backward {
+		pc = pcs[len(pcs)-1]
+	}
+	ui.Code.FollowPC(pc)
+}
+
+// findInTabs selects the func of the next tab with hits after the tab of
+// the selected func, or the previous one with backward, and highlights
+// its first or last hit. It wraps around to the selected func and reports
+// whether any tab had hits.
+func (ui *FileUI) findInTabs(backward bool) bool {
+	rx := ui.find.Regexp()
+	n := len(ui.tabs.List)
+	at := ui.tabs.Index(ui.Funcs.Selected)
+	for step := 1; step <= n; step++ {
+		var i int
+		switch {
+		case at < 0 && backward:
+			i = n - step
+		case at < 0:
+			i = step - 1
+		case backward:
+			i = (at - step + n) % n
+		default:
+			i = (at + step) % n
+		}
+
+		tab := ui.tabs.List[i]
+		pcs := tab.hits.Update(tab.Func, rx, ui.Config.LoadOptions())
+		if len(pcs) == 0 {
+			continue
+		}
+		if tab.Func.Name() != ui.Funcs.Selected {
+			ui.selectFunc(tab.Func)
+		}
+		pc := pcs[0]
+		if backward {
+			pc = pcs[len(pcs)-1]
+		}
+		ui.Code.FollowPC(pc)
+		return true
+	}
+	return false
+}
+
+// nextHit returns the first of the sorted pcs after current, or the last
+// one before it with backward. When current isn't an instruction of code,
+// e.g. after selecting another func, it's the first or the last hit.
+func nextHit(pcs []uint64, code *disasm.Code, current uint64, backward bool) (uint64, bool) {
+	if len(pcs) == 0 {
+		return 0, false
+	}
+	if instAt(code, current) < 0 {
+		if backward {
+			return pcs[len(pcs)-1], true
+		}
+		return pcs[0], true
+	}
+	if backward {
+		at := sort.Search(len(pcs), func(i int) bool { return pcs[i] >= current })
+		if at == 0 {
+			return 0, false
+		}
+		return pcs[at-1], true
+	}
+	at := sort.Search(len(pcs), func(i int) bool { return pcs[i] > current })
+	if at == len(pcs) {
+		return 0, false
+	}
+	return pcs[at], true
+}
+
+// findStatus describes the position of the current hit within the hits of
+// the selected func and, in the global mode, the hits in all the tabs.
+func (ui *FileUI) findStatus() string {
+	rx := ui.find.Regexp()
+	if rx == nil {
+		return ""
+	}
+	pcs := ui.funcHits(ui.Funcs.SelectedItem)
+	status := fmt.Sprintf("%d matches", len(pcs))
+	for i, pc := range pcs {
+		if pc == ui.Code.CurrentPC {
+			status = fmt.Sprintf("%d / %d", i+1, len(pcs))
+			break
+		}
+	}
+	if ui.find.Global.Value && len(ui.tabs.List) > 0 {
+		total := 0
+		for _, tab := range ui.tabs.List {
+			total += len(tab.hits.Update(tab.Func, rx, ui.Config.LoadOptions()))
+		}
+		status += fmt.Sprintf(", %d in %d tabs", total, len(ui.tabs.List))
+	}
+	return status
+}
+
+// tabHits returns the function for noting the hits of the find query in
+// the tab strip, or nil outside of the global mode.
+func (ui *FileUI) tabHits() func(tab *Tab) int {
+	rx := ui.find.Regexp()
+	if rx == nil || !ui.find.Global.Value {
+		return nil
+	}
+	return func(tab *Tab) int {
+		return len(tab.hits.Update(tab.Func, rx, ui.Config.LoadOptions()))
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// maxFollowRoots is the maximum number of matched funcs whose calls are followed,
+// since following requires disassembling every func in the tree.
+const maxFollowRoots = 256
+
+// calleeFunc is a func called from the previous func in the list.
+type calleeFunc struct {
+	disasm.Func
+	depth int
+}
+
+func (fn calleeFunc) Indent() int { return fn.depth }
+
+// Marker forwards the marker of the callee, when it has one.
+func (fn calleeFunc) Marker() string {
+	if marked, ok := fn.Func.(disasm.Marked); ok {
+		return marked.Marker()
+	}
+	return ""
+}
+
+// Package forwards the package of the callee, when it has one.
+func (fn calleeFunc) Package() string {
+	if packaged, ok := fn.Func.(disasm.Packaged); ok {
+		return packaged.Package()
+	}
+	return ""
+}
+
+// CallFollower adds the callees of funcs up to the specified depth. The
+// calls of the funcs are found in the background, Expand lists only the
+// callees that are known so far.
+type CallFollower struct {
+	Depth int
+	Opts  disasm.Options
+
+	byName map[string]disasm.Func
+	// updated is signalled after the calls of the queued funcs are found.
+	updated chan<- struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu sync.Mutex
+	// calls are the callees of the funcs that were disassembled.
+	calls   map[string][]disasm.Func
+	queue   []disasm.Func
+	queued  map[string]bool
+	running bool
+}
+
+// NewCallFollower creates a follower for the funcs of file, which signals
+// updated when Expand should be called again.
+func NewCallFollower(file disasm.File, depth int, opts disasm.Options, updated chan<- struct{}) *CallFollower {
+	ctx, cancel := context.WithCancel(context.Background())
+	follower := &CallFollower{
+		Depth:   depth,
+		Opts:    opts,
+		byName:  map[string]disasm.Func{},
+		updated: updated,
+		ctx:     ctx,
+		cancel:  cancel,
+		calls:   map[string][]disasm.Func{},
+		queued:  map[string]bool{},
+	}
+	for _, fn := range file.Funcs() {
+		follower.byName[fn.Name()] = fn
+	}
+	return follower
+}
+
+// Stop stops finding the calls and waits until the file isn't used.
+func (follower *CallFollower) Stop() {
+	follower.cancel()
+	follower.wg.Wait()
+}
+
+// Expand returns funcs, where each func is followed by its callees. Every
+// func is listed only once, the callees are listed under the first func
+// that calls them.
+func (follower *CallFollower) Expand(funcs []disasm.Func) []disasm.Func {
+	if len(funcs) > maxFollowRoots {
+		return funcs
+	}
+
+	follower.mu.Lock()
+	defer follower.mu.Unlock()
+
+	expanded := make([]disasm.Func, 0, len(funcs))
+	visited := map[string]bool{}
+	var missing []disasm.Func
+
+	var follow func(fn disasm.Func, depth int)
+	follow = func(fn disasm.Func, depth int) {
+		calls, ok := follower.calls[fn.Name()]
+		if !ok {
+			missing = append(missing, fn)
+			return
+		}
+		for _, callee := range calls {
+			if visited[callee.Name()] {
+				continue
+			}
+			visited[callee.Name()] = true
+			expanded = append(expanded, calleeFunc{Func: callee, depth: depth})
+			if depth < follower.Depth {
+				follow(callee, depth+1)
+			}
+		}
+	}
+
+	for _, fn := range funcs {
+		visited[fn.Name()] = true
+	}
+	for _, fn := range funcs {
+		expanded = append(expanded, fn)
+		follow(fn, 1)
+	}
+	follower.enqueue(missing)
+	return expanded
+}
+
+// enqueue starts finding the calls of funcs, follower.mu must be held.
+func (follower *CallFollower) enqueue(funcs []disasm.Func) {
+	for _, fn := range funcs {
+		if !follower.queued[fn.Name()] {
+			follower.queued[fn.Name()] = true
+			follower.queue = append(follower.queue, fn)
+		}
+	}
+	if len(follower.queue) == 0 || follower.running || follower.ctx.Err() != nil {
+		return
+	}
+	follower.running = true
+	follower.wg.Add(1)
+	go follower.run()
+}
+
+// run finds the calls of the queued funcs until the queue is empty.
+func (follower *CallFollower) run() {
+	defer follower.wg.Done()
+	for {
+		follower.mu.Lock()
+		if len(follower.queue) == 0 || follower.ctx.Err() != nil {
+			follower.running = false
+			follower.mu.Unlock()
+			break
+		}
+		fn := follower.queue[0]
+		follower.queue = follower.queue[1:]
+		follower.mu.Unlock()
+
+		calls := follower.callees(disasm.Disassemble(fn, follower.Opts))
+
+		follower.mu.Lock()
+		follower.calls[fn.Name()] = calls
+		follower.mu.Unlock()
+	}
+
+	if follower.ctx.Err() == nil {
+		select {
+		case follower.updated <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// callees returns the funcs called by code in the order of the calls.
+func (follower *CallFollower) callees(code *disasm.Code) []disasm.Func {
+	if code == nil {
+		return nil
+	}
+	var callees []disasm.Func
+	seen := map[string]bool{}
+	for _, ix := range code.Insts {
+		if ix.Call == "" || seen[ix.Call] {
+			continue
+		}
+		seen[ix.Call] = true
+		if callee, ok := follower.byName[ix.Call]; ok {
+			callees = append(callees, callee)
+		}
+	}
+	return callees
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"image"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// instFrequencyTop is the number of mnemonics shown in the sidebar.
+const instFrequencyTop = 40
+
+// InstFrequency shows the most common instructions of a func in a sidebar.
+type InstFrequency struct {
+	// code is the func that bars were computed for.
+	code    *disasm.Code
+	summary Summary
+	bars    []Bar
+
+	list widget.List
+}
+
+// Layout draws the instruction counts of code, which are recomputed
+// when a different func is selected.
+func (freq *InstFrequency) Layout(th *material.Theme, gtx layout.Context, code *disasm.Code) layout.Dimensions {
+	if freq.code != code {
+		freq.code = code
+		freq.summary = Summary{}
+		freq.summary.Add(code)
+		freq.bars = freq.summary.MnemonicBars(instFrequencyTop)
+		freq.list.Position = layout.Position{}
+	}
+
+	size := image.Pt(gtx.Sp(th.TextSize*26), gtx.Constraints.Max.Y)
+	gtx.Constraints = layout.Exact(size)
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	paint.FillShape(gtx.Ops, palette.SecondaryBackground, clip.Rect{Max: size}.Op())
+
+	freq.list.Axis = layout.Vertical
+	widgets := []layout.Widget{
+		material.Body1(th, fmt.Sprintf("%d instructions, %d kinds", freq.summary.Insts, len(freq.summary.Mnemonics))).Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Top: 8}.Layout(gtx,
+				BarChart{Bars: freq.bars, TextHeight: th.TextSize * 8 / 10, Color: palette.Splitter}.Widget(th))
+		},
+	}
+	layout.UniformInset(8).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return material.List(th, &freq.list).Layout(gtx, len(widgets), func(gtx layout.Context, index int) layout.Dimensions {
+			return widgets[index](gtx)
+		})
+	})
+	return layout.Dimensions{Size: size}
+}
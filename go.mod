@@ -8,13 +8,13 @@ require (
 	golang.org/x/arch v0.2.0
 	golang.org/x/exp v0.0.0-20230713183714-613f0c0eb8a1
 	golang.org/x/exp/shiny v0.0.0-20230713183714-613f0c0eb8a1
+	golang.org/x/image v0.5.0
 )
 
 require (
 	gioui.org/cpu v0.0.0-20210817075930-8d6a761490d2 // indirect
 	gioui.org/shader v1.0.8 // indirect
 	github.com/go-text/typesetting v0.0.0-20230803102845-24e03d8b5372 // indirect
-	golang.org/x/image v0.5.0 // indirect
 	golang.org/x/sys v0.1.0 // indirect
 	golang.org/x/text v0.7.0 // indirect
 )
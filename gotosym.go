@@ -0,0 +1,166 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gioui.org/font"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// gotoMaxMatches is the number of matches listed by the goto switcher.
+const gotoMaxMatches = 12
+
+// GotoUI is the quick switcher for jumping to any func of the file by a
+// fuzzy match of its name, regardless of the filters.
+type GotoUI struct {
+	active bool
+	Editor widget.Editor
+
+	// matches are the best matches of the query, Selected is the one
+	// picked by Enter.
+	matches  []disasm.Func
+	Selected int
+	clicks   [gotoMaxMatches]widget.Clickable
+}
+
+// Open starts a new search.
+func (ui *GotoUI) Open() {
+	ui.active = true
+	ui.Editor.SingleLine = true
+	ui.Editor.Submit = true
+	ui.Editor.SetText("")
+	ui.matches, ui.Selected = nil, 0
+}
+
+// Close stops the search.
+func (ui *GotoUI) Close() { ui.active = false }
+
+// Active returns whether the switcher is open.
+func (ui *GotoUI) Active() bool { return ui.active }
+
+// Move moves the selection by offset within the matches.
+func (ui *GotoUI) Move(offset int) {
+	if len(ui.matches) > 0 {
+		ui.Selected = (ui.Selected + offset + len(ui.matches)) % len(ui.matches)
+	}
+}
+
+// Layout draws the query with the matches of funcs and reports the picked func.
+func (ui *GotoUI) Layout(th *material.Theme, gtx layout.Context, funcs []disasm.Func) (picked disasm.Func, dims layout.Dimensions) {
+	for _, ev := range ui.Editor.Events() {
+		switch ev.(type) {
+		case widget.ChangeEvent:
+			ui.matches = fuzzyMatches(funcs, ui.Editor.Text(), gotoMaxMatches)
+			ui.Selected = 0
+			op.InvalidateOp{}.Add(gtx.Ops)
+		case widget.SubmitEvent:
+			if InRange(ui.Selected, len(ui.matches)) {
+				picked = ui.matches[ui.Selected]
+			}
+		}
+	}
+	for i := range ui.matches {
+		if ui.clicks[i].Clicked() {
+			picked = ui.matches[i]
+		}
+	}
+	ui.Editor.Focus()
+
+	children := []layout.FlexChild{
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			editor := material.Editor(th, &ui.Editor, "Go to func (fuzzy), Tab for the next match")
+			return layout.UniformInset(4).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return FocusBorder(th, true).Layout(gtx, editor.Layout)
+			})
+		}),
+	}
+	for i, fn := range ui.matches {
+		i, fn := i, fn
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return ui.clicks[i].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				label := material.Body1(th, fn.Name())
+				label.MaxLines = 1
+				if i == ui.Selected {
+					label.Font.Weight = font.Bold
+					label.Color = th.ContrastBg
+				}
+				return layout.Inset{Left: 8, Right: 4, Bottom: 2}.Layout(gtx, label.Layout)
+			})
+		}))
+	}
+	if len(ui.matches) == 0 && ui.Editor.Len() > 0 {
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Left: 8, Right: 4, Bottom: 2}.Layout(gtx, material.Body1(th, "no matching funcs").Layout)
+		}))
+	}
+	return picked, layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+}
+
+// fuzzyMatches returns up to limit funcs whose names contain the runes of
+// query in order, the best matches first.
+func fuzzyMatches(funcs []disasm.Func, query string, limit int) []disasm.Func {
+	if query == "" {
+		return nil
+	}
+	type match struct {
+		fn    disasm.Func
+		score int
+	}
+	var matches []match
+	for _, fn := range funcs {
+		if score, ok := fuzzyScore(fn.Name(), query); ok {
+			matches = append(matches, match{fn, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, k int) bool {
+		if matches[i].score != matches[k].score {
+			return matches[i].score > matches[k].score
+		}
+		return len(matches[i].fn.Name()) < len(matches[k].fn.Name())
+	})
+
+	result := make([]disasm.Func, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		result = append(result, m.fn)
+	}
+	return result
+}
+
+// fuzzyScore matches the runes of query in order within name, ignoring
+// the case. The consecutive runes and the ones at the start of a part of
+// the name, e.g. after a dot, score higher and the skipped runes lower.
+func fuzzyScore(name, query string) (score int, ok bool) {
+	name, query = strings.ToLower(name), strings.ToLower(query)
+	prev, last := ' ', -2
+	at := 0
+	for i, r := range name {
+		if at >= len(query) {
+			break
+		}
+		want, size := utf8.DecodeRuneInString(query[at:])
+		if r == want {
+			switch {
+			case last == i-utf8.RuneLen(prev):
+				score += 8
+			case strings.ContainsRune(" ./(*_", prev):
+				score += 6
+			default:
+				score -= 1
+			}
+			at += size
+			last = i
+		}
+		prev = r
+	}
+	if at < len(query) {
+		return 0, false
+	}
+	return score - len(name)/16, true
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"regexp"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// grepResult is a progress update from searching the instructions.
+type grepResult struct {
+	file disasm.File
+	// fn is the func that matched, nil when only reporting progress.
+	fn disasm.Func
+
+	done, total int
+}
+
+// GrepAsm searches the instructions of all funcs for rx. It sends the
+// matching funcs and progress to results until ctx is cancelled.
+func GrepAsm(ctx context.Context, file disasm.File, rx *regexp.Regexp, opts disasm.Options, results chan<- grepResult) {
+	funcs := file.Funcs()
+	for i, fn := range funcs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		result := grepResult{file: file, done: i + 1, total: len(funcs)}
+		if CodeMatches(disasm.Disassemble(fn, opts), rx) {
+			result.fn = fn
+		}
+
+		select {
+		case results <- result:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CodeMatches checks whether any instruction of code matches rx.
+func CodeMatches(code *disasm.Code, rx *regexp.Regexp) bool {
+	if code == nil {
+		return false
+	}
+	for _, ix := range code.Insts {
+		if ix.Text != "" && rx.MatchString(ix.Text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ImmediateBase defines the base used for displaying immediate operands.
+type ImmediateBase int
+
+const (
+	// ImmediateHex shows the immediates as decoded, usually in hex.
+	ImmediateHex ImmediateBase = iota
+	// ImmediateDecimal shows the immediates in decimal.
+	ImmediateDecimal
+	// ImmediateBinary shows the immediates in binary.
+	ImmediateBinary
+)
+
+var immediateBaseNames = [...]string{
+	ImmediateHex:     "hex",
+	ImmediateDecimal: "dec",
+	ImmediateBinary:  "bin",
+}
+
+func (base ImmediateBase) String() string { return immediateBaseNames[base] }
+
+// Set implements flag.Value.
+func (base *ImmediateBase) Set(value string) error {
+	for b, name := range immediateBaseNames {
+		if name == value {
+			*base = ImmediateBase(b)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown immediate base %q, expected hex, dec or bin", value)
+}
+
+// Next returns the following base for cycling through them.
+func (base ImmediateBase) Next() ImmediateBase {
+	return (base + 1) % ImmediateBase(len(immediateBaseNames))
+}
+
+var rxImmediate = regexp.MustCompile(`\$(-?)(0x[\da-fA-F]+|\d+)\b`)
+
+// Format rewrites the immediate operands in the instruction text.
+func (base ImmediateBase) Format(text string) string {
+	if base == ImmediateHex {
+		return text
+	}
+	return rxImmediate.ReplaceAllStringFunc(text, func(match string) string {
+		parts := rxImmediate.FindStringSubmatch(match)
+		value, err := strconv.ParseUint(parts[2], 0, 64)
+		if err != nil {
+			return match
+		}
+		switch base {
+		case ImmediateDecimal:
+			return "$" + parts[1] + strconv.FormatUint(value, 10)
+		case ImmediateBinary:
+			return "$" + parts[1] + "0b" + strconv.FormatUint(value, 2)
+		}
+		return match
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// inlineViewColumns is the width of the inline stack in characters.
+const inlineViewColumns = 64
+
+// inlinePollInterval is how often the code is redrawn while the inline
+// stacks are pending.
+const inlinePollInterval = 100 * time.Millisecond
+
+// InlineView shows the inline stack of an instruction, the innermost
+// inlined func first, each with the source line.
+type InlineView struct {
+	Theme      *material.Theme
+	TextHeight unit.Sp
+	LineHeight unit.Sp
+
+	PC     uint64
+	Frames []disasm.InlineFrame
+}
+
+// Layout draws the frames with their func, location and source line.
+func (view InlineView) Layout(gtx layout.Context) layout.Dimensions {
+	advance := monospaceAdvance(view.Theme, gtx, view.TextHeight)
+	size := image.Pt(inlineViewColumns*advance, gtx.Constraints.Max.Y)
+	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+	paint.FillShape(gtx.Ops, palette.SecondaryBackground, clip.Rect{Max: size}.Op())
+
+	lineHeight := gtx.Metric.Sp(view.LineHeight)
+	pad := lineHeight / 2
+	row := 0
+	draw := func(text string, indent int, bold, italic bool) {
+		SourceLine{
+			TopLeft:    image.Pt(pad+indent*advance, pad+row*lineHeight),
+			Width:      size.X - 2*pad - indent*advance,
+			Text:       text,
+			TextHeight: view.TextHeight,
+			Bold:       bold,
+			Italic:     italic,
+			Color:      palette.Foreground,
+			Truncate:   true,
+		}.Layout(view.Theme, gtx)
+		row++
+	}
+
+	draw(fmt.Sprintf("inline stack at 0x%x", view.PC), 0, true, false)
+	for i, frame := range view.Frames {
+		row++
+		name := frame.Func
+		if i > 0 {
+			name = "called from " + name
+		}
+		draw(name, 0, i == 0, false)
+		draw(fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line), 2, false, true)
+		if frame.Text != "" {
+			draw(frame.Text, 2, false, false)
+		}
+	}
+	return layout.Dimensions{Size: size}
+}
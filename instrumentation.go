@@ -0,0 +1,57 @@
+package main
+
+import "fmt"
+
+// Instrumentation defines how the instructions added by the race
+// detector, the sanitizers and the coverage instrumentation are shown.
+type Instrumentation int
+
+const (
+	// InstrumentationShown draws them like the other instructions.
+	InstrumentationShown Instrumentation = iota
+	// InstrumentationDim draws them faded, so the code underneath stands out.
+	InstrumentationDim
+	// InstrumentationHighlight tints them.
+	InstrumentationHighlight
+)
+
+var instrumentationNames = [...]string{
+	InstrumentationShown:     "off",
+	InstrumentationDim:       "dim",
+	InstrumentationHighlight: "highlight",
+}
+
+func (mode Instrumentation) String() string { return instrumentationNames[mode] }
+
+// Set implements flag.Value.
+func (mode *Instrumentation) Set(value string) error {
+	for m, name := range instrumentationNames {
+		if name == value {
+			*mode = Instrumentation(m)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown instrumentation %q, expected off, dim or highlight", value)
+}
+
+// Next returns the following mode for cycling through them.
+func (mode Instrumentation) Next() Instrumentation {
+	return (mode + 1) % Instrumentation(len(instrumentationNames))
+}
+
+// instrumented returns which instructions of the code were added by the
+// instrumentation, which is cached until the code changes.
+func (ui *CodeUI) instrumented() []bool {
+	if ui.instrumentation.code != ui.Code {
+		ui.instrumentation.code = ui.Code
+		ui.instrumentation.marked = ui.Code.Instrumentation()
+	}
+	return ui.instrumentation.marked
+}
+
+// isInstrumented reports whether the instruction i was added by the
+// instrumentation.
+func (ui *CodeUI) isInstrumented(i int) bool {
+	marked := ui.instrumented()
+	return i < len(marked) && marked[i]
+}
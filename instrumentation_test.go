@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// TestRaceInstrumentation builds testdata/go-race with -race and checks
+// that only the calls of the race detector and the loads of their
+// arguments are classified as instrumentation.
+func TestRaceInstrumentation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building with -race is slow")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	dir, err := filepath.Abs(filepath.Join("testdata", "go-race"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exePath := filepath.Join(t.TempDir(), "race")
+	build := exec.Command(goTool, "build", "-race", "-o", exePath, ".")
+	build.Dir = dir
+	if out, err := build.CombinedOutput(); err != nil {
+		if strings.Contains(string(out), "-race") {
+			t.Skipf("-race is not supported: %s", out)
+		}
+		t.Fatalf("building with -race failed: %v\n%s", err, out)
+	}
+
+	file, err := LoadFile(exePath, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var add disasm.Func
+	for _, fn := range file.Funcs() {
+		if fn.Name() == "main.Add" {
+			add = fn
+		}
+	}
+	if add == nil {
+		t.Fatal("main.Add is not listed")
+	}
+	code := add.Load(disasm.Options{})
+	if code.X86Mode != 64 {
+		t.Skip("the expected instructions are for amd64")
+	}
+
+	calls := 0
+	marked := code.Instrumentation()
+	for i, ix := range code.Insts {
+		if ix.Text == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(ix.Call, "runtime.race"):
+			calls++
+			if !marked[i] {
+				t.Errorf("%q isn't marked", ix.Text)
+			}
+		case marked[i]:
+			// only the return address for racefuncenter is loaded
+			if ix.Text != "NOPL 0(AX)" && !strings.HasSuffix(ix.Text, "(SP), AX") {
+				t.Errorf("%q is marked", ix.Text)
+			}
+		}
+	}
+	if calls == 0 {
+		t.Error("main.Add doesn't call the race detector")
+	}
+}
@@ -0,0 +1,60 @@
+package disasm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// AuditClass is the kind of a security relevant instruction.
+type AuditClass byte
+
+const (
+	// AuditNone is used for instructions that aren't interesting for an audit.
+	AuditNone AuditClass = iota
+	// AuditSyscall enters the kernel, e.g. SYSCALL or INT.
+	AuditSyscall
+	// AuditIndirect calls or jumps to an address in a register or memory.
+	AuditIndirect
+	// AuditProtect calls a func that changes memory protection, which
+	// is used for making written memory executable.
+	AuditProtect
+)
+
+// String returns the short description of the class.
+func (class AuditClass) String() string {
+	switch class {
+	case AuditSyscall:
+		return "syscall"
+	case AuditIndirect:
+		return "indirect branch"
+	case AuditProtect:
+		return "memory protection change"
+	default:
+		return ""
+	}
+}
+
+// rxDirectTarget matches the operand of calls and jumps with a fixed
+// target, e.g. "runtime.morestack(SB)", "0x4a1c20" or anonymized "<addr>".
+var rxDirectTarget = regexp.MustCompile(`^(-?0x[\da-fA-F]+|\+0x[\da-fA-F]+|<addr>|\S+\(SB\))$`)
+
+// rxProtect matches the funcs that change memory protection.
+var rxProtect = regexp.MustCompile(`(?i)(^|[./])(mprotect|mmap|VirtualProtect|VirtualAlloc)`)
+
+// Audit classifies the instruction in Go syntax for a security audit.
+func Audit(ix *Inst) AuditClass {
+	mnemonic, operand, _ := strings.Cut(ix.Text, " ")
+	operand = strings.TrimSpace(operand)
+	switch mnemonic {
+	case "SYSCALL", "SYSENTER", "INT", "SVC", "ECALL":
+		return AuditSyscall
+	case "CALL", "JMP", "BLR", "BR", "JALR":
+		if ix.Call != "" && rxProtect.MatchString(ix.Call) || rxProtect.MatchString(operand) {
+			return AuditProtect
+		}
+		if operand != "" && !rxDirectTarget.MatchString(operand) {
+			return AuditIndirect
+		}
+	}
+	return AuditNone
+}
@@ -0,0 +1,177 @@
+package disasm
+
+import (
+	"sort"
+	"strings"
+)
+
+// Block is a basic block of instructions.
+type Block struct {
+	// LineRange is the range of instructions in Code.Insts.
+	LineRange
+	// Succs are the blocks where the execution can continue.
+	Succs []Edge
+}
+
+// Edge is a control-flow edge between blocks.
+type Edge struct {
+	// Block is the index of the target block.
+	Block int
+	// Kind describes how the target is reached.
+	Kind EdgeKind
+}
+
+// EdgeKind describes how the execution reaches a block.
+type EdgeKind byte
+
+const (
+	// Fallthrough continues to the next instruction.
+	Fallthrough EdgeKind = iota
+	// Taken is a jump to the target.
+	Taken
+	// TableEntry is a jump through a jump table.
+	TableEntry
+)
+
+func (kind EdgeKind) String() string {
+	switch kind {
+	case Fallthrough:
+		return "fallthrough"
+	case Taken:
+		return "taken"
+	case TableEntry:
+		return "table"
+	default:
+		return "?"
+	}
+}
+
+// BasicBlocks splits the code into basic blocks.
+func BasicBlocks(code *Code) []Block {
+	leaders := map[int]bool{}
+	first := -1
+	for i := range code.Insts {
+		ix := &code.Insts[i]
+		if ix.Text == "" {
+			continue
+		}
+		if first < 0 {
+			first = i
+			leaders[i] = true
+		}
+		for _, off := range ix.Targets() {
+			leaders[nextInst(code, i+off)] = true
+		}
+		if len(ix.Targets()) > 0 || endsBlock(ix) {
+			leaders[nextInst(code, i+1)] = true
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+	delete(leaders, len(code.Insts))
+
+	starts := make([]int, 0, len(leaders))
+	for i := range leaders {
+		starts = append(starts, i)
+	}
+	sort.Ints(starts)
+
+	blocks := make([]Block, len(starts))
+	blockAt := map[int]int{}
+	for k, start := range starts {
+		end := len(code.Insts)
+		if k+1 < len(starts) {
+			end = starts[k+1]
+		}
+		blocks[k].LineRange = LineRange{From: start, To: end}
+		blockAt[start] = k
+	}
+
+	for k := range blocks {
+		block := &blocks[k]
+		last := lastInst(code, block.LineRange)
+		if last < 0 {
+			continue
+		}
+		ix := &code.Insts[last]
+
+		kind := Taken
+		if len(ix.RefTable) > 0 {
+			kind = TableEntry
+		}
+		for _, off := range ix.Targets() {
+			if target, ok := blockAt[nextInst(code, last+off)]; ok {
+				block.Succs = append(block.Succs, Edge{Block: target, Kind: kind})
+			}
+		}
+		if !endsBlock(ix) && !unconditionalJump(ix) && k+1 < len(blocks) {
+			block.Succs = append(block.Succs, Edge{Block: k + 1, Kind: Fallthrough})
+		}
+	}
+
+	return blocks
+}
+
+// nextInst returns the index of the first non-blank instruction at or after i.
+func nextInst(code *Code, i int) int {
+	for i < len(code.Insts) && code.Insts[i].Text == "" {
+		i++
+	}
+	return i
+}
+
+// lastInst returns the index of the last non-blank instruction in r.
+func lastInst(code *Code, r LineRange) int {
+	for i := r.To - 1; i >= r.From; i-- {
+		if code.Insts[i].Text != "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// mnemonic returns the first word of the instruction.
+func mnemonic(ix *Inst) string {
+	name, _, _ := strings.Cut(ix.Text, " ")
+	return name
+}
+
+// isReturn checks whether the instruction returns from the func.
+func isReturn(ix *Inst) bool {
+	name := mnemonic(ix)
+	return strings.HasPrefix(name, "RET") || name == "ERET" || name == "return"
+}
+
+// Returns returns the indices of the return instructions in Insts.
+func (code *Code) Returns() []int {
+	var returns []int
+	for i := range code.Insts {
+		if code.Insts[i].Text != "" && isReturn(&code.Insts[i]) {
+			returns = append(returns, i)
+		}
+	}
+	return returns
+}
+
+// unconditionalJump checks whether the instruction always jumps.
+func unconditionalJump(ix *Inst) bool {
+	switch mnemonic(ix) {
+	case "JMP", "B":
+		return true
+	}
+	return len(ix.RefTable) > 0
+}
+
+// endsBlock checks whether the execution doesn't continue after
+// the instruction, except through the jump targets.
+func endsBlock(ix *Inst) bool {
+	switch name := mnemonic(ix); {
+	case isReturn(ix), name == "UD2":
+		return true
+	case name == "JMP" || name == "B":
+		// indirect jumps and tail calls
+		return ix.RefOffset == 0
+	}
+	return false
+}
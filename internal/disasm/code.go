@@ -6,6 +6,18 @@ type Code struct {
 	Name string
 	// File is where the code is located.
 	File string
+	// Signature is the declaration of the func, when known.
+	Signature string
+	// Marker describes the special treatment of the func, see Marked.
+	Marker string
+	// Package is the import path of the package of the func, see Packaged.
+	Package string
+	// Window describes where the instructions are within the func, when
+	// Options.Offset or Length limited them, e.g. "bytes 0x200-0x300 of 0x1c40".
+	Window string
+	// X86Mode is 64 or 32 for the x86 code, see X86Encoding, and 0 for
+	// the other architectures.
+	X86Mode int
 
 	// Insts is the slice of a all instructions in the code.
 	Insts []Inst
@@ -22,12 +34,17 @@ type Code struct {
 type Inst struct {
 	// PC is the program counter, usually offset in the binary.
 	PC uint64
+	// Size is the size of the instruction in bytes.
+	Size int
 	// Text is the textual representation of this instruction.
 	Text string
 	// File is the location where this instruction was compiled from.
 	File string
 	// Line is the line in the file where this instruction was compiled from.
 	Line int
+	// Bad is set when the bytes couldn't be decoded, e.g. unsupported
+	// instructions. Text then contains the raw bytes instead.
+	Bad bool
 
 	// RefPC is a reference to another program counter, e.g. a call.
 	RefPC uint64
@@ -35,11 +52,68 @@ type Inst struct {
 	RefOffset int
 	// RefStack is the depth that the jump line should be drawn at.
 	RefStack int
+	// RefOverflow is set when the jump didn't fit into the lanes
+	// and should be drawn only using endpoint markers.
+	RefOverflow bool
+	// RefTable contains relative offsets to the possible targets
+	// of an indirect jump through a jump table.
+	RefTable []int
+
+	// DataPC is the address of the data referenced by the instruction.
+	DataPC uint64
+	// PCRelative describes the effective address of a PC-relative
+	// operand that the decoder didn't resolve to a symbol, e.g.
+	// "= 0x4c2a40 near runtime.buildVersion+0x8".
+	PCRelative string
+	// Preview shows the local data referenced by the instruction, which
+	// doesn't have a symbol of its own, e.g. a quoted string literal or
+	// the first bytes of a lookup table.
+	Preview string
 
 	// Call is a named target that should be present in Funcs.
 	// This is used to make the instruction clickable and follow to the
 	// called target.
 	Call string
+
+	// Annotation is extra text from Options.AnnotateInstruction.
+	Annotation string
+	// Coverage is whether Options.Coverage reports the instruction as executed.
+	Coverage Coverage
+	// Hits is the number of times that Options.Coverage reports the
+	// instruction or its source line as executed.
+	Hits int
+	// Feature is the CPU feature that the x86 instruction needs beyond
+	// the baseline, e.g. AVX2.
+	Feature Feature
+}
+
+// Targets returns the relative offsets to all jump targets of the instruction.
+func (ix *Inst) Targets() []int {
+	if ix.RefOffset != 0 {
+		return []int{ix.RefOffset}
+	}
+	return ix.RefTable
+}
+
+// Annotate sets the annotation of each instruction using fn.
+func (code *Code) Annotate(fn func(ix Inst) string) {
+	for i := range code.Insts {
+		if code.Insts[i].Text != "" {
+			code.Insts[i].Annotation = fn(code.Insts[i])
+		}
+	}
+}
+
+// Unsupported returns the number of spans that the decoder didn't
+// support, e.g. instructions from newer CPU extensions.
+func (code *Code) Unsupported() int {
+	n := 0
+	for i := range code.Insts {
+		if code.Insts[i].Bad {
+			n++
+		}
+	}
+	return n
 }
 
 // Source represents code from a single file.
@@ -61,3 +135,28 @@ type SourceBlock struct {
 	// instructions `for _, r := range Related[5] { draw(Insts[r.From:r.To]) }`
 	Related [][]LineRange
 }
+
+// MergeRanges merges ranges that are only separated by blank instructions,
+// such as the padding added before jump targets.
+func MergeRanges(ranges []LineRange, insts []Inst) []LineRange {
+	if len(ranges) <= 1 {
+		return ranges
+	}
+	merged := []LineRange{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		blank := true
+		for i := last.To; i < r.From; i++ {
+			if insts[i].Text != "" {
+				blank = false
+				break
+			}
+		}
+		if blank {
+			last.To = r.To
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
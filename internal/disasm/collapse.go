@@ -0,0 +1,106 @@
+package disasm
+
+// Group is a range of instructions that is collapsed into a single line.
+type Group struct {
+	LineRange
+	// Text is shown instead of the instructions.
+	Text string
+}
+
+// Collapse returns a copy of the code where each of the groups is replaced
+// with a single line. The groups must be sorted and not overlap.
+// Jumps and source relations to the grouped instructions refer to the line,
+// the jumps from within the groups are dropped.
+func (code *Code) Collapse(groups []Group) *Code {
+	result := &Code{
+		Name:      code.Name,
+		File:      code.File,
+		Signature: code.Signature,
+		Marker:    code.Marker,
+		Package:   code.Package,
+		Window:    code.Window,
+		X86Mode:   code.X86Mode,
+		MaxJump:   code.MaxJump,
+	}
+
+	newIndex := make([]int, len(code.Insts))
+	grouped := make([]bool, len(code.Insts))
+	for i := 0; i < len(code.Insts); {
+		if len(groups) > 0 && groups[0].From == i {
+			group := groups[0]
+			groups = groups[1:]
+
+			line := Inst{
+				PC:   code.Insts[i].PC,
+				Text: group.Text,
+				File: code.Insts[i].File,
+				Line: code.Insts[i].Line,
+			}
+			for ; i < group.To; i++ {
+				newIndex[i] = len(result.Insts)
+				grouped[i] = true
+				line.Size += code.Insts[i].Size
+				// the line is as hot as the hottest of the instructions
+				if ix := &code.Insts[i]; ix.Hits > line.Hits || ix.Coverage == Covered {
+					line.Hits = max(line.Hits, ix.Hits)
+					line.Coverage = Covered
+				} else if ix.Coverage == NotCovered && line.Coverage == CoverageUnknown {
+					line.Coverage = NotCovered
+				}
+			}
+			result.Insts = append(result.Insts, line)
+			continue
+		}
+		newIndex[i] = len(result.Insts)
+		result.Insts = append(result.Insts, code.Insts[i])
+		i++
+	}
+
+	for i, ix := range code.Insts {
+		if grouped[i] {
+			continue
+		}
+		target := &result.Insts[newIndex[i]]
+		if ix.RefOffset != 0 {
+			target.RefOffset = newIndex[i+ix.RefOffset] - newIndex[i]
+		}
+		target.RefTable = nil
+		for _, off := range ix.RefTable {
+			target.RefTable = append(target.RefTable, newIndex[i+off]-newIndex[i])
+		}
+	}
+
+	for _, src := range code.Source {
+		blocks := make([]SourceBlock, 0, len(src.Blocks))
+		for _, block := range src.Blocks {
+			related := make([][]LineRange, len(block.Related))
+			for line, ranges := range block.Related {
+				for _, r := range ranges {
+					for i := r.From; i < r.To; i++ {
+						related[line] = addToRanges(related[line], newIndex[i])
+					}
+				}
+			}
+			block.Related = related
+			blocks = append(blocks, block)
+		}
+		result.Source = append(result.Source, Source{File: src.File, Blocks: blocks})
+	}
+
+	return result
+}
+
+// addToRanges adds index to the ranges, which are built in increasing order.
+func addToRanges(ranges []LineRange, index int) []LineRange {
+	if n := len(ranges); n > 0 {
+		last := &ranges[n-1]
+		if last.Contains(index) {
+			return ranges
+		}
+		if last.To == index {
+			last.To++
+			return ranges
+		}
+	}
+	return append(ranges, LineRange{From: index, To: index + 1})
+}
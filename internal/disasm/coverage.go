@@ -0,0 +1,182 @@
+package disasm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Coverage is whether an instruction was executed.
+type Coverage byte
+
+const (
+	// CoverageUnknown is used when the profile doesn't describe the instruction.
+	CoverageUnknown Coverage = iota
+	// Covered instructions were executed at least once.
+	Covered
+	// NotCovered instructions were never executed.
+	NotCovered
+)
+
+// CoverageProfile describes the executed source lines or instructions.
+type CoverageProfile struct {
+	// lines contains the execution count of each line of a file, the
+	// files are in the form written to the profile,
+	// e.g. "loov.dev/lensm/main.go".
+	lines map[string]map[int]int
+	// byBase contains the files of lines by their base name.
+	byBase map[string][]string
+	// pcs contains the number of times each instruction of an execution
+	// trace was executed.
+	pcs map[uint64]int
+}
+
+// ParseCoverage parses either a Go coverage profile, as written by
+// "go test -coverprofile", or an execution trace with an executed
+// address per line. The addresses are hex with an optional "0x" prefix,
+// the rest of the line after the address is ignored.
+func ParseCoverage(r io.Reader) (*CoverageProfile, error) {
+	profile := &CoverageProfile{}
+	scanner := bufio.NewScanner(r)
+	first := true
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if first {
+			first = false
+			if strings.HasPrefix(line, "mode:") {
+				profile.lines = map[string]map[int]int{}
+				profile.byBase = map[string][]string{}
+				continue
+			}
+			profile.pcs = map[uint64]int{}
+		}
+
+		var err error
+		if profile.pcs != nil {
+			err = profile.addPC(line)
+		} else {
+			err = profile.addBlock(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// addPC adds an executed address from a trace line.
+func (profile *CoverageProfile) addPC(line string) error {
+	field := strings.Fields(line)[0]
+	pc, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(field), "0x"), 16, 64)
+	if err != nil {
+		return fmt.Errorf("invalid address %q", field)
+	}
+	profile.pcs[pc]++
+	return nil
+}
+
+// addBlock adds a block in the form "file:startLine.startCol,endLine.endCol numStmts count".
+func (profile *CoverageProfile) addBlock(line string) error {
+	colon := strings.LastIndexByte(line, ':')
+	if colon < 0 {
+		return fmt.Errorf("invalid block %q", line)
+	}
+	file := line[:colon]
+
+	var startLine, startCol, endLine, endCol, numStmts, count int
+	_, err := fmt.Sscanf(line[colon+1:], "%d.%d,%d.%d %d %d", &startLine, &startCol, &endLine, &endCol, &numStmts, &count)
+	if err != nil {
+		return fmt.Errorf("invalid block %q: %w", line, err)
+	}
+
+	lines, ok := profile.lines[file]
+	if !ok {
+		lines = map[int]int{}
+		profile.lines[file] = lines
+		base := path.Base(file)
+		profile.byBase[base] = append(profile.byBase[base], file)
+	}
+	for line := startLine; line <= endLine; line++ {
+		// a line is executed as often as the most executed block on it
+		lines[line] = max(lines[line], count)
+	}
+	return nil
+}
+
+// resolveFile finds the file of the profile that corresponds to the
+// source file of the binary. The profile uses import paths, so the
+// file that shares the longest path suffix is used.
+func (profile *CoverageProfile) resolveFile(file string) string {
+	file = strings.ReplaceAll(file, "\\", "/")
+	best, bestCommon := "", 0
+	for _, candidate := range profile.byBase[path.Base(file)] {
+		if common := commonSuffixElements(file, candidate); common > bestCommon {
+			best, bestCommon = candidate, common
+		}
+	}
+	return best
+}
+
+// commonSuffixElements returns the number of trailing path elements that a and b share.
+func commonSuffixElements(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	n := 0
+	for n < len(as) && n < len(bs) && as[len(as)-1-n] == bs[len(bs)-1-n] {
+		n++
+	}
+	return n
+}
+
+// Cover sets the Coverage of the instructions from profile.
+func (code *Code) Cover(profile *CoverageProfile) {
+	resolved := map[string]string{}
+	for i := range code.Insts {
+		ix := &code.Insts[i]
+		if ix.Text == "" {
+			continue
+		}
+
+		if profile.pcs != nil {
+			ix.Coverage = NotCovered
+			if ix.Hits = profile.pcs[ix.PC]; ix.Hits > 0 {
+				ix.Coverage = Covered
+			}
+			continue
+		}
+
+		file, ok := resolved[ix.File]
+		if !ok {
+			file = profile.resolveFile(ix.File)
+			resolved[ix.File] = file
+		}
+		// lines without statements, e.g. the func prologue, are not described
+		if count, ok := profile.lines[file][ix.Line]; ok {
+			ix.Coverage = NotCovered
+			if ix.Hits = count; count > 0 {
+				ix.Coverage = Covered
+			}
+		}
+	}
+}
+
+// Hottest returns the index of the instruction with the most Hits, the
+// first one of them when several have the same, or -1 when none of the
+// instructions was executed.
+func (code *Code) Hottest() int {
+	hottest := -1
+	for i, ix := range code.Insts {
+		if ix.Hits > 0 && (hottest < 0 || ix.Hits > code.Insts[hottest].Hits) {
+			hottest = i
+		}
+	}
+	return hottest
+}
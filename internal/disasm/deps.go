@@ -0,0 +1,192 @@
+package disasm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Dependency is a register that is written by the instruction From and
+// read by the later instruction To.
+type Dependency struct {
+	From, To int
+	Register string
+}
+
+var rxRegister = regexp.MustCompile(`\b[A-Z][A-Z0-9]*\b`)
+
+// partialRegisters maps the byte registers of amd64 to the full register.
+var partialRegisters = map[string]string{
+	"AL": "AX", "AH": "AX", "BL": "BX", "BH": "BX",
+	"CL": "CX", "CH": "CX", "DL": "DX", "DH": "DX",
+	"SIB": "SI", "DIB": "DI", "BPB": "BP",
+}
+
+// ignoredRegisters are used by most instructions or aren't real registers,
+// so they would connect everything.
+var ignoredRegisters = map[string]bool{
+	"SP": true, "RSP": true, "SB": true, "FP": true, "PC": true, "ZR": true, "XZR": true, "WZR": true,
+}
+
+// isRegister checks whether the token of the Go asm syntax is a register
+// of amd64 or arm64.
+func isRegister(token string) bool {
+	switch token {
+	case "AX", "BX", "CX", "DX", "SI", "DI", "BP", "G", "LR":
+		return true
+	}
+	prefix := strings.TrimRight(token, "0123456789")
+	if prefix == token || len(prefix) > 1 {
+		return false
+	}
+	switch prefix {
+	case "R", "X", "Y", "Z", "K", "F", "V":
+		return true
+	}
+	return false
+}
+
+// operandRegisters returns the registers in the operand.
+func operandRegisters(operand string) []string {
+	var regs []string
+	for _, token := range rxRegister.FindAllString(operand, -1) {
+		if full, ok := partialRegisters[token]; ok {
+			token = full
+		}
+		if !ignoredRegisters[token] && isRegister(token) {
+			regs = append(regs, token)
+		}
+	}
+	return regs
+}
+
+// splitOperands splits the operands at the commas outside of parentheses.
+func splitOperands(text string) []string {
+	var operands []string
+	depth, start := 0, 0
+	for i, r := range text {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				operands = append(operands, strings.TrimSpace(text[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		operands = append(operands, rest)
+	}
+	return operands
+}
+
+// onlyWrites reports whether the instruction doesn't read its destination.
+func onlyWrites(name string) bool {
+	for _, prefix := range []string{"MOV", "VMOV", "FMOV", "LEA", "POP", "SET", "CVT", "LDR", "LDP", "LZCNT", "TZCNT", "POPCNT", "BSF", "BSR", "ADR"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// arm64Branches are the branches of arm64, which don't write a register.
+var arm64Branches = map[string]bool{
+	"B": true, "BL": true, "BR": true, "BLR": true,
+	"BEQ": true, "BNE": true, "BCS": true, "BHS": true, "BCC": true, "BLO": true,
+	"BMI": true, "BPL": true, "BVS": true, "BVC": true, "BHI": true, "BLS": true,
+	"BGE": true, "BLT": true, "BGT": true, "BLE": true, "BAL": true,
+}
+
+// onlyReads reports whether the instruction doesn't write its last operand.
+func onlyReads(name string) bool {
+	if arm64Branches[name] {
+		return true
+	}
+	for _, prefix := range []string{"CMP", "TEST", "BT", "UCOMIS", "COMIS", "PTEST", "TBZ", "TBNZ", "CBZ", "CBNZ", "TST", "CMN", "PUSH", "J", "CALL", "RET"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// registerUse returns the registers that the instruction reads and writes.
+// It's a heuristic based on the text of the instruction, which ignores
+// the implicit registers, e.g. of MULQ.
+func registerUse(ix *Inst) (reads, writes []string) {
+	name, args, _ := strings.Cut(ix.Text, " ")
+	operands := splitOperands(args)
+	if len(operands) == 0 || ix.Bad {
+		return nil, nil
+	}
+
+	last := operands[len(operands)-1]
+	for _, operand := range operands[:len(operands)-1] {
+		reads = append(reads, operandRegisters(operand)...)
+	}
+
+	memory := strings.Contains(last, "(")
+	switch {
+	case strings.HasPrefix(name, "LDP") && strings.HasPrefix(last, "("):
+		// the loaded register pair, e.g. "(R1, R2)"
+		writes = operandRegisters(last)
+	case onlyReads(name) || memory || strings.HasPrefix(last, "$"):
+		reads = append(reads, operandRegisters(last)...)
+	default:
+		writes = operandRegisters(last)
+		if len(operands) == 2 && operands[0] == last && strings.HasPrefix(name, "XOR") {
+			// zeroing the register doesn't depend on its value
+			reads = nil
+		} else if len(operands) <= 2 && !onlyWrites(name) {
+			reads = append(reads, writes...)
+		}
+	}
+	return reads, writes
+}
+
+// Dependencies finds the registers that are read by an instruction and
+// written by one of the window instructions before it. The search stops
+// at calls, which clobber the registers.
+func (code *Code) Dependencies(window int) []Dependency {
+	type use struct{ reads, writes []string }
+	uses := make([]use, len(code.Insts))
+	for i := range code.Insts {
+		if code.Insts[i].Text != "" {
+			reads, writes := registerUse(&code.Insts[i])
+			uses[i] = use{reads, writes}
+		}
+	}
+
+	var deps []Dependency
+	for i := range code.Insts {
+		found := map[string]bool{}
+		for _, reg := range uses[i].reads {
+			if found[reg] {
+				continue
+			}
+			found[reg] = true
+			seen := 0
+		search:
+			for k := i - 1; k >= 0 && seen < window; k-- {
+				ix := &code.Insts[k]
+				if ix.Text == "" {
+					continue
+				}
+				seen++
+				if name := mnemonic(ix); ix.Call != "" || strings.HasPrefix(name, "CALL") || name == "BL" || name == "BLR" {
+					break
+				}
+				for _, written := range uses[k].writes {
+					if written == reg {
+						deps = append(deps, Dependency{From: k, To: i, Register: reg})
+						break search
+					}
+				}
+			}
+		}
+	}
+	return deps
+}
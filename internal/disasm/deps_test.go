@@ -0,0 +1,110 @@
+package disasm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDependencies(t *testing.T) {
+	tests := []struct {
+		name   string
+		window int
+		insts  []string
+		want   []Dependency
+	}{
+		{
+			name:   "read modify write",
+			window: 8,
+			insts:  []string{"MOVQ 0x10(SP), AX", "ADDQ $0x1, AX"},
+			want:   []Dependency{{From: 0, To: 1, Register: "AX"}},
+		},
+		{
+			name:   "last write",
+			window: 8,
+			insts:  []string{"MOVQ $0x1, AX", "MOVQ $0x2, AX", "MOVQ AX, BX"},
+			want:   []Dependency{{From: 1, To: 2, Register: "AX"}},
+		},
+		{
+			name:   "same register twice",
+			window: 8,
+			insts:  []string{"MOVQ $0x1, AX", "ADDQ AX, AX"},
+			want:   []Dependency{{From: 0, To: 1, Register: "AX"}},
+		},
+		{
+			name:   "partial register",
+			window: 8,
+			insts:  []string{"MOVL $0x1, AX", "CMPB AL, $0x0"},
+			want:   []Dependency{{From: 0, To: 1, Register: "AX"}},
+		},
+		{
+			name:   "memory destination",
+			window: 8,
+			insts:  []string{"MOVQ $0x1, AX", "MOVQ $0x2, BX", "MOVQ BX, 0x8(AX)"},
+			want: []Dependency{
+				{From: 1, To: 2, Register: "BX"},
+				{From: 0, To: 2, Register: "AX"},
+			},
+		},
+		{
+			name:   "three operands",
+			window: 8,
+			insts:  []string{"MOVQ $0x1, BX", "IMUL3Q $0x3, AX, BX", "MOVQ BX, CX"},
+			want:   []Dependency{{From: 1, To: 2, Register: "BX"}},
+		},
+		{
+			name:   "zeroing",
+			window: 8,
+			insts:  []string{"MOVQ $0x1, AX", "XORL AX, AX", "MOVQ AX, BX"},
+			want:   []Dependency{{From: 1, To: 2, Register: "AX"}},
+		},
+		{
+			name:   "stack pointer",
+			window: 8,
+			insts:  []string{"SUBQ $0x18, SP", "MOVQ 0x8(SP), AX"},
+			want:   nil,
+		},
+		{
+			name:   "call",
+			window: 8,
+			insts:  []string{"MOVQ $0x1, AX", "CALL runtime.f(SB)", "MOVQ AX, BX"},
+			want:   nil,
+		},
+		{
+			name:   "outside of window",
+			window: 1,
+			insts:  []string{"MOVQ $0x1, AX", "NOPL", "MOVQ AX, BX"},
+			want:   nil,
+		},
+		{
+			name:   "inside of window",
+			window: 2,
+			insts:  []string{"MOVQ $0x1, AX", "NOPL", "MOVQ AX, BX"},
+			want:   []Dependency{{From: 0, To: 2, Register: "AX"}},
+		},
+		{
+			name:   "arm64",
+			window: 8,
+			insts:  []string{"MOVD $0x1, R1", "ADD R1, R2, R3", "CBZ R3, 3(PC)"},
+			want: []Dependency{
+				{From: 0, To: 1, Register: "R1"},
+				{From: 1, To: 2, Register: "R3"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code := &Code{}
+			for _, text := range test.insts {
+				ix := Inst{Text: text}
+				if call, ok := strings.CutPrefix(text, "CALL "); ok {
+					ix.Call = strings.TrimSuffix(call, "(SB)")
+				}
+				code.Insts = append(code.Insts, ix)
+			}
+			if got := code.Dependencies(test.window); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
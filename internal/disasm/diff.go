@@ -0,0 +1,160 @@
+package disasm
+
+import "fmt"
+
+// maxDiffCells limits the size of the table used for comparing
+// instructions, larger differences are reported as fully changed.
+const maxDiffCells = 1 << 24
+
+// ChangedInsts compares the instructions of a and b, ignoring the addresses
+// that depend on the link layout, and reports which of the instructions
+// are not part of the longest common subsequence.
+func ChangedInsts(a, b *Code) (changedA, changedB []bool) {
+	textsA, indexA := diffTexts(a)
+	textsB, indexB := diffTexts(b)
+	changedA = make([]bool, len(a.Insts))
+	changedB = make([]bool, len(b.Insts))
+
+	changedTextsA, changedTextsB := ChangedLines(textsA, textsB)
+	for i, changed := range changedTextsA {
+		changedA[indexA[i]] = changed
+	}
+	for i, changed := range changedTextsB {
+		changedB[indexB[i]] = changed
+	}
+	return changedA, changedB
+}
+
+// ChangedLines reports which of the lines of a and b are not part of
+// their longest common subsequence.
+func ChangedLines(a, b []string) (changedA, changedB []bool) {
+	changedA = make([]bool, len(a))
+	changedB = make([]bool, len(b))
+
+	// codegen changes are usually local, so skip the common prefix and suffix
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	matchedA := make([]bool, len(midA))
+	matchedB := make([]bool, len(midB))
+	if (len(midA)+1)*(len(midB)+1) <= maxDiffCells {
+		lcs(midA, midB, matchedA, matchedB)
+	}
+	for i, matched := range matchedA {
+		changedA[prefix+i] = !matched
+	}
+	for i, matched := range matchedB {
+		changedB[prefix+i] = !matched
+	}
+	return changedA, changedB
+}
+
+// AnonymizedTexts returns the text of the instructions, skipping the
+// blank ones, with the addresses that depend on the link layout replaced.
+func (code *Code) AnonymizedTexts() []string {
+	texts, _ := diffTexts(code)
+	return texts
+}
+
+// diffTexts returns the comparable text of the instructions, skipping
+// the blank ones, and their index in code.Insts.
+func diffTexts(code *Code) (texts []string, index []int) {
+	var start uint64
+	for _, ix := range code.Insts {
+		if ix.Text != "" {
+			start = ix.PC
+			break
+		}
+	}
+	for i := range code.Insts {
+		ix := &code.Insts[i]
+		if ix.Text == "" {
+			continue
+		}
+		texts = append(texts, anonymizedText(ix, start))
+		index = append(index, i)
+	}
+	return texts, index
+}
+
+// lcs marks the elements of a and b that are part of their longest
+// common subsequence.
+func lcs(a, b []string, matchedA, matchedB []bool) {
+	width := len(b) + 1
+	table := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for k := len(b) - 1; k >= 0; k-- {
+			if a[i] == b[k] {
+				table[i*width+k] = table[(i+1)*width+k+1] + 1
+			} else {
+				table[i*width+k] = max(table[(i+1)*width+k], table[i*width+k+1])
+			}
+		}
+	}
+	for i, k := 0, 0; i < len(a) && k < len(b); {
+		switch {
+		case a[i] == b[k]:
+			matchedA[i], matchedB[k] = true, true
+			i, k = i+1, k+1
+		case table[(i+1)*width+k] >= table[i*width+k+1]:
+			i++
+		default:
+			k++
+		}
+	}
+}
+
+// Changes returns a copy of the code that contains only the changed
+// instructions, marked with "+", and context instructions around them.
+// The other runs of instructions are collapsed into a single line.
+func (code *Code) Changes(changed []bool, context int) *Code {
+	keep := make([]bool, len(code.Insts))
+	for i, isChanged := range changed {
+		if !isChanged {
+			continue
+		}
+		for k := max(i-context, 0); k <= i+context && k < len(keep); k++ {
+			keep[k] = true
+		}
+	}
+
+	marked := *code
+	marked.Insts = make([]Inst, len(code.Insts))
+	var groups []Group
+	for i, ix := range code.Insts {
+		switch {
+		case !keep[i]:
+			if n := len(groups); n > 0 && groups[n-1].To == i {
+				groups[n-1].To++
+			} else {
+				groups = append(groups, Group{LineRange: LineRange{From: i, To: i + 1}})
+			}
+		case ix.Text == "":
+		case changed[i]:
+			ix.Text = "+ " + ix.Text
+		default:
+			ix.Text = "  " + ix.Text
+		}
+		marked.Insts[i] = ix
+	}
+	for i := range groups {
+		folded := 0
+		for _, ix := range code.Insts[groups[i].From:groups[i].To] {
+			if ix.Text != "" {
+				folded++
+			}
+		}
+		groups[i].Text = fmt.Sprintf("⋯ %d unchanged", folded)
+	}
+
+	return marked.Collapse(groups)
+}
@@ -0,0 +1,369 @@
+package disasm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EncodingPart is a group of the bytes of an encoded x86 instruction,
+// e.g. the ModRM byte or the displacement.
+type EncodingPart struct {
+	// Kind is "prefix", "rex", "vex", "evex", "opcode", "modrm", "sib",
+	// "disp", "imm" or "?" for the bytes that weren't recognized.
+	Kind  string
+	Bytes []byte
+	// Meaning describes the decoded fields, e.g. "mod=1 reg=0 rm=4".
+	Meaning string
+}
+
+var legacyPrefixes = map[byte]string{
+	0xF0: "lock", 0xF2: "repne", 0xF3: "rep",
+	0x2E: "cs", 0x36: "ss", 0x3E: "ds", 0x26: "es", 0x64: "fs", 0x65: "gs",
+	0x66: "operand size", 0x67: "address size",
+}
+
+// X86Encoding splits the encoded x86 instruction into its prefixes, opcode,
+// ModRM, SIB, displacement and immediate. It only looks at the bytes, so
+// it can be used for checking the decoder. The mode is 32 or 64, as in
+// x86asm.Decode.
+func X86Encoding(data []byte, mode int) []EncodingPart {
+	var parts []EncodingPart
+	at := 0
+	add := func(kind string, n int, meaning string) bool {
+		if at+n > len(data) {
+			return false
+		}
+		parts = append(parts, EncodingPart{Kind: kind, Bytes: data[at : at+n], Meaning: meaning})
+		at += n
+		return true
+	}
+	rest := func() []EncodingPart {
+		if at < len(data) {
+			parts = append(parts, EncodingPart{Kind: "?", Bytes: data[at:]})
+		}
+		return parts
+	}
+
+	operand16, address16 := false, false
+	for at < len(data) {
+		name, ok := legacyPrefixes[data[at]]
+		if !ok {
+			break
+		}
+		operand16 = operand16 || data[at] == 0x66
+		address16 = address16 || data[at] == 0x67
+		add("prefix", 1, name)
+	}
+	if at >= len(data) {
+		return rest()
+	}
+
+	var rexW bool
+	if mode == 64 && data[at]&0xF0 == 0x40 {
+		rex := data[at]
+		rexW = rex&0x08 != 0
+		add("rex", 1, rexFlags(rex))
+	}
+	if at >= len(data) {
+		return rest()
+	}
+
+	// the operand and address sizes in bytes
+	immZ := 4
+	if operand16 {
+		immZ = 2
+	}
+	addrSize := mode / 8
+	if address16 {
+		addrSize /= 2
+	}
+
+	var opmap, opcode byte
+	var modrm bool
+	var imm int
+	if isVEX(data[at:], mode) {
+		var ok bool
+		opmap, ok = vexPrefix(data[at:], add)
+		if !ok {
+			return rest()
+		}
+		if at >= len(data) {
+			return rest()
+		}
+		opcode = data[at]
+		add("opcode", 1, "")
+		// VZEROUPPER and VZEROALL are the only ones without a ModRM
+		modrm = !(opmap == 1 && opcode == 0x77)
+		switch {
+		case opmap == 3:
+			imm = 1
+		case opmap == 1 && (opcode >= 0x70 && opcode <= 0x73 || opcode == 0xC2 || opcode >= 0xC4 && opcode <= 0xC6):
+			imm = 1
+		}
+	} else {
+		opcode = data[at]
+		switch {
+		case opcode == 0x0F && at+1 < len(data) && (data[at+1] == 0x38 || data[at+1] == 0x3A):
+			opmap = 2
+			if data[at+1] == 0x3A {
+				opmap = 3
+			}
+			if !add("opcode", 3, "") {
+				return rest()
+			}
+			opcode, modrm = data[at-1], true
+			if opmap == 3 {
+				imm = 1
+			}
+		case opcode == 0x0F:
+			if !add("opcode", 2, "") {
+				return rest()
+			}
+			opmap, opcode = 1, data[at-1]
+			modrm, imm = twoByteOperands(opcode, immZ)
+		default:
+			add("opcode", 1, "")
+			modrm, imm = oneByteOperands(opcode, mode, immZ, addrSize, rexW)
+		}
+	}
+	// the other forms of the groups don't have the immediate
+	if opmap == 0 && (opcode == 0xF6 || opcode == 0xF7) && at < len(data) && (data[at]>>3)&7 > 1 {
+		imm = 0
+	}
+
+	if modrm {
+		if at >= len(data) {
+			return rest()
+		}
+		b := data[at]
+		mod, reg, rm := b>>6, (b>>3)&7, b&7
+		meaning := fmt.Sprintf("mod=%d reg=%d rm=%d", mod, reg, rm)
+		disp := 0
+		switch {
+		case mod == 3:
+			meaning += " register"
+		case addrSize == 2:
+			if mod == 0 && rm == 6 || mod == 2 {
+				disp = 2
+			} else if mod == 1 {
+				disp = 1
+			}
+		default:
+			if mod == 0 && rm == 5 {
+				disp = 4
+				if mode == 64 {
+					meaning += " rip-relative"
+				}
+			} else if mod == 1 {
+				disp = 1
+			} else if mod == 2 {
+				disp = 4
+			}
+		}
+		add("modrm", 1, meaning)
+
+		if mod != 3 && rm == 4 && addrSize != 2 {
+			if at >= len(data) {
+				return rest()
+			}
+			sib := data[at]
+			scale, index, base := 1<<(sib>>6), (sib>>3)&7, sib&7
+			meaning := fmt.Sprintf("scale=%d index=%d base=%d", scale, index, base)
+			if mod == 0 && base == 5 {
+				disp = 4
+			}
+			add("sib", 1, meaning)
+		}
+		if disp > 0 && at+disp <= len(data) {
+			add("disp", disp, fmt.Sprintf("disp%d %s", disp*8, formatSigned(data[at:at+disp])))
+		}
+	}
+	if imm > 0 && at+imm <= len(data) {
+		add("imm", imm, fmt.Sprintf("imm%d %s", imm*8, formatSigned(data[at:at+imm])))
+	}
+	return rest()
+}
+
+// rexFlags formats the set bits of the REX prefix, e.g. "REX.WB".
+func rexFlags(rex byte) string {
+	flags := "REX"
+	if rex&0x0F != 0 {
+		flags += "."
+	}
+	for i, flag := range "WRXB" {
+		if rex&(0x08>>i) != 0 {
+			flags += string(flag)
+		}
+	}
+	return flags
+}
+
+// vexPrefix adds the VEX or EVEX prefix at the start of data and returns
+// the opcode map.
+func vexPrefix(data []byte, add func(kind string, n int, meaning string) bool) (opmap byte, ok bool) {
+	maps := [...]string{1: "0F", 2: "0F38", 3: "0F3A", 5: "MAP5", 6: "MAP6"}
+	pps := [...]string{"", " 66", " F3", " F2"}
+	describe := func(opmap byte, wide bool, length int, pp byte) string {
+		name := fmt.Sprintf("map%d", opmap)
+		if int(opmap) < len(maps) && maps[opmap] != "" {
+			name = maps[opmap]
+		}
+		w := 0
+		if wide {
+			w = 1
+		}
+		return fmt.Sprintf("%s W=%d L=%d%s", name, w, length, pps[pp])
+	}
+
+	switch data[0] {
+	case 0xC5:
+		if len(data) < 2 {
+			return 0, false
+		}
+		length := 128 << (data[1] >> 2 & 1)
+		return 1, add("vex", 2, describe(1, false, length, data[1]&3))
+	case 0xC4:
+		if len(data) < 3 {
+			return 0, false
+		}
+		opmap = data[1] & 0x1F
+		length := 128 << (data[2] >> 2 & 1)
+		return opmap, add("vex", 3, describe(opmap, data[2]&0x80 != 0, length, data[2]&3))
+	case 0x62:
+		if len(data) < 4 {
+			return 0, false
+		}
+		opmap = data[1] & 0x07
+		length := 128 << (data[3] >> 5 & 3)
+		return opmap, add("evex", 4, describe(opmap, data[2]&0x80 != 0, length, data[2]&3))
+	}
+	return 0, false
+}
+
+// oneByteOperands returns whether the opcode of the one byte map has a
+// ModRM and the size of its immediate.
+func oneByteOperands(opcode byte, mode, immZ, addrSize int, rexW bool) (modrm bool, imm int) {
+	lo := opcode & 7
+	switch {
+	case opcode < 0x40:
+		// the arithmetic ops, e.g. ADD, with the forms r/m,r r,r/m AL,imm8 AX,imm
+		switch lo {
+		case 0, 1, 2, 3:
+			return true, 0
+		case 4:
+			return false, 1
+		case 5:
+			return false, immZ
+		}
+		return false, 0
+	case opcode == 0x62 && mode != 64, opcode == 0x63:
+		return true, 0
+	case opcode == 0x68:
+		return false, immZ
+	case opcode == 0x69:
+		return true, immZ
+	case opcode == 0x6A:
+		return false, 1
+	case opcode == 0x6B:
+		return true, 1
+	case opcode >= 0x70 && opcode <= 0x7F:
+		return false, 1
+	case opcode == 0x80 || opcode == 0x82 || opcode == 0x83:
+		return true, 1
+	case opcode == 0x81:
+		return true, immZ
+	case opcode >= 0x84 && opcode <= 0x8F:
+		return true, 0
+	case opcode == 0x9A:
+		return false, immZ + 2
+	case opcode >= 0xA0 && opcode <= 0xA3:
+		return false, addrSize
+	case opcode == 0xA8:
+		return false, 1
+	case opcode == 0xA9:
+		return false, immZ
+	case opcode >= 0xB0 && opcode <= 0xB7:
+		return false, 1
+	case opcode >= 0xB8 && opcode <= 0xBF:
+		if rexW {
+			return false, 8
+		}
+		return false, immZ
+	case opcode == 0xC0 || opcode == 0xC1 || opcode == 0xC6:
+		return true, 1
+	case opcode == 0xC2 || opcode == 0xCA:
+		return false, 2
+	case opcode == 0xC7:
+		return true, immZ
+	case opcode == 0xC8:
+		return false, 3
+	case opcode == 0xCD || opcode == 0xD4 || opcode == 0xD5:
+		return false, 1
+	case opcode >= 0xD0 && opcode <= 0xD3, opcode >= 0xD8 && opcode <= 0xDF:
+		return true, 0
+	case opcode >= 0xE0 && opcode <= 0xE7 || opcode == 0xEB:
+		return false, 1
+	case opcode == 0xE8 || opcode == 0xE9:
+		return false, 4
+	case opcode == 0xEA:
+		return false, immZ + 2
+	case opcode == 0xF6:
+		return true, 1
+	case opcode == 0xF7:
+		return true, immZ
+	case opcode == 0xFE || opcode == 0xFF:
+		return true, 0
+	}
+	return false, 0
+}
+
+// twoByteOperands returns whether the opcode of the 0F map has a ModRM
+// and the size of its immediate.
+func twoByteOperands(opcode byte, immZ int) (modrm bool, imm int) {
+	switch {
+	case opcode >= 0x05 && opcode <= 0x09, opcode == 0x0B, opcode == 0x0E,
+		opcode >= 0x30 && opcode <= 0x37, opcode == 0x77,
+		opcode >= 0xA0 && opcode <= 0xA2, opcode >= 0xA8 && opcode <= 0xAA,
+		opcode >= 0xC8 && opcode <= 0xCF:
+		// e.g. SYSCALL, UD2, RDTSC, CPUID and BSWAP
+		return false, 0
+	case opcode >= 0x80 && opcode <= 0x8F:
+		// the conditional jumps
+		return false, 4
+	case opcode >= 0x70 && opcode <= 0x73, opcode == 0xA4, opcode == 0xAC, opcode == 0xBA,
+		opcode == 0xC2, opcode >= 0xC4 && opcode <= 0xC6:
+		return true, 1
+	}
+	return true, 0
+}
+
+// formatSigned formats the little-endian value as a signed hex number.
+func formatSigned(data []byte) string {
+	var value uint64
+	for i := len(data) - 1; i >= 0; i-- {
+		value = value<<8 | uint64(data[i])
+	}
+	bits := uint(len(data) * 8)
+	signed := int64(value<<(64-bits)) >> (64 - bits)
+	if signed < 0 {
+		return fmt.Sprintf("-%#x", uint64(-signed))
+	}
+	return fmt.Sprintf("%#x", signed)
+}
+
+// DescribeEncoding formats the parts of an instruction on a single line,
+// e.g. "48 REX.W │ 8b opcode │ 44 modrm mod=1 reg=0 rm=4".
+func DescribeEncoding(parts []EncodingPart) string {
+	descs := make([]string, len(parts))
+	for i, part := range parts {
+		desc := fmt.Sprintf("% x", part.Bytes)
+		switch part.Kind {
+		case "rex", "disp", "imm":
+			desc += " " + part.Meaning
+		default:
+			desc += " " + strings.TrimSpace(part.Kind+" "+part.Meaning)
+		}
+		descs[i] = desc
+	}
+	return strings.Join(descs, " │ ")
+}
@@ -0,0 +1,52 @@
+package disasm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestX86Encoding(t *testing.T) {
+	tests := []struct {
+		hex  string
+		mode int
+		want string
+	}{
+		{"c3", 64, "opcode:1"},
+		{"488b442408", 64, "rex:1 opcode:1 modrm:1 sib:1 disp:1"},
+		{"8b05f0ff0000", 64, "opcode:1 modrm:1 disp:4"},
+		{"8b0425f0ff0000", 64, "opcode:1 modrm:1 sib:1 disp:4"},
+		{"b801000000", 64, "opcode:1 imm:4"},
+		{"48b80102030405060708", 64, "rex:1 opcode:1 imm:8"},
+		{"66c7000100", 64, "prefix:1 opcode:1 modrm:1 imm:2"},
+		{"678b00", 64, "prefix:1 opcode:1 modrm:1"},
+		{"6690", 64, "prefix:1 opcode:1"},
+		{"7404", 64, "opcode:1 imm:1"},
+		{"e800000000", 64, "opcode:1 imm:4"},
+		{"0f84fcffffff", 64, "opcode:2 imm:4"},
+		{"0f1f440000", 64, "opcode:2 modrm:1 sib:1 disp:1"},
+		{"660f3a0fc108", 64, "prefix:1 opcode:3 modrm:1 imm:1"},
+		{"c5f877", 64, "vex:2 opcode:1"},
+		{"c4e27d18c0", 64, "vex:3 opcode:1 modrm:1"},
+		{"62f17c4810c1", 64, "evex:4 opcode:1 modrm:1"},
+		{"8b442408", 32, "opcode:1 modrm:1 sib:1 disp:1"},
+		// 0x48 is DEC AX instead of REX.W in 32-bit mode
+		{"48", 32, "opcode:1"},
+		// the ModRM is missing
+		{"488b", 64, "rex:1 opcode:1"},
+	}
+	for _, test := range tests {
+		data, err := hex.DecodeString(test.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parts []string
+		for _, part := range X86Encoding(data, test.mode) {
+			parts = append(parts, fmt.Sprintf("%s:%d", part.Kind, len(part.Bytes)))
+		}
+		if got := strings.Join(parts, " "); got != test.want {
+			t.Errorf("X86Encoding(%s, %d) = %q, want %q", test.hex, test.mode, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package disasm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Feature is an x86 CPU feature beyond the amd64 baseline of SSE2 that an
+// instruction needs.
+type Feature byte
+
+const (
+	// FeatureNone is used for the instructions of the baseline.
+	FeatureNone Feature = iota
+	FeatureSSE3
+	FeatureSSSE3
+	FeatureSSE41
+	FeatureSSE42
+	FeaturePOPCNT
+	FeatureAVX
+	FeatureAVX2
+	FeatureFMA
+	FeatureF16C
+	FeatureBMI1
+	FeatureBMI2
+	FeatureLZCNT
+	FeatureMOVBE
+	FeatureAVX512
+	FeatureAES
+	FeaturePCLMULQDQ
+	FeatureSHA
+)
+
+var featureNames = [...]string{
+	FeatureNone:      "",
+	FeatureSSE3:      "SSE3",
+	FeatureSSSE3:     "SSSE3",
+	FeatureSSE41:     "SSE4.1",
+	FeatureSSE42:     "SSE4.2",
+	FeaturePOPCNT:    "POPCNT",
+	FeatureAVX:       "AVX",
+	FeatureAVX2:      "AVX2",
+	FeatureFMA:       "FMA",
+	FeatureF16C:      "F16C",
+	FeatureBMI1:      "BMI1",
+	FeatureBMI2:      "BMI2",
+	FeatureLZCNT:     "LZCNT",
+	FeatureMOVBE:     "MOVBE",
+	FeatureAVX512:    "AVX-512",
+	FeatureAES:       "AES",
+	FeaturePCLMULQDQ: "PCLMULQDQ",
+	FeatureSHA:       "SHA",
+}
+
+// String returns the name of the feature as used by CPU vendors.
+func (feature Feature) String() string { return featureNames[feature] }
+
+// Level returns the GOAMD64 level that includes the feature, e.g. 3 for
+// AVX2. The features outside of the levels, e.g. AES, return 1.
+func (feature Feature) Level() int {
+	switch {
+	case feature >= FeatureSSE3 && feature <= FeaturePOPCNT:
+		return 2
+	case feature >= FeatureAVX && feature <= FeatureMOVBE:
+		return 3
+	case feature == FeatureAVX512:
+		return 4
+	}
+	return 1
+}
+
+// featurePrefixes are the mnemonics in Go syntax that need a feature, the
+// general purpose ones are followed by the operand size, e.g. POPCNTQ.
+var featurePrefixes = []struct {
+	prefix  string
+	feature Feature
+}{
+	{"ADDSUBP", FeatureSSE3}, {"HADDP", FeatureSSE3}, {"HSUBP", FeatureSSE3},
+	{"LDDQU", FeatureSSE3}, {"MOVDDUP", FeatureSSE3}, {"MOVSHDUP", FeatureSSE3}, {"MOVSLDUP", FeatureSSE3},
+
+	{"PABS", FeatureSSSE3}, {"PALIGNR", FeatureSSSE3}, {"PHADD", FeatureSSSE3}, {"PHSUB", FeatureSSSE3},
+	{"PMADDUBSW", FeatureSSSE3}, {"PMULHRSW", FeatureSSSE3}, {"PSHUFB", FeatureSSSE3}, {"PSIGN", FeatureSSSE3},
+
+	{"BLENDP", FeatureSSE41}, {"BLENDVP", FeatureSSE41}, {"DPP", FeatureSSE41}, {"EXTRACTPS", FeatureSSE41},
+	{"INSERTPS", FeatureSSE41}, {"MOVNTDQA", FeatureSSE41}, {"MPSADBW", FeatureSSE41}, {"PACKUSDW", FeatureSSE41},
+	{"PBLENDVB", FeatureSSE41}, {"PBLENDW", FeatureSSE41}, {"PCMPEQQ", FeatureSSE41}, {"PEXTRB", FeatureSSE41},
+	{"PEXTRD", FeatureSSE41}, {"PEXTRQ", FeatureSSE41}, {"PHMINPOSUW", FeatureSSE41}, {"PINSRB", FeatureSSE41},
+	{"PINSRD", FeatureSSE41}, {"PINSRQ", FeatureSSE41}, {"PMAXSB", FeatureSSE41}, {"PMAXSD", FeatureSSE41},
+	{"PMAXUD", FeatureSSE41}, {"PMAXUW", FeatureSSE41}, {"PMINSB", FeatureSSE41}, {"PMINSD", FeatureSSE41},
+	{"PMINUD", FeatureSSE41}, {"PMINUW", FeatureSSE41}, {"PMOVSX", FeatureSSE41}, {"PMOVZX", FeatureSSE41},
+	{"PMULDQ", FeatureSSE41}, {"PMULLD", FeatureSSE41}, {"PTEST", FeatureSSE41}, {"ROUNDP", FeatureSSE41},
+	{"ROUNDS", FeatureSSE41},
+
+	{"CRC32", FeatureSSE42}, {"PCMPESTR", FeatureSSE42}, {"PCMPISTR", FeatureSSE42}, {"PCMPGTQ", FeatureSSE42},
+
+	{"POPCNT", FeaturePOPCNT},
+	{"LZCNT", FeatureLZCNT},
+	{"TZCNT", FeatureBMI1},
+	{"MOVBE", FeatureMOVBE},
+	{"AESENC", FeatureAES}, {"AESDEC", FeatureAES}, {"AESIMC", FeatureAES}, {"AESKEYGENASSIST", FeatureAES},
+	{"PCLMULQDQ", FeaturePCLMULQDQ},
+	{"SHA1", FeatureSHA}, {"SHA256", FeatureSHA},
+}
+
+// X86Feature returns the feature needed by the x86 instruction with the
+// text in Go syntax and the encoded data. The instructions with a VEX or
+// EVEX prefix are classified by their encoding, since the decoder doesn't
+// support most of them. The mode is 32 or 64, as in x86asm.Decode.
+func X86Feature(text string, data []byte, mode int) Feature {
+	if isVEX(data, mode) {
+		return vexFeature(data)
+	}
+	name, _, _ := strings.Cut(text, " ")
+	for _, entry := range featurePrefixes {
+		if strings.HasPrefix(name, entry.prefix) {
+			return entry.feature
+		}
+	}
+	return FeatureNone
+}
+
+// isVEX checks whether data starts with a VEX or EVEX prefix. In 32 bit
+// mode the same bytes are LES, LDS and BOUND with a memory operand.
+func isVEX(data []byte, mode int) bool {
+	if len(data) < 2 {
+		return false
+	}
+	switch data[0] {
+	case 0xC4, 0xC5, 0x62:
+		return mode == 64 || data[1]&0xC0 == 0xC0
+	}
+	return false
+}
+
+// vexFeature classifies an instruction with a VEX prefix, 0xC4 or 0xC5,
+// or an EVEX prefix, 0x62, by its opcode map and opcode.
+func vexFeature(data []byte) Feature {
+	var opmap, prefix, opcode byte
+	var wide bool
+	switch data[0] {
+	case 0x62:
+		return FeatureAVX512
+	case 0xC5:
+		if len(data) < 3 {
+			return FeatureAVX
+		}
+		opmap, prefix, wide, opcode = 1, data[1]&0x03, data[1]&0x04 != 0, data[2]
+	case 0xC4:
+		if len(data) < 4 {
+			return FeatureAVX
+		}
+		opmap, prefix, wide, opcode = data[1]&0x1F, data[2]&0x03, data[2]&0x04 != 0, data[3]
+	default:
+		return FeatureNone
+	}
+
+	switch opmap {
+	case 1:
+		// the integer ops on 256 bit registers, e.g. VPADDD with Y registers,
+		// except for the moves and conversions
+		integer := opcode >= 0x60 && opcode <= 0x6D || opcode >= 0x70 && opcode <= 0x76 || opcode >= 0xD1
+		switch opcode {
+		case 0xD6, 0xE6, 0xE7, 0xF0:
+			integer = false
+		}
+		if wide && prefix == 1 && integer {
+			return FeatureAVX2
+		}
+	case 2:
+		switch {
+		case opcode == 0xF2 || opcode == 0xF3 || opcode == 0xF7 && prefix == 0:
+			// ANDN, BLSR/BLSMSK/BLSI and BEXTR
+			return FeatureBMI1
+		case opcode == 0xF5 || opcode == 0xF6 || opcode == 0xF7:
+			// BZHI/PDEP/PEXT, MULX and SHLX/SARX/SHRX
+			return FeatureBMI2
+		case opcode >= 0x96 && opcode <= 0x9F, opcode >= 0xA6 && opcode <= 0xAF, opcode >= 0xB6 && opcode <= 0xBF:
+			return FeatureFMA
+		case opcode == 0x13:
+			return FeatureF16C
+		case opcode == 0x16 || opcode == 0x36 || opcode >= 0x45 && opcode <= 0x47,
+			opcode >= 0x58 && opcode <= 0x5A, opcode == 0x78 || opcode == 0x79,
+			opcode == 0x8C || opcode == 0x8E, opcode >= 0x90 && opcode <= 0x93:
+			// VPERMD, VPSRLV, VPBROADCAST, VPMASKMOV and the gathers
+			return FeatureAVX2
+		case wide && (opcode <= 0x0B || opcode >= 0x1C && opcode <= 0x1E || opcode >= 0x20 && opcode <= 0x40):
+			return FeatureAVX2
+		}
+	case 3:
+		switch {
+		case opcode == 0xF0:
+			// RORX
+			return FeatureBMI2
+		case opcode == 0x1D:
+			return FeatureF16C
+		case opcode <= 0x02, opcode == 0x38 || opcode == 0x39, opcode == 0x46:
+			// VPERMQ, VPERMPD, VPBLENDD, VINSERTI128, VEXTRACTI128 and VPERM2I128
+			return FeatureAVX2
+		case wide && (opcode == 0x0E || opcode == 0x0F || opcode == 0x42):
+			return FeatureAVX2
+		}
+	}
+	return FeatureAVX
+}
+
+// Features returns the distinct features needed by the instructions.
+func (code *Code) Features() []Feature {
+	seen := map[Feature]bool{}
+	var features []Feature
+	for i := range code.Insts {
+		if feature := code.Insts[i].Feature; feature != FeatureNone && !seen[feature] {
+			seen[feature] = true
+			features = append(features, feature)
+		}
+	}
+	sort.Slice(features, func(i, k int) bool { return features[i] < features[k] })
+	return features
+}
+
+// DescribeFeatures describes the features and the GOAMD64 level that
+// includes them, e.g. "requires AVX2, BMI2 (GOAMD64=v3)". It returns an
+// empty string without features.
+func DescribeFeatures(features []Feature) string {
+	if len(features) == 0 {
+		return ""
+	}
+	level := 1
+	names := make([]string, len(features))
+	for i, feature := range features {
+		names[i] = feature.String()
+		level = max(level, feature.Level())
+	}
+	desc := "requires " + strings.Join(names, ", ")
+	if level > 1 {
+		desc += fmt.Sprintf(" (GOAMD64=v%d)", level)
+	}
+	return desc
+}
@@ -0,0 +1,75 @@
+package disasm
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestX86Feature(t *testing.T) {
+	tests := []struct {
+		text string
+		hex  string
+		mode int
+		want Feature
+	}{
+		{"MOVQ AX, BX", "4889c3", 64, FeatureNone},
+		{"POPCNTQ AX, BX", "f3480fb8d8", 64, FeaturePOPCNT},
+		{"TZCNTQ AX, BX", "f3480fbcd8", 64, FeatureBMI1},
+		{"PSHUFB X1, X0", "660f3800c1", 64, FeatureSSSE3},
+		{"PCMPEQQ X1, X0", "660f3829c1", 64, FeatureSSE41},
+		{"CRC32Q AX, BX", "f2480f38f1d8", 64, FeatureSSE42},
+		{"AESENC X1, X0", "660f38dcc1", 64, FeatureAES},
+		// VZEROUPPER
+		{"", "c5f877", 64, FeatureAVX},
+		// VMOVAPS Y1, Y0
+		{"", "c5fc28c1", 64, FeatureAVX},
+		// VPADDD Y1, Y0, Y0
+		{"", "c5fdfec1", 64, FeatureAVX2},
+		// VPADDD X1, X0, X0
+		{"", "c5f9fec1", 64, FeatureAVX},
+		// ANDNL CX, AX, AX
+		{"", "c4e270f2c1", 64, FeatureBMI1},
+		// BEXTRL AX, CX, AX
+		{"", "c4e270f7c1", 64, FeatureBMI1},
+		// SHLXL AX, CX, AX
+		{"", "c4e279f7c1", 64, FeatureBMI2},
+		// VFMADD231PD X2, X1, X0
+		{"", "c4e2f1b8c2", 64, FeatureFMA},
+		// RORXQ $0x8, CX, AX
+		{"", "c4e3fbf0c108", 64, FeatureBMI2},
+		// VPERMQ $0x1b, Y1, Y0
+		{"", "c4e3fd00c11b", 64, FeatureAVX2},
+		// VMOVUPS Z1, Z0
+		{"", "62f17c4810c1", 64, FeatureAVX512},
+		// VZEROUPPER, the ModRM bits can't be LDS
+		{"", "c5f877", 32, FeatureAVX},
+		{"LES 0(AX), AX", "c400", 32, FeatureNone},
+	}
+	for _, test := range tests {
+		data, err := hex.DecodeString(test.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := X86Feature(test.text, data, test.mode); got != test.want {
+			t.Errorf("X86Feature(%q, %s, %d) = %q, want %q", test.text, test.hex, test.mode, got, test.want)
+		}
+	}
+}
+
+func TestDescribeFeatures(t *testing.T) {
+	tests := []struct {
+		features []Feature
+		want     string
+	}{
+		{nil, ""},
+		{[]Feature{FeatureAES}, "requires AES"},
+		{[]Feature{FeatureSSE41, FeaturePOPCNT}, "requires SSE4.1, POPCNT (GOAMD64=v2)"},
+		{[]Feature{FeatureAVX2, FeatureBMI2}, "requires AVX2, BMI2 (GOAMD64=v3)"},
+		{[]Feature{FeaturePOPCNT, FeatureAVX512, FeatureSHA}, "requires POPCNT, AVX-512, SHA (GOAMD64=v4)"},
+	}
+	for _, test := range tests {
+		if got := DescribeFeatures(test.features); got != test.want {
+			t.Errorf("DescribeFeatures(%v) = %q, want %q", test.features, got, test.want)
+		}
+	}
+}
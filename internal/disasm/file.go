@@ -1,5 +1,7 @@
 package disasm
 
+import "time"
+
 // File represents an object file, a module or anything that contains functions.
 type File interface {
 	// Close closes the underlying data.
@@ -16,9 +18,138 @@ type Func interface {
 	Load(opt Options) *Code
 }
 
+// Disassembler is implemented by funcs that can be disassembled without
+// caching the code, e.g. for scanning all the funcs of a file.
+type Disassembler interface {
+	// Disassemble loads the code like Load, but doesn't keep it.
+	Disassemble(opt Options) *Code
+}
+
+// Disassemble loads the code of fn without caching it, when fn is
+// a Disassembler.
+func Disassemble(fn Func, opt Options) *Code {
+	if dis, ok := fn.(Disassembler); ok {
+		return dis.Disassemble(opt)
+	}
+	return fn.Load(opt)
+}
+
+// Symbol is implemented by funcs that know their location in the file.
+type Symbol interface {
+	// Addr is the address of the func.
+	Addr() uint64
+	// Size is the size of the func in bytes.
+	Size() uint64
+}
+
+// DataReader is implemented by files that can read the data referenced
+// by the instructions, see Inst.DataPC.
+type DataReader interface {
+	// ReadData reads len(data) bytes starting at the virtual address addr.
+	ReadData(addr uint64, data []byte) error
+}
+
+// InlineStacker is implemented by files that know the inlined calls of
+// the instructions, e.g. from DWARF.
+type InlineStacker interface {
+	// InlineStack returns the frames of the instruction at pc from the
+	// innermost inlined func to the func that contains pc. It's nil when
+	// the instruction wasn't inlined, or with pending while the inlined
+	// calls are still being indexed.
+	InlineStack(pc uint64) (frames []InlineFrame, pending bool)
+}
+
+// InlineFrame is a single func in the inline stack of an instruction.
+type InlineFrame struct {
+	// Func is the name of the func.
+	Func string
+	// File and Line are the position within Func, which is the call of
+	// the inner frame for all but the innermost frame.
+	File string
+	Line int
+	// Text is the source line, when it's available.
+	Text string
+}
+
+// Started is implemented by files that know where the program starts.
+type Started interface {
+	// EntryFunc returns the func that contains the entry point of the
+	// program, nil when it's unknown.
+	EntryFunc() Func
+}
+
+// Marked is implemented by funcs that can be treated specially,
+// e.g. the runtime funcs with a funcID.
+type Marked interface {
+	// Marker describes the special treatment, empty for normal funcs.
+	Marker() string
+}
+
+// Packaged is implemented by funcs that belong to a package.
+type Packaged interface {
+	// Package returns the import path of the package, empty when unknown.
+	Package() string
+}
+
+// Tabled is implemented by funcs that can decode their raw pcln tables.
+type Tabled interface {
+	// PCTables decodes the pcsp, pcfile and pcline tables of the func.
+	PCTables() (*PCTables, error)
+}
+
+// Described is implemented by files that know how they were built.
+type Described interface {
+	// Metadata returns the build properties of the file, e.g. the build ID,
+	// the Go version, the VCS revision and the build settings.
+	Metadata() []Property
+}
+
+// Property is a single entry of the file metadata.
+type Property struct {
+	Key   string
+	Value string
+}
+
 // Options defines configuration for loading the func.
 type Options struct {
-	// Context is the number of lines that should be additionally included for context.
-	// This can often contain function documentation.
-	Context int
+	// ContextBefore and ContextAfter are the number of lines that should be
+	// additionally included for context before and after the used lines.
+	// The leading context can often contain function documentation.
+	ContextBefore int
+	ContextAfter  int
+	// MergeLines merges the instruction ranges of a source line,
+	// when they are only separated by blank lines.
+	MergeLines bool
+	// MaxJumpLanes limits the number of lanes used for drawing jumps.
+	// Zero means unlimited.
+	MaxJumpLanes int
+
+	// Anonymize replaces the addresses that depend on the link layout.
+	Anonymize bool
+
+	// Offset and Length limit the disassembly to the instructions within
+	// a window of the func in bytes from its start, which is faster for
+	// huge funcs. Zero Length means until the end of the func.
+	Offset uint64
+	Length uint64
+
+	// AnnotateInstruction, when not nil, returns extra text for each
+	// instruction, which is shown dimmed after the asm. The instruction
+	// has the decoded PC, Size, Text, source File and Line, and the
+	// resolved jump or call target in RefPC, RefOffset, RefTable and Call.
+	// The addresses are not anonymized yet.
+	AnnotateInstruction func(ix Inst) string
+	// Coverage, when not nil, marks the executed instructions.
+	Coverage *CoverageProfile
+
+	// Timings, when not nil, accumulates the time spent loading.
+	Timings *Timings
+}
+
+// Timings contains the time spent in different phases of loading a func.
+type Timings struct {
+	// Disassemble is the time spent decoding the instructions.
+	Disassemble time.Duration
+	// Source is the time spent loading sources and mapping them to instructions.
+	Source time.Duration
 }
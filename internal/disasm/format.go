@@ -0,0 +1,115 @@
+package disasm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var rxVectorRegister = regexp.MustCompile(`(^|[ ,(])([XYZ])([12]?[0-9]|3[01])\b`)
+
+// AnnotateVectorLanes annotates the vector registers in Go syntax
+// instruction text with the register width, and the lane count and
+// type inferred from the mnemonic, e.g. "Y0" becomes "Y0(8×f32)".
+func AnnotateVectorLanes(text string) string {
+	mnemonic, _, _ := strings.Cut(text, " ")
+	mnemonic, _, _ = strings.Cut(mnemonic, ".")
+	lane, laneBits := vectorLane(mnemonic)
+
+	return rxVectorRegister.ReplaceAllStringFunc(text, func(match string) string {
+		reg := strings.TrimLeft(match, " ,(")
+		var width int
+		switch reg[0] {
+		case 'X':
+			width = 128
+		case 'Y':
+			width = 256
+		case 'Z':
+			width = 512
+		}
+		switch {
+		case laneBits == 0:
+			return fmt.Sprintf("%s(%db)", match, width)
+		case lane == "scalar":
+			return fmt.Sprintf("%s(f%d)", match, laneBits)
+		default:
+			return fmt.Sprintf("%s(%d×%s%d)", match, width/laneBits, lane, laneBits)
+		}
+	})
+}
+
+// vectorLane infers the lane type and size from the mnemonic.
+func vectorLane(mnemonic string) (lane string, bits int) {
+	switch {
+	case strings.HasSuffix(mnemonic, "PS"):
+		return "f", 32
+	case strings.HasSuffix(mnemonic, "PD"):
+		return "f", 64
+	case strings.HasSuffix(mnemonic, "SS"):
+		return "scalar", 32
+	case strings.HasSuffix(mnemonic, "SD"):
+		return "scalar", 64
+	}
+
+	if !strings.HasPrefix(mnemonic, "P") && !strings.HasPrefix(mnemonic, "VP") {
+		return "", 0
+	}
+	switch mnemonic[len(mnemonic)-1] {
+	case 'B':
+		return "i", 8
+	case 'W':
+		return "i", 16
+	case 'D':
+		return "i", 32
+	case 'Q':
+		return "i", 64
+	}
+	return "", 0
+}
+
+var (
+	rxAbsoluteTarget = regexp.MustCompile(`\s0x[\da-fA-F]+$`)
+	rxRelativeToIP   = regexp.MustCompile(`-?0x[\da-fA-F]+\(IP\)`)
+)
+
+// Anonymize replaces the addresses that depend on the link layout with
+// offsets from the start of the code, so that the same source and compiler
+// produce identical output.
+func (code *Code) Anonymize() {
+	var start uint64
+	for _, ix := range code.Insts {
+		if ix.Text != "" {
+			start = ix.PC
+			break
+		}
+	}
+
+	for i := range code.Insts {
+		ix := &code.Insts[i]
+		if ix.Text == "" {
+			continue
+		}
+		ix.Text = anonymizedText(ix, start)
+		ix.PCRelative = ""
+		ix.PC -= start
+		if ix.RefOffset != 0 {
+			ix.RefPC -= start
+		} else {
+			ix.RefPC = 0
+		}
+	}
+}
+
+// anonymizedText returns the text of ix with the addresses that depend
+// on the link layout replaced, where start is the first address of the code.
+func anonymizedText(ix *Inst, start uint64) string {
+	text := ix.Text
+	if ix.RefPC != 0 {
+		target := "<addr>"
+		if ix.RefOffset != 0 {
+			target = fmt.Sprintf("+%#x", ix.RefPC-start)
+		}
+		text = rxAbsoluteTarget.ReplaceAllString(text, " "+target)
+	}
+	return rxRelativeToIP.ReplaceAllString(text, "<addr>(IP)")
+}
@@ -0,0 +1,141 @@
+package disasm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// rxInstrumentationCall matches the callees that the race detector, the
+// sanitizers and libFuzzer insert, e.g. "runtime.racefuncenter",
+// "runtime.asanread" or "__tsan_read".
+var rxInstrumentationCall = regexp.MustCompile(`^(runtime\.(race|asan|msan|libfuzzer)|_*(tsan|asan|msan|sanitizer_cov)_)`)
+
+// rxCoverageCounter matches the counters of the coverage instrumentation,
+// e.g. "runtime.covctrs+0x18" or "main.goCover_11a1fb80b9ba_P(SB)".
+var rxCoverageCounter = regexp.MustCompile(`\b(runtime\.covctrs|go:covctrs|\S*\.[gG]oCover_)`)
+
+// instrumentationSetup is the number of instructions searched before the
+// instrumentation for loading its arguments.
+const instrumentationSetup = 3
+
+// instrumentationArgs is the number of the integer arguments of the funcs
+// that the instrumentation calls, the others aren't assumed to have any.
+var instrumentationArgs = map[string]int{
+	"runtime.raceread":       1,
+	"runtime.racewrite":      1,
+	"runtime.racereadrange":  2,
+	"runtime.racewriterange": 2,
+	"runtime.racefuncenter":  1,
+	"runtime.asanread":       2,
+	"runtime.asanwrite":      2,
+	"runtime.msanread":       2,
+	"runtime.msanwrite":      2,
+	"runtime.msanmove":       3,
+}
+
+// The integer registers of the internal ABI in the order of the arguments.
+var (
+	amd64ArgumentRegisters = []string{"AX", "BX", "CX", "DI", "SI", "R8", "R9", "R10", "R11"}
+	arm64ArgumentRegisters = []string{"R0", "R1", "R2", "R3", "R4", "R5", "R6", "R7"}
+)
+
+// callArguments returns the registers of the arguments of the call ix,
+// which are none on 386, where the arguments are passed on the stack.
+func (code *Code) callArguments(ix *Inst) []string {
+	n := instrumentationArgs[ix.Call]
+	switch code.X86Mode {
+	case 64:
+		return amd64ArgumentRegisters[:n]
+	case 32:
+		return nil
+	default:
+		return arm64ArgumentRegisters[:n]
+	}
+}
+
+// isInstrumentation reports whether the instruction calls the race or
+// sanitizer runtime or updates a coverage counter.
+func isInstrumentation(ix *Inst) bool {
+	if ix.Text == "" {
+		return false
+	}
+	if ix.Call != "" {
+		return rxInstrumentationCall.MatchString(ix.Call)
+	}
+	return rxCoverageCounter.MatchString(ix.Text) || rxCoverageCounter.MatchString(ix.PCRelative)
+}
+
+// Instrumentation classifies the instructions that were added by the race
+// detector, the sanitizers or the coverage instrumentation, i.e. the calls
+// to their runtime and the coverage counter updates, together with the
+// instructions just before them that only load their arguments. The
+// result is indexed like Insts.
+//
+// It's a heuristic based on the callee names and the text of the
+// instructions: only the last load of each argument register is marked,
+// when it's directly before, and the stores of the arguments on the stack
+// of 386 aren't recognized.
+func (code *Code) Instrumentation() []bool {
+	marked := make([]bool, len(code.Insts))
+	for i := range code.Insts {
+		ix := &code.Insts[i]
+		if !isInstrumentation(ix) {
+			continue
+		}
+		marked[i] = true
+
+		// the registers that the instrumentation consumes
+		wanted := map[string]bool{}
+		reads, _ := registerUse(ix)
+		for _, reg := range reads {
+			wanted[reg] = true
+		}
+		if ix.Call != "" {
+			for _, reg := range code.callArguments(ix) {
+				wanted[reg] = true
+			}
+		}
+
+		seen := 0
+		for k := i - 1; k >= 0 && seen < instrumentationSetup && len(wanted) > 0; k-- {
+			prev := &code.Insts[k]
+			if prev.Text == "" {
+				continue
+			}
+			seen++
+			if mnemonic(prev) == "NOPL" || mnemonic(prev) == "NOP" {
+				marked[k] = true
+				continue
+			}
+			if !loadsOnly(prev, wanted) {
+				break
+			}
+			marked[k] = true
+			// the earlier writes of the register don't reach the call
+			_, writes := registerUse(prev)
+			for _, reg := range writes {
+				delete(wanted, reg)
+			}
+		}
+	}
+	return marked
+}
+
+// loadsOnly reports whether the instruction only writes registers from
+// wanted without reading them, e.g. "MOVL $0x8, BX" or "LEAQ 0(CX)(AX*8), AX".
+func loadsOnly(ix *Inst, wanted map[string]bool) bool {
+	name := mnemonic(ix)
+	if ix.Call != "" || !onlyWrites(name) || strings.HasPrefix(name, "POP") {
+		return false
+	}
+	_, writes := registerUse(ix)
+	if len(writes) == 0 {
+		return false
+	}
+	for _, reg := range writes {
+		if !wanted[reg] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package disasm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInstrumentation(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  int
+		insts []string
+		want  []bool
+	}{
+		{
+			name:  "argument",
+			mode:  64,
+			insts: []string{"MOVQ 0x28(SP), CX", "MOVQ 0x20(SP), AX", "CALL runtime.raceread(SB)"},
+			want:  []bool{false, true, true},
+		},
+		{
+			name:  "last load",
+			mode:  64,
+			insts: []string{"MOVQ 0x10(SP), AX", "MOVQ 0x20(SP), AX", "CALL runtime.racewrite(SB)"},
+			want:  []bool{false, true, true},
+		},
+		{
+			name:  "range",
+			mode:  64,
+			insts: []string{"MOVL $0x8, BX", "LEAQ 0(CX), AX", "CALL runtime.racereadrange(SB)"},
+			want:  []bool{true, true, true},
+		},
+		{
+			name:  "alignment",
+			mode:  64,
+			insts: []string{"MOVQ 0x18(SP), AX", "NOPL 0(AX)", "CALL runtime.racefuncenter(SB)"},
+			want:  []bool{true, true, true},
+		},
+		{
+			name:  "no arguments",
+			mode:  64,
+			insts: []string{"MOVQ 0x8(SP), AX", "CALL runtime.racefuncexit(SB)"},
+			want:  []bool{false, true},
+		},
+		{
+			name:  "interrupted",
+			mode:  64,
+			insts: []string{"MOVQ 0x20(SP), AX", "TESTB AL, 0(AX)", "CALL runtime.raceread(SB)"},
+			want:  []bool{false, false, true},
+		},
+		{
+			name:  "stack arguments",
+			mode:  32,
+			insts: []string{"MOVL 0x8(SP), AX", "CALL runtime.raceread(SB)"},
+			want:  []bool{false, true},
+		},
+		{
+			name:  "arm64",
+			insts: []string{"MOVD R2, R1", "MOVD R3, R0", "CALL runtime.raceread(SB)"},
+			want:  []bool{false, true, true},
+		},
+		{
+			name:  "coverage",
+			mode:  64,
+			insts: []string{"MOVQ 0x8(SP), AX", "INCL main.goCover_11a1fb80b9ba_P+0x18(SB)"},
+			want:  []bool{false, true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code := &Code{X86Mode: test.mode}
+			for _, text := range test.insts {
+				ix := Inst{Text: text}
+				if call, ok := strings.CutPrefix(text, "CALL "); ok {
+					ix.Call = strings.TrimSuffix(call, "(SB)")
+				}
+				code.Insts = append(code.Insts, ix)
+			}
+			if got := code.Instrumentation(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
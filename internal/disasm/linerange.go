@@ -3,6 +3,24 @@ package disasm
 // LineRange represents a list of lines.
 type LineRange struct{ From, To int }
 
+// NewLineRange creates a range that contains both a and b.
+func NewLineRange(a, b int) LineRange {
+	if a > b {
+		a, b = b, a
+	}
+	return LineRange{From: a, To: b + 1}
+}
+
+// Contains checks whether line is in the range.
+func (r LineRange) Contains(line int) bool {
+	return r.From <= line && line < r.To
+}
+
+// Intersects checks whether r and b have any lines in common.
+func (r LineRange) Intersects(b LineRange) bool {
+	return r.From < b.To && b.From < r.To
+}
+
 // LineRangesContain checks whether line a or line b is contained in the ranges.
 func LineRangesContain(ranges []LineRange, a, b int) bool {
 	for _, r := range ranges {
@@ -12,3 +30,13 @@ func LineRangesContain(ranges []LineRange, a, b int) bool {
 	}
 	return false
 }
+
+// LineRangesIntersect checks whether any of the ranges intersects with r.
+func LineRangesIntersect(ranges []LineRange, r LineRange) bool {
+	for _, x := range ranges {
+		if x.Intersects(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package disasm
+
+import "fmt"
+
+// OnlyLines returns a copy of the code where the runs of instructions
+// that weren't compiled from the lines [from, to] of file are collapsed
+// into a single line.
+func (code *Code) OnlyLines(file string, from, to int) *Code {
+	var groups []Group
+	folding := false
+	for i, ix := range code.Insts {
+		switch {
+		case ix.Text == "":
+			// empty lines are folded together with the surrounding code
+			if folding {
+				groups[len(groups)-1].To++
+			}
+		case ix.File == file && from <= ix.Line && ix.Line <= to:
+			if folding {
+				// keep the empty line that separates the jump target
+				last := &groups[len(groups)-1]
+				for code.Insts[last.To-1].Text == "" {
+					last.To--
+				}
+			}
+			folding = false
+		case folding:
+			groups[len(groups)-1].To++
+		default:
+			folding = true
+			groups = append(groups, Group{LineRange: LineRange{From: i, To: i + 1}})
+		}
+	}
+	for i := range groups {
+		folded := 0
+		for _, ix := range code.Insts[groups[i].From:groups[i].To] {
+			if ix.Text != "" {
+				folded++
+			}
+		}
+		groups[i].Text = fmt.Sprintf("⋯ %d outside of the lines", folded)
+	}
+	return code.Collapse(groups)
+}
@@ -27,22 +27,28 @@ func (rs *LineSet) Add(line int) {
 
 // Ranges converts line set to line ranges and adds context for extra information.
 func (rs *LineSet) Ranges(context int) []LineRange {
+	return rs.RangesAround(context, context)
+}
+
+// RangesAround converts line set to line ranges and adds before lines
+// of context in front and after lines of context behind each line.
+func (rs *LineSet) RangesAround(before, after int) []LineRange {
 	if len(rs.list) == 0 {
 		return nil
 	}
 
 	var all []LineRange
 
-	current := LineRange{From: rs.list[0] - context, To: rs.list[0] + context + 1}
+	current := LineRange{From: rs.list[0] - before, To: rs.list[0] + after + 1}
 	if current.From < 1 {
 		current.From = 1
 	}
 	for _, line := range rs.list {
-		if line-context <= current.To {
-			current.To = line + context + 1
+		if line-before <= current.To {
+			current.To = line + after + 1
 		} else {
 			all = append(all, current)
-			current = LineRange{From: line - context, To: line + context + 1}
+			current = LineRange{From: line - before, To: line + after + 1}
 		}
 	}
 	all = append(all, current)
@@ -0,0 +1,89 @@
+package disasm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MemoryOperand is the address of a memory operand in Go syntax, e.g.
+// "0x10(SP)" or "-0x8(SI)(BX*8)". The operands with the same fields
+// access the same location, as long as the registers aren't changed.
+type MemoryOperand struct {
+	// Address is the resolved address of a PC-relative operand.
+	Address uint64
+	// Symbol is set for the static data, e.g. "runtime.x+8" of "runtime.x+8(SB)".
+	Symbol string
+	Offset int64
+	Base   string
+	Index  string
+	Scale  int
+}
+
+var rxMemoryOperand = regexp.MustCompile(`([^\s,$()]+)\(SB\)|(-?(?:0x)?[0-9a-f]+)?\(([A-Z][A-Z0-9]*)\)(?:\(([A-Z][A-Z0-9]*)\*([1248])\))?`)
+
+// MemoryOperands returns the memory operands of the instruction, which
+// doesn't include the targets of calls and jumps.
+func MemoryOperands(ix *Inst) []MemoryOperand {
+	if ix.Bad || ix.Call != "" || ix.Text == "" || ix.RefPC != 0 || len(ix.Targets()) > 0 {
+		return nil
+	}
+	_, args, _ := strings.Cut(ix.Text, " ")
+
+	var operands []MemoryOperand
+	for _, match := range rxMemoryOperand.FindAllStringSubmatch(args, -1) {
+		if match[1] != "" {
+			operands = append(operands, MemoryOperand{Symbol: match[1], Base: "SB"})
+			continue
+		}
+		if base := match[3]; base == "IP" || base == "PC" {
+			// the same offset is a different address for each instruction
+			if ix.DataPC != 0 {
+				operands = append(operands, MemoryOperand{Address: ix.DataPC})
+			}
+			continue
+		}
+		operand := MemoryOperand{Base: match[3], Index: match[4]}
+		if match[2] != "" {
+			offset, err := strconv.ParseInt(match[2], 0, 64)
+			if err != nil {
+				continue
+			}
+			operand.Offset = offset
+		}
+		if match[5] != "" {
+			operand.Scale = int(match[5][0] - '0')
+		}
+		operands = append(operands, operand)
+	}
+	return operands
+}
+
+// Accesses checks whether the instruction has the memory operand.
+func (operand MemoryOperand) Accesses(ix *Inst) bool {
+	for _, other := range MemoryOperands(ix) {
+		if other == operand {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the operand in Go syntax.
+func (operand MemoryOperand) String() string {
+	if operand.Address != 0 {
+		return fmt.Sprintf("%#x", operand.Address)
+	}
+	if operand.Symbol != "" {
+		return operand.Symbol + "(SB)"
+	}
+	text := fmt.Sprintf("%#x(%s)", operand.Offset, operand.Base)
+	if operand.Offset < 0 {
+		text = fmt.Sprintf("-%#x(%s)", -operand.Offset, operand.Base)
+	}
+	if operand.Index != "" {
+		text += fmt.Sprintf("(%s*%d)", operand.Index, operand.Scale)
+	}
+	return text
+}
@@ -0,0 +1,84 @@
+package disasm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMemoryOperands(t *testing.T) {
+	tests := []struct {
+		ix   Inst
+		want []MemoryOperand
+	}{
+		{
+			ix:   Inst{Text: "MOVQ 0x10(SP), AX"},
+			want: []MemoryOperand{{Offset: 0x10, Base: "SP"}},
+		},
+		{
+			ix:   Inst{Text: "MOVQ AX, -0x8(SI)(BX*8)"},
+			want: []MemoryOperand{{Offset: -0x8, Base: "SI", Index: "BX", Scale: 8}},
+		},
+		{
+			ix:   Inst{Text: "LEAQ 0(CX), AX"},
+			want: []MemoryOperand{{Base: "CX"}},
+		},
+		{
+			ix:   Inst{Text: "MOVL (AX)(CX*4), DX"},
+			want: []MemoryOperand{{Base: "AX", Index: "CX", Scale: 4}},
+		},
+		{
+			ix:   Inst{Text: "MOVQ runtime.x+8(SB), AX"},
+			want: []MemoryOperand{{Symbol: "runtime.x+8", Base: "SB"}},
+		},
+		{
+			ix:   Inst{Text: "MOVQ 0x1234(IP), AX", DataPC: 0x4c2a40},
+			want: []MemoryOperand{{Address: 0x4c2a40}},
+		},
+		{
+			ix:   Inst{Text: "MOVQ 0x1234(IP), AX"},
+			want: nil,
+		},
+		{
+			ix:   Inst{Text: "MOVQ 0x8(AX), 0x10(BX)"},
+			want: []MemoryOperand{{Offset: 0x8, Base: "AX"}, {Offset: 0x10, Base: "BX"}},
+		},
+		{
+			ix:   Inst{Text: "MOVQ $0x10, AX"},
+			want: nil,
+		},
+		{
+			ix:   Inst{Text: "CALL 0x10(AX)", Call: "runtime.f"},
+			want: nil,
+		},
+		{
+			ix:   Inst{Text: "JMP 0x10(AX)", RefTable: []int{1, 2}},
+			want: nil,
+		},
+		{
+			ix:   Inst{Text: "0f0b", Bad: true},
+			want: nil,
+		},
+	}
+	for _, test := range tests {
+		if got := MemoryOperands(&test.ix); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("MemoryOperands(%q) = %v, want %v", test.ix.Text, got, test.want)
+		}
+	}
+}
+
+func TestMemoryOperandString(t *testing.T) {
+	tests := []struct {
+		operand MemoryOperand
+		want    string
+	}{
+		{MemoryOperand{Offset: 0x10, Base: "SP"}, "0x10(SP)"},
+		{MemoryOperand{Offset: -0x8, Base: "SI", Index: "BX", Scale: 8}, "-0x8(SI)(BX*8)"},
+		{MemoryOperand{Symbol: "runtime.x+8", Base: "SB"}, "runtime.x+8(SB)"},
+		{MemoryOperand{Address: 0x4c2a40}, "0x4c2a40"},
+	}
+	for _, test := range tests {
+		if got := test.operand.String(); got != test.want {
+			t.Errorf("%#v.String() = %q, want %q", test.operand, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package disasm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a labeled sequence of instructions, e.g. a bounds check.
+type Pattern struct {
+	Label string
+	// Insts contains a regexp for each consecutive instruction.
+	Insts []*regexp.Regexp
+}
+
+// ParsePatterns parses pattern definitions. Each pattern starts with an
+// unindented label followed by indented lines, each containing a regexp
+// for the text of one instruction. Empty lines and lines starting with
+// "#" are ignored. For example:
+//
+//	bounds check
+//		^CMPQ
+//		^JLS|^JCC
+func ParsePatterns(r io.Reader) ([]Pattern, error) {
+	var patterns []Pattern
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			patterns = append(patterns, Pattern{Label: trimmed})
+			continue
+		}
+		if len(patterns) == 0 {
+			return nil, fmt.Errorf("line %d: instruction before pattern label", lineNumber)
+		}
+		rx, err := regexp.Compile(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		last := &patterns[len(patterns)-1]
+		last.Insts = append(last.Insts, rx)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, pattern := range patterns {
+		if len(pattern.Insts) == 0 {
+			return nil, fmt.Errorf("pattern %q has no instructions", pattern.Label)
+		}
+	}
+	return patterns, nil
+}
+
+// PatternMatch is an occurrence of a pattern in the instructions.
+type PatternMatch struct {
+	LineRange
+	Pattern *Pattern
+}
+
+// MatchPatterns finds the non-overlapping occurrences of the patterns,
+// trying them in order at each instruction. Blank instructions between
+// the matched instructions are skipped.
+func (code *Code) MatchPatterns(patterns []Pattern) []PatternMatch {
+	var matches []PatternMatch
+	for i := 0; i < len(code.Insts); i++ {
+		if code.Insts[i].Text == "" {
+			continue
+		}
+		for k := range patterns {
+			if end, ok := code.matchPattern(&patterns[k], i); ok {
+				matches = append(matches, PatternMatch{
+					LineRange: LineRange{From: i, To: end},
+					Pattern:   &patterns[k],
+				})
+				i = end - 1
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// matchPattern checks whether pattern matches the instructions starting
+// at index from and returns the end of the match.
+func (code *Code) matchPattern(pattern *Pattern, from int) (end int, ok bool) {
+	i := from
+	for _, rx := range pattern.Insts {
+		for i < len(code.Insts) && code.Insts[i].Text == "" {
+			i++
+		}
+		if i >= len(code.Insts) || !rx.MatchString(code.Insts[i].Text) {
+			return 0, false
+		}
+		i++
+	}
+	return i, true
+}
+
+// LabelPatterns returns a copy of the code where the matched patterns
+// are either collapsed into a single labeled line or the label is added
+// to the annotation of the first instruction.
+func (code *Code) LabelPatterns(matches []PatternMatch, collapse bool) *Code {
+	if collapse {
+		groups := make([]Group, 0, len(matches))
+		for _, match := range matches {
+			groups = append(groups, Group{
+				LineRange: match.LineRange,
+				Text:      fmt.Sprintf("▸ %s (%d instructions)", match.Pattern.Label, len(match.Pattern.Insts)),
+			})
+		}
+		return code.Collapse(groups)
+	}
+
+	labeled := *code
+	labeled.Insts = append([]Inst(nil), code.Insts...)
+	for _, match := range matches {
+		ix := &labeled.Insts[match.From]
+		label := "▾ " + match.Pattern.Label
+		if ix.Annotation != "" {
+			label += "; " + ix.Annotation
+		}
+		ix.Annotation = label
+	}
+	return &labeled
+}
@@ -0,0 +1,28 @@
+package disasm
+
+// PCTables are the tables of the pclntab that the compiler emitted for
+// a func, which map its program counters to the offset of the stack
+// pointer, the source file and the line.
+type PCTables struct {
+	// Entry is the address of the first instruction.
+	Entry uint64
+	// StartLine is the line of the func keyword, zero before Go 1.20.
+	StartLine int
+	// Quantum is the instruction size unit of the program counter deltas.
+	Quantum int
+
+	// SPOffset, FileOffset and LineOffset are the offsets of the tables
+	// in the pctab.
+	SPOffset, FileOffset, LineOffset uint32
+	SP, File, Line                   []PCValue
+
+	// Files are the names of the file indexes used by File.
+	Files map[int32]string
+}
+
+// PCValue is an entry of a pcvalue table, the value applies to the
+// program counters [From, To).
+type PCValue struct {
+	From, To uint64
+	Value    int32
+}
@@ -60,7 +60,7 @@ func (e *Entry) Disasm() (*Disasm, error) {
 	disasm := disasms[goarch]
 	byteOrder := byteOrders[goarch]
 	if disasm == nil || byteOrder == nil {
-		return nil, fmt.Errorf("unsupported architecture")
+		return nil, ErrUnsupportedArch
 	}
 
 	// Filter out section symbols, overwriting syms in place.
@@ -1,6 +1,224 @@
 package objfile
 
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrUnrecognized is returned by Open for files in none of the formats.
+	ErrUnrecognized = errors.New("unrecognized object file")
+	// ErrUnsupportedArch is returned by Disasm for architectures without
+	// a disassembler.
+	ErrUnsupportedArch = errors.New("unsupported architecture")
+)
+
 func (d *Disasm) Syms() []Sym       { return d.syms }
 func (d *Disasm) TextStart() uint64 { return d.textStart }
 func (d *Disasm) TextEnd() uint64   { return d.textEnd }
 func (d *Disasm) PCLN() Liner       { return d.pcln }
+func (d *Disasm) GoArch() string    { return d.goarch }
+
+// Archs returns the GOARCH of the architectures that can be disassembled.
+func Archs() []string {
+	archs := make([]string, 0, len(disasms))
+	for arch := range disasms {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+	return archs
+}
+
+// TextBytes returns the bytes of the text segment in [start, end).
+func (d *Disasm) TextBytes(start, end uint64) []byte {
+	if start < d.textStart || end > d.textEnd || start > end {
+		return nil
+	}
+	return d.text[start-d.textStart : end-d.textStart]
+}
+
+// PCLNTab returns the raw pclntab and the start of the text segment.
+func (f *File) PCLNTab() (textStart uint64, pclntab []byte, err error) {
+	textStart, _, pclntab, err = f.entries[0].raw.pcln()
+	return textStart, pclntab, err
+}
+
+// ReadData reads len(data) bytes starting at the virtual address addr.
+func (f *File) ReadData(addr uint64, data []byte) error {
+	return f.entries[0].ReadData(addr, data)
+}
+
+// ReadData reads len(data) bytes starting at the virtual address addr.
+func (e *Entry) ReadData(addr uint64, data []byte) error {
+	end := addr + uint64(len(data))
+	switch raw := e.main().(type) {
+	case *elfFile:
+		for _, s := range raw.elf.Sections {
+			if s.Type != elf.SHT_NOBITS && s.Addr <= addr && end <= s.Addr+s.Size {
+				_, err := s.ReadAt(data, int64(addr-s.Addr))
+				return err
+			}
+		}
+	case *machoFile:
+		for _, s := range raw.macho.Sections {
+			if s.Addr <= addr && end <= s.Addr+s.Size {
+				_, err := s.ReadAt(data, int64(addr-s.Addr))
+				return err
+			}
+		}
+	case *peFile:
+		imageBase, err := raw.imageBase()
+		if err != nil {
+			return err
+		}
+		for _, s := range raw.pe.Sections {
+			start := imageBase + uint64(s.VirtualAddress)
+			if start <= addr && end <= start+uint64(s.Size) {
+				_, err := s.ReadAt(data, int64(addr-start))
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("reading data not supported for %T", e.raw)
+	}
+	return fmt.Errorf("address 0x%x not found", addr)
+}
+
+// WithDebugFile returns f, which uses the symbols and DWARF of debug
+// when f doesn't contain them, e.g. when they have been split into a
+// separate file for distribution. debug must be closed separately.
+func (f *File) WithDebugFile(debug *File) *File {
+	return &File{f.r, []*Entry{{
+		name: f.entries[0].name,
+		raw:  &splitDebug{rawFile: f.entries[0].raw, debug: debug.entries[0].raw},
+	}}}
+}
+
+// splitDebug reads the symbols and DWARF missing from rawFile from debug.
+type splitDebug struct {
+	rawFile
+	debug rawFile
+}
+
+func (f *splitDebug) symbols() ([]Sym, error) {
+	if syms, err := f.rawFile.symbols(); err == nil && len(syms) > 0 {
+		return syms, nil
+	}
+	return f.debug.symbols()
+}
+
+func (f *splitDebug) dwarf() (*dwarf.Data, error) {
+	if data, err := f.rawFile.dwarf(); err == nil {
+		return data, nil
+	}
+	return f.debug.dwarf()
+}
+
+// main returns the raw file that contains the code and data.
+func (e *Entry) main() rawFile {
+	if split, ok := e.raw.(*splitDebug); ok {
+		return split.rawFile
+	}
+	return e.raw
+}
+
+// BuildID returns the hex encoded GNU build-id or the Go build ID of ELF
+// files or the UUID of Mach-O files, which is also present in their
+// separate debug files. It returns "" when the file doesn't have one.
+func (f *File) BuildID() string {
+	switch raw := f.entries[0].main().(type) {
+	case *elfFile:
+		if id := elfNote(raw.elf, ".note.gnu.build-id"); id != nil {
+			return hex.EncodeToString(id)
+		}
+		return string(elfNote(raw.elf, ".note.go.buildid"))
+	case *machoFile:
+		const loadCmdUUID = 0x1b
+		for _, load := range raw.macho.Loads {
+			data := load.Raw()
+			if len(data) >= 24 && raw.macho.ByteOrder.Uint32(data[0:4]) == loadCmdUUID {
+				return hex.EncodeToString(data[8:24])
+			}
+		}
+	}
+	return ""
+}
+
+// EntryPoint returns the address where the execution of the program
+// starts. It returns 0 when the file doesn't have one.
+func (f *File) EntryPoint() uint64 {
+	switch raw := f.entries[0].main().(type) {
+	case *elfFile:
+		return raw.elf.Entry
+	case *peFile:
+		imageBase, err := raw.imageBase()
+		if err != nil {
+			return 0
+		}
+		switch header := raw.pe.OptionalHeader.(type) {
+		case *pe.OptionalHeader32:
+			return imageBase + uint64(header.AddressOfEntryPoint)
+		case *pe.OptionalHeader64:
+			return imageBase + uint64(header.AddressOfEntryPoint)
+		}
+	case *machoFile:
+		// LC_MAIN contains the offset of the entry point in __TEXT and
+		// LC_UNIXTHREAD the initial registers, which include the PC
+		const (
+			loadCmdUnixThread = 0x5
+			loadCmdMain       = 0x80000028
+		)
+		order := raw.macho.ByteOrder
+		for _, load := range raw.macho.Loads {
+			data := load.Raw()
+			if len(data) < 16 {
+				continue
+			}
+			switch order.Uint32(data[0:4]) {
+			case loadCmdMain:
+				if text := raw.macho.Segment("__TEXT"); text != nil {
+					return text.Addr + order.Uint64(data[8:16])
+				}
+			case loadCmdUnixThread:
+				// the registers follow the flavor and count
+				pc := -1
+				switch raw.macho.Cpu {
+				case macho.CpuAmd64:
+					pc = 16 + 16*8 // after rax ... r15
+				case macho.CpuArm64:
+					pc = 16 + 32*8 // after x0 ... x28, fp, lr and sp
+				}
+				if pc >= 0 && len(data) >= pc+8 {
+					return order.Uint64(data[pc : pc+8])
+				}
+			}
+		}
+	}
+	return 0
+}
+
+// elfNote returns the description of the first note in the section.
+func elfNote(f *elf.File, name string) []byte {
+	section := f.Section(name)
+	if section == nil || section.Type != elf.SHT_NOTE {
+		return nil
+	}
+	note, err := section.Data()
+	// namesz, descsz and type are followed by the padded name and desc
+	if err != nil || len(note) < 12 {
+		return nil
+	}
+	nameSize := f.ByteOrder.Uint32(note[0:4])
+	descSize := f.ByteOrder.Uint32(note[4:8])
+	start := 12 + (uint64(nameSize)+3)&^3
+	if start+uint64(descSize) > uint64(len(note)) {
+		return nil
+	}
+	return note[start : start+uint64(descSize)]
+}
@@ -83,7 +83,7 @@ func Open(name string) (*File, error) {
 		}
 	}
 	r.Close()
-	return nil, fmt.Errorf("open %s: unrecognized object file", name)
+	return nil, fmt.Errorf("open %s: %w", name, ErrUnrecognized)
 }
 
 func (f *File) Close() error {
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"loov.dev/lensm/internal/disasm"
 	"loov.dev/lensm/internal/go/src/objfile"
@@ -14,10 +15,13 @@ import (
 
 var rxRefAbs = regexp.MustCompile(`\s0x[\da-fA-F]+$`)
 var rxRefRel = regexp.MustCompile(`\s-?\d+\(PC\)$`)
-var rxCall = regexp.MustCompile(`^CALL\s+([\w\d\/\.\(\)\*]+)\(SB\)`)
+var rxCall = regexp.MustCompile(`^CALL\s+([\w\d\/\.\(\)\*-]+)\(SB\)`)
+var rxDataIP = regexp.MustCompile(`(-?0x[\da-fA-F]+)\(IP\)`)
+var rxDataSym = regexp.MustCompile(`([^\s,$()]+?)([+-]\d+)?\(SB\)`)
 
 // Disassemble disassembles the specified symbol.
 func Disassemble(dis *objfile.Disasm, sym *Function, opts disasm.Options) (*disasm.Code, error) {
+	start := time.Now()
 	neededLines := make(map[string]*disasm.LineSet)
 
 	file, _, _ := dis.PCLN().PCToLine(sym.sym.Addr)
@@ -28,8 +32,34 @@ func Disassemble(dis *objfile.Disasm, sym *Function, opts disasm.Options) (*disa
 		File: file,
 	}
 	var instructions []disasm.Inst
+	var tables jumpTableDetector
+	tableTargets := map[uint64][]uint64{}
+	x86mode := map[string]int{"amd64": 64, "386": 32}[dis.GoArch()]
+	code.X86Mode = x86mode
+
+	// the whole func is decoded, since the window may start in the middle
+	// of an instruction
+	var windowStart, windowEnd uint64
+	windowStart, windowEnd, code.Window = window(sym, opts)
 	dis.Decode(sym.sym.Addr, sym.sym.Addr+uint64(sym.sym.Size), sym.sym.Relocs, false,
 		func(pc, size uint64, file string, line int, text string) {
+			if pc+size <= windowStart || pc >= windowEnd {
+				return
+			}
+			bad := text == "?"
+			if bad {
+				// join consecutive undecodable bytes into a single line
+				if n := len(instructions); n > 0 {
+					prev := &instructions[n-1]
+					if prev.Bad && prev.PC+uint64(prev.Size) == pc && prev.Size < maxBadBytes && prev.File == file && prev.Line == line {
+						prev.Size += int(size)
+						prev.Text = badText(dis.TextBytes(prev.PC, pc+size))
+						return
+					}
+				}
+				text = badText(dis.TextBytes(pc, pc+size))
+			}
+
 			// TODO: find a better way to calculate the jump target
 			var refPC uint64
 			var call string
@@ -46,18 +76,47 @@ func Disassemble(dis *objfile.Disasm, sym *Function, opts disasm.Options) (*disa
 				}
 			} else if match := rxCall.FindStringSubmatch(text); len(match) > 0 {
 				call = match[1]
+				if name, ok := sym.obj.aliases[call]; ok {
+					call = name
+				}
+			}
+
+			var dataPC uint64
+			var pcRelative string
+			if refPC == 0 && call == "" && !bad {
+				dataPC = sym.obj.dataAddr(pc, size, text)
+				if dataPC != 0 && rxDataIP.MatchString(text) {
+					pcRelative = sym.obj.describeAddr(dataPC)
+				}
+			}
+
+			var feature disasm.Feature
+			if x86mode != 0 {
+				feature = disasm.X86Feature(text, dis.TextBytes(pc, pc+size), x86mode)
 			}
 
 			if refPC != 0 {
 				needRefPCs[refPC] = struct{}{}
 			}
+			if targets := tables.Next(sym.obj.objfile, pc, size, text); len(targets) > 0 {
+				tableTargets[pc] = targets
+				for _, target := range targets {
+					needRefPCs[target] = struct{}{}
+				}
+			}
 			instructions = append(instructions, disasm.Inst{
 				PC:    pc,
+				Size:  int(size),
 				Text:  text,
 				File:  file,
 				Line:  line,
+				Bad:   bad,
 				Call:  call,
 				RefPC: refPC,
+
+				DataPC:     dataPC,
+				PCRelative: pcRelative,
+				Feature:    feature,
 			})
 
 			if file != "" && file != "<autogenerated>" {
@@ -70,6 +129,10 @@ func Disassemble(dis *objfile.Disasm, sym *Function, opts disasm.Options) (*disa
 			}
 		})
 
+	for i := range instructions {
+		instructions[i].Preview = sym.obj.previewLocalData(instructions, i)
+	}
+
 	pcToIndex := map[uint64]int{}
 	for _, ix := range instructions {
 		if _, ok := needRefPCs[ix.PC]; ok {
@@ -89,6 +152,26 @@ func Disassemble(dis *objfile.Disasm, sym *Function, opts disasm.Options) (*disa
 	var jumps []jumpInterval
 	for i := range code.Insts {
 		ix := &code.Insts[i]
+		if targets, ok := tableTargets[ix.PC]; ok && ix.Text != "" {
+			jump := jumpInterval{index: i, ix: ix, min: ix.PC, max: ix.PC}
+			for _, pc := range targets {
+				target, ok := pcToIndex[pc]
+				if !ok {
+					continue
+				}
+				ix.RefTable = append(ix.RefTable, target-i)
+				if pc < jump.min {
+					jump.min = pc
+				}
+				if pc > jump.max {
+					jump.max = pc
+				}
+			}
+			if len(ix.RefTable) > 0 {
+				jumps = append(jumps, jump)
+			}
+			continue
+		}
 		if ix.RefPC != 0 {
 			target, ok := pcToIndex[ix.RefPC]
 			if !ok {
@@ -147,8 +230,14 @@ func Disassemble(dis *objfile.Disasm, sym *Function, opts disasm.Options) (*disa
 		}
 		insertToStack(jump.ix, jump.max)
 	}
+	if opts.MaxJumpLanes > 0 && code.MaxJump >= opts.MaxJumpLanes {
+		code.MaxJump = opts.MaxJumpLanes - 1
+	}
 	for i := range code.Insts {
 		ix := &code.Insts[i]
+		if (ix.RefOffset != 0 || len(ix.RefTable) > 0) && ix.RefStack > code.MaxJump {
+			ix.RefOverflow = true
+		}
 		ix.RefStack = code.MaxJump - ix.RefStack + 1
 	}
 	code.MaxJump++
@@ -156,12 +245,18 @@ func Disassemble(dis *objfile.Disasm, sym *Function, opts disasm.Options) (*disa
 	// remove trailing interrupts from funcs
 	for len(code.Insts) > 0 &&
 		(strings.HasPrefix(code.Insts[len(code.Insts)-1].Text, "INT ") ||
-			code.Insts[len(code.Insts)-1].Text == "?") {
+			code.Insts[len(code.Insts)-1].Bad) {
 		code.Insts = code.Insts[:len(code.Insts)-1]
 	}
 
+	sourceStart := time.Now()
+	if opts.Timings != nil {
+		opts.Timings.Disassemble += sourceStart.Sub(start)
+		defer func() { opts.Timings.Source += time.Since(sourceStart) }()
+	}
+
 	// load sources
-	code.Source = LoadSources(neededLines, code.File, opts.Context)
+	code.Source = LoadSources(neededLines, code.File, opts.ContextBefore, opts.ContextAfter, sym.obj.readSource)
 
 	// create a mapping from source code to disassembly
 	type fileLine struct {
@@ -186,20 +281,62 @@ func Disassemble(dis *objfile.Disasm, sym *Function, opts disasm.Options) (*disa
 			block.Related = make([][]disasm.LineRange, len(block.Lines))
 			for line := block.From; line <= block.To; line++ { // todo check: line <= block.To
 				if refs, ok := lineRefs[fileLine{file: src.File, line: line}]; ok {
-					block.Related[line-block.From] = refs.RangesZero()
+					ranges := refs.RangesZero()
+					if opts.MergeLines {
+						ranges = disasm.MergeRanges(ranges, code.Insts)
+					}
+					block.Related[line-block.From] = ranges
 				}
 			}
 		}
 	}
 
+	if opts.AnnotateInstruction != nil {
+		code.Annotate(opts.AnnotateInstruction)
+	}
+	if opts.Coverage != nil {
+		code.Cover(opts.Coverage)
+	}
+	if opts.Anonymize {
+		code.Anonymize()
+	}
+
 	return code, nil
 }
 
-// LoadSources loads the specified line sets.
-func LoadSources(needed map[string]*disasm.LineSet, symbolFile string, context int) []disasm.Source {
+// window returns the addresses of the window of sym from opts.Offset and
+// opts.Length and describes it for Code.Window. The funcs that are
+// shorter than the offset are shown whole.
+func window(sym *Function, opts disasm.Options) (start, end uint64, desc string) {
+	start, end = sym.sym.Addr, sym.sym.Addr+uint64(sym.sym.Size)
+	if opts.Offset == 0 && opts.Length == 0 {
+		return start, end, ""
+	}
+	if opts.Offset >= uint64(sym.sym.Size) {
+		return start, end, fmt.Sprintf("offset %#x is past the end of %#x bytes", opts.Offset, sym.sym.Size)
+	}
+	start += opts.Offset
+	if opts.Length > 0 && opts.Length < end-start {
+		end = start + opts.Length
+	}
+	return start, end, fmt.Sprintf("bytes %#x-%#x of %#x", start-sym.sym.Addr, end-sym.sym.Addr, sym.sym.Size)
+}
+
+// maxBadBytes is the maximum number of undecodable bytes shown in a single line.
+const maxBadBytes = 8
+
+// badText formats undecodable bytes, so that they can be decoded with
+// another tool.
+func badText(data []byte) string {
+	return fmt.Sprintf("(unsupported) % x", data)
+}
+
+// LoadSources loads the specified line sets with before and after lines of
+// context, the files are read with read.
+func LoadSources(needed map[string]*disasm.LineSet, symbolFile string, before, after int, read func(file string) ([]byte, error)) []disasm.Source {
 	var sources []disasm.Source
 	for file, set := range needed {
-		data, err := os.ReadFile(file)
+		data, err := read(file)
 		if err != nil {
 			// TODO: should we create a stub source block instead?
 			fmt.Fprintf(os.Stderr, "unable to load source from %q: %v\n", file, err)
@@ -209,7 +346,7 @@ func LoadSources(needed map[string]*disasm.LineSet, symbolFile string, context i
 		source := disasm.Source{
 			File: file,
 		}
-		for _, r := range set.Ranges(context) {
+		for _, r := range set.RangesAround(before, after) {
 			to := r.To - 1
 			if to > len(lines) {
 				to = len(lines)
@@ -240,3 +377,41 @@ func LoadSources(needed map[string]*disasm.LineSet, symbolFile string, context i
 
 	return sources
 }
+
+// describeAddr describes the effective address of a PC-relative operand
+// using the closest symbol before it.
+func (file *File) describeAddr(addr uint64) string {
+	syms := file.disasm.Syms()
+	i := sort.Search(len(syms), func(i int) bool { return addr < syms[i].Addr }) - 1
+	if i < 0 || syms[i].Addr == 0 {
+		return fmt.Sprintf("= 0x%x", addr)
+	}
+	sym := syms[i]
+	where := "in"
+	if addr >= sym.Addr+uint64(sym.Size) {
+		where = "near"
+	}
+	return fmt.Sprintf("= 0x%x %s %s+0x%x", addr, where, sym.Name, addr-sym.Addr)
+}
+
+// dataAddr returns the address of the data referenced by the instruction
+// at pc, either relative to the instruction pointer or by a symbol.
+func (file *File) dataAddr(pc, size uint64, text string) uint64 {
+	if match := rxDataIP.FindStringSubmatch(text); match != nil {
+		if off, err := strconv.ParseInt(match[1], 0, 64); err == nil {
+			return uint64(int64(pc+size) + off)
+		}
+	}
+	if match := rxDataSym.FindStringSubmatch(text); match != nil {
+		addr, ok := file.data[match[1]]
+		if !ok {
+			return 0
+		}
+		if match[2] != "" {
+			off, _ := strconv.ParseInt(match[2], 10, 64)
+			addr = uint64(int64(addr) + off)
+		}
+		return addr
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+package goobj
+
+import (
+	"debug/dwarf"
+	"strings"
+	"sync"
+)
+
+// signatures looks up the func declarations from DWARF.
+type signatures struct {
+	once sync.Once
+	// mu guards data, which caches the types that it has read.
+	mu   sync.Mutex
+	data *dwarf.Data
+	// decls maps the entry pc of a func to the entry describing the declaration.
+	decls map[uint64]dwarf.Offset
+}
+
+// load indexes the subprograms by their entry pc.
+func (sigs *signatures) load(data *dwarf.Data) {
+	sigs.data = data
+	sigs.decls = map[uint64]dwarf.Offset{}
+
+	r := data.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil || entry == nil {
+			return
+		}
+		if entry.Tag != dwarf.TagSubprogram {
+			continue
+		}
+		if pc, ok := entry.Val(dwarf.AttrLowpc).(uint64); ok {
+			// concrete instances of inlined funcs may omit unused parameters,
+			// hence prefer the abstract declaration
+			decl := entry.Offset
+			if origin, ok := entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset); ok {
+				decl = origin
+			}
+			sigs.decls[pc] = decl
+		}
+		r.SkipChildren()
+	}
+}
+
+// lookup returns the declaration of the func at pc,
+// e.g. "func bytes.(*Buffer).WriteString(b *bytes.Buffer, s string) (int, error)".
+func (sigs *signatures) lookup(pc uint64) string {
+	if sigs.data == nil {
+		return ""
+	}
+	offset, ok := sigs.decls[pc]
+	if !ok {
+		return ""
+	}
+
+	sigs.mu.Lock()
+	defer sigs.mu.Unlock()
+
+	r := sigs.data.Reader()
+	r.Seek(offset)
+	entry, err := r.Next()
+	if err != nil || entry == nil || entry.Tag != dwarf.TagSubprogram {
+		return ""
+	}
+	name, _ := entry.Val(dwarf.AttrName).(string)
+	if name == "" {
+		return ""
+	}
+
+	var params, results []string
+	for entry.Children {
+		child, err := r.Next()
+		if err != nil || child == nil || child.Tag == 0 {
+			break
+		}
+		if child.Children {
+			r.SkipChildren()
+		}
+		if child.Tag != dwarf.TagFormalParameter {
+			continue
+		}
+
+		param := sigs.typeName(child)
+		if name, _ := child.Val(dwarf.AttrName).(string); name != "" && !strings.HasPrefix(name, "~") {
+			param = name + " " + param
+		}
+		if result, _ := child.Val(dwarf.AttrVarParam).(bool); result {
+			results = append(results, param)
+		} else {
+			params = append(params, param)
+		}
+	}
+
+	sig := "func " + name + "(" + strings.Join(params, ", ") + ")"
+	switch {
+	case len(results) == 1 && !strings.Contains(results[0], " "):
+		sig += " " + results[0]
+	case len(results) > 0:
+		sig += " (" + strings.Join(results, ", ") + ")"
+	}
+	return sig
+}
+
+// typeName returns the Go name of the type of entry.
+func (sigs *signatures) typeName(entry *dwarf.Entry) string {
+	offset, ok := entry.Val(dwarf.AttrType).(dwarf.Offset)
+	if !ok {
+		return "?"
+	}
+	typ, err := sigs.data.Type(offset)
+	if err != nil {
+		return "?"
+	}
+	if name := typ.Common().Name; name != "" {
+		return name
+	}
+	switch typ := typ.(type) {
+	case *dwarf.StructType:
+		// slices, strings and interfaces are described as structs
+		return typ.StructName
+	case *dwarf.PtrType:
+		if _, ok := typ.Type.(*dwarf.VoidType); ok {
+			return "unsafe.Pointer"
+		}
+	}
+	return typ.String()
+}
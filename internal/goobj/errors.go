@@ -0,0 +1,36 @@
+package goobj
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"os"
+
+	"loov.dev/lensm/internal/go/src/objfile"
+)
+
+var (
+	// ErrUnrecognized is returned for files that aren't executables or
+	// object files in one of the supported formats, e.g. text or images.
+	ErrUnrecognized = errors.New("not a recognized executable or object format")
+	// ErrNoSymbols is returned for executables without a symbol table,
+	// e.g. after building with -ldflags=-s or running strip.
+	ErrNoSymbols = errors.New("no symbol table, the executable is probably stripped; rebuild without -ldflags=-s or use -debug-file")
+)
+
+// classifyError replaces the errors of opening path and reading its
+// symbols with ErrUnrecognized and ErrNoSymbols, where possible.
+func classifyError(path string, err error) error {
+	if stat, statErr := os.Stat(path); statErr == nil && stat.IsDir() {
+		return fmt.Errorf("%s is a directory: %w", path, ErrUnrecognized)
+	}
+	switch {
+	case errors.Is(err, objfile.ErrUnrecognized):
+		return fmt.Errorf("%s: %w", path, ErrUnrecognized)
+	case errors.Is(err, elf.ErrNoSymbols):
+		return fmt.Errorf("%s: %w", path, ErrNoSymbols)
+	case errors.Is(err, objfile.ErrUnsupportedArch):
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 
 	"loov.dev/lensm/internal/disasm"
 	"loov.dev/lensm/internal/go/src/objfile"
@@ -13,13 +14,39 @@ import (
 
 var _ disasm.File = (*File)(nil)
 var _ disasm.Func = (*Function)(nil)
+var _ disasm.Symbol = (*Function)(nil)
+var _ disasm.Marked = (*Function)(nil)
+var _ disasm.Packaged = (*Function)(nil)
+var _ disasm.Tabled = (*Function)(nil)
+var _ disasm.DataReader = (*File)(nil)
+var _ disasm.Described = (*File)(nil)
+var _ disasm.Started = (*File)(nil)
+var _ disasm.InlineStacker = (*File)(nil)
+
+// Archs returns the GOARCH of the architectures that can be disassembled,
+// e.g. "amd64".
+func Archs() []string { return objfile.Archs() }
 
 // File contains information about the object file.
 type File struct {
+	path    string
 	objfile *objfile.File
-	disasm  *objfile.Disasm
-	funcs   []disasm.Func
+	// debug is the separate file with the symbols and DWARF.
+	debug  *objfile.File
+	disasm *objfile.Disasm
+	funcs  []disasm.Func
+	// aliases maps the local symbols of plugins and shared libraries
+	// to the exported symbol at the same address.
+	aliases map[string]string
+	// data contains the addresses of the data symbols.
+	data map[string]uint64
+
+	signatures signatures
+	inlines    inlines
+	// modules reads the sources missing at their path from the module cache.
+	modules *moduleSources
 
+	mu    sync.Mutex
 	cache map[*Function]*disasm.Code
 }
 
@@ -31,40 +58,125 @@ type Function struct {
 	sym objfile.Sym
 
 	sortName string
+	marker   string
 }
 
 func (fn *Function) Name() string { return fn.sym.Name }
+func (fn *Function) Addr() uint64 { return fn.sym.Addr }
+func (fn *Function) Size() uint64 { return uint64(fn.sym.Size) }
+
+// Marker returns the name of the funcID of special runtime funcs.
+func (fn *Function) Marker() string { return fn.marker }
+
+// Package returns the import path of the package of the func.
+func (fn *Function) Package() string { return packagePath(fn.sym.Name) }
+
+// ReadData reads len(data) bytes starting at the virtual address addr.
+func (file *File) ReadData(addr uint64, data []byte) error {
+	return file.objfile.ReadData(addr, data)
+}
+
+// EntryFunc returns the func that contains the entry point of the program,
+// e.g. _rt0_amd64_linux for Go executables.
+func (file *File) EntryFunc() disasm.Func {
+	entry := file.objfile.EntryPoint()
+	if entry == 0 {
+		return nil
+	}
+	for _, fn := range file.funcs {
+		fn := fn.(*Function)
+		if fn.Addr() <= entry && entry < fn.Addr()+fn.Size() {
+			return fn
+		}
+	}
+	return nil
+}
 
 func (file *File) Close() error {
-	return file.objfile.Close()
+	err := file.objfile.Close()
+	if file.debug != nil {
+		if debugErr := file.debug.Close(); err == nil {
+			err = debugErr
+		}
+	}
+	return err
 }
 
 func Load(path string) (*File, error) {
+	return LoadWithDebugFile(path, "")
+}
+
+// LoadWithDebugFile loads path, reading the symbols and DWARF from
+// debugPath when path doesn't contain them, e.g. after they have been
+// split off with "objcopy --only-keep-debug". The files must have the
+// same build-id, when both of them have one.
+func LoadWithDebugFile(path, debugPath string) (*File, error) {
 	f, err := objfile.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, classifyError(path, err)
+	}
+
+	var debug *objfile.File
+	if debugPath != "" {
+		debug, err = objfile.Open(debugPath)
+		if err != nil {
+			_ = f.Close()
+			return nil, classifyError(debugPath, err)
+		}
+		mainID, debugID := f.BuildID(), debug.BuildID()
+		if mainID != "" && debugID != "" && mainID != debugID {
+			_ = f.Close()
+			_ = debug.Close()
+			return nil, fmt.Errorf("build-id of %s (%s) does not match debug file %s (%s)", path, mainID, debugPath, debugID)
+		}
+		f = f.WithDebugFile(debug)
 	}
 
 	dis, err := f.Disasm()
 	if err != nil {
 		_ = f.Close()
-		return nil, err
+		if debug != nil {
+			_ = debug.Close()
+		}
+		return nil, classifyError(path, err)
 	}
 
 	file := &File{
+		path:    path,
 		objfile: f,
+		debug:   debug,
 		disasm:  dis,
 		cache:   make(map[*Function]*disasm.Code),
+		aliases: make(map[string]string),
+		data:    make(map[string]uint64),
+	}
+
+	// Go plugins and shared libraries contain a "local." prefixed copy
+	// of the symbols, which are used for calls within the module.
+	funcIDs, wrapperID := readFuncIDs(f)
+	exported := map[uint64]string{}
+	for _, sym := range dis.Syms() {
+		if !strings.HasPrefix(sym.Name, localPrefix) {
+			exported[sym.Addr] = sym.Name
+		}
 	}
 
 	for _, sym := range dis.Syms() {
+		if sym.Code != 'T' && sym.Code != 't' {
+			file.data[sym.Name] = sym.Addr
+		}
 		if sym.Code != 'T' && sym.Code != 't' || sym.Addr < dis.TextStart() {
 			continue
 		}
+		if name, ok := exported[sym.Addr]; ok && sym.Name == localPrefix+name {
+			file.aliases[sym.Name] = name
+			continue
+		}
 		sym := &Function{
 			obj:      file,
 			sym:      sym,
 			sortName: sortingName(sym.Name),
+			marker:   funcIDMarker(sym.Name, funcIDs[sym.Addr], wrapperID),
 		}
 		file.funcs = append(file.funcs, sym)
 	}
@@ -80,23 +192,75 @@ func (fn *Function) Load(opts disasm.Options) *disasm.Code {
 	return fn.obj.LoadCode(fn, opts)
 }
 
+// Disassemble disassembles fn without caching the code, which doesn't
+// hold the lock of the file while scanning all the funcs.
+func (fn *Function) Disassemble(opts disasm.Options) *disasm.Code {
+	return fn.obj.disassemble(fn, opts)
+}
+
 func (file *File) LoadCode(fn *Function, opts disasm.Options) *disasm.Code {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+
 	code, ok := file.cache[fn]
 	if !ok {
-		var err error
-		code, err = Disassemble(fn.obj.disasm, fn, opts)
+		code = file.disassemble(fn, opts)
 		file.cache[fn] = code
-		if err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err)
-		}
 	}
 
 	return code
 }
 
+// disassemble disassembles fn with the details that the file knows about it.
+func (file *File) disassemble(fn *Function, opts disasm.Options) *disasm.Code {
+	code, err := Disassemble(file.disasm, fn, opts)
+	code.Signature = file.signature(fn)
+	code.Marker = fn.Marker()
+	code.Package = fn.Package()
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+	}
+	return code
+}
+
+// signature returns the declaration of fn from DWARF, when available.
+func (file *File) signature(fn *Function) string {
+	file.signatures.once.Do(func() {
+		if data, err := file.objfile.DWARF(); err == nil {
+			file.signatures.load(data)
+		}
+	})
+	return file.signatures.lookup(fn.sym.Addr)
+}
+
+// localPrefix is the prefix of the module local symbols.
+const localPrefix = "local."
+
 var rxCodeDelimiter = regexp.MustCompile(`[ *().]+`)
 
 func sortingName(sym string) string {
 	sym = strings.ToLower(sym)
 	return rxCodeDelimiter.ReplaceAllString(sym, " ")
 }
+
+// packagePath returns the import path of the package of the symbol name,
+// e.g. "loov.dev/lensm/internal/disasm" for "loov.dev/lensm/internal/disasm.(*Code).Collapse".
+// It returns "" for the symbols that don't belong to a package.
+func packagePath(name string) string {
+	name = strings.TrimPrefix(name, localPrefix)
+	// type arguments can contain other import paths
+	if bracket := strings.IndexByte(name, '['); bracket >= 0 {
+		name = name[:bracket]
+	}
+	slash := strings.LastIndexByte(name, '/')
+	dot := strings.IndexByte(name[slash+1:], '.')
+	if dot <= 0 {
+		return ""
+	}
+	path := name[:slash+1+dot]
+	// e.g. "type:.eq.main.T" and "go:buildid"
+	if strings.Contains(path, ":") {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,137 @@
+package goobj
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"loov.dev/lensm/internal/go/src/objfile"
+)
+
+// funcIDMarker describes the funcID of the func with the specified name.
+// The numbering of the funcIDs changes between Go versions, except that the
+// wrapper is the last one, hence the special funcs are described by their name.
+func funcIDMarker(name string, funcID, wrapperID uint8) string {
+	switch {
+	case funcID == 0:
+		return ""
+	case funcID == wrapperID:
+		return "wrapper"
+	case strings.HasPrefix(name, "runtime."):
+		return strings.TrimSuffix(strings.TrimPrefix(name, "runtime."), ".abi0")
+	default:
+		return fmt.Sprintf("funcID %d", funcID)
+	}
+}
+
+// pclntab magic numbers of the supported layouts.
+const (
+	pclntabGo118 = 0xfffffff0
+	pclntabGo120 = 0xfffffff1
+)
+
+// pclnHeader is the parsed header of a pclntab in the layout of Go 1.18
+// and later.
+type pclnHeader struct {
+	order     binary.ByteOrder
+	magic     uint32
+	quantum   int
+	ptrSize   int
+	textStart uint64
+	nfunc     int
+	// cuTab, fileTab, pcTab and funcTab are the sub-tables of the pclntab.
+	cuTab, fileTab, pcTab, funcTab []byte
+}
+
+// readPCLNHeader parses the pclntab of f, it returns false for the
+// layouts before Go 1.18.
+func readPCLNHeader(f *objfile.File) (*pclnHeader, bool) {
+	textStart, tab, err := f.PCLNTab()
+	if err != nil || len(tab) < 8 {
+		return nil, false
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	magic := order.Uint32(tab)
+	if magic != pclntabGo118 && magic != pclntabGo120 {
+		order = binary.BigEndian
+		magic = order.Uint32(tab)
+	}
+	if magic != pclntabGo118 && magic != pclntabGo120 {
+		return nil, false
+	}
+
+	ptrSize := int(tab[7])
+	if ptrSize != 4 && ptrSize != 8 {
+		return nil, false
+	}
+	word := func(i int) uint64 {
+		at := 8 + i*ptrSize
+		if at+ptrSize > len(tab) {
+			return 0
+		}
+		if ptrSize == 4 {
+			return uint64(order.Uint32(tab[at:]))
+		}
+		return order.Uint64(tab[at:])
+	}
+	if start := word(2); start != 0 {
+		textStart = start
+	}
+	sub := func(i int) []byte {
+		if off := word(i); off < uint64(len(tab)) {
+			return tab[off:]
+		}
+		return nil
+	}
+	header := &pclnHeader{
+		order:     order,
+		magic:     magic,
+		quantum:   int(tab[6]),
+		ptrSize:   ptrSize,
+		textStart: textStart,
+		nfunc:     int(word(0)),
+		cuTab:     sub(4),
+		fileTab:   sub(5),
+		pcTab:     sub(6),
+		funcTab:   sub(7),
+	}
+	if header.funcTab == nil {
+		return nil, false
+	}
+	return header, true
+}
+
+// funcIDOffset returns the offset of the funcID in the _func struct.
+func (header *pclnHeader) funcIDOffset() int {
+	if header.magic == pclntabGo120 {
+		// startLine was added before the funcID
+		return 40
+	}
+	return 36
+}
+
+// readFuncIDs reads the funcID of each special func from the pclntab,
+// indexed by the entry address, and the largest funcID, which is the wrapper.
+// It returns nil for unsupported layouts.
+func readFuncIDs(f *objfile.File) (ids map[uint64]uint8, wrapperID uint8) {
+	header, ok := readPCLNHeader(f)
+	if !ok {
+		return nil, 0
+	}
+	order, funcTab, funcIDOffset := header.order, header.funcTab, header.funcIDOffset()
+
+	ids = map[uint64]uint8{}
+	for i := 0; i < header.nfunc && (i+1)*8 <= len(funcTab); i++ {
+		entryOff := order.Uint32(funcTab[i*8:])
+		funcOff := int(order.Uint32(funcTab[i*8+4:]))
+		if funcOff+funcIDOffset >= len(funcTab) {
+			continue
+		}
+		if id := funcTab[funcOff+funcIDOffset]; id != 0 {
+			ids[header.textStart+uint64(entryOff)] = id
+			wrapperID = max(wrapperID, id)
+		}
+	}
+	return ids, wrapperID
+}
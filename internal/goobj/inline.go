@@ -0,0 +1,239 @@
+package goobj
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"sort"
+	"strings"
+	"sync"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// inlines looks up the inlined calls of the instructions from DWARF.
+type inlines struct {
+	once sync.Once
+	// loaded is closed after the index has been built in the background,
+	// the fields below are valid only then.
+	loaded chan struct{}
+
+	data *dwarf.Data
+	// funcs are the concrete subprograms sorted by their start.
+	funcs []inlineFunc
+
+	mu sync.Mutex
+	// files caches the file tables of the compile units by their offset.
+	files map[dwarf.Offset][]*dwarf.LineFile
+}
+
+// inlineFunc is the location of a subprogram, whose children describe
+// the inlined calls.
+type inlineFunc struct {
+	low, high uint64
+	offset    dwarf.Offset
+	unit      *dwarf.Entry
+}
+
+// load indexes the subprograms by their address range.
+func (index *inlines) load(data *dwarf.Data) {
+	index.data = data
+	index.files = map[dwarf.Offset][]*dwarf.LineFile{}
+
+	var unit *dwarf.Entry
+	r := data.Reader()
+	for {
+		entry, err := r.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		switch entry.Tag {
+		case dwarf.TagCompileUnit:
+			unit = entry
+			continue
+		case dwarf.TagSubprogram:
+			ranges, err := data.Ranges(entry)
+			if err == nil && len(ranges) > 0 && unit != nil {
+				index.funcs = append(index.funcs, inlineFunc{
+					low:    ranges[0][0],
+					high:   ranges[0][1],
+					offset: entry.Offset,
+					unit:   unit,
+				})
+			}
+		}
+		if entry.Children {
+			r.SkipChildren()
+		}
+	}
+	sort.Slice(index.funcs, func(i, k int) bool { return index.funcs[i].low < index.funcs[k].low })
+}
+
+// calls returns the inlined subroutines that contain pc from the outermost
+// to the innermost and the subprogram that contains them.
+func (index *inlines) calls(pc uint64) (fn *dwarf.Entry, unit *dwarf.Entry, chain []*dwarf.Entry) {
+	i := sort.Search(len(index.funcs), func(i int) bool { return index.funcs[i].high > pc })
+	if i >= len(index.funcs) || index.funcs[i].low > pc {
+		return nil, nil, nil
+	}
+	sub := index.funcs[i]
+
+	r := index.data.Reader()
+	r.Seek(sub.offset)
+	fn, err := r.Next()
+	if err != nil || fn == nil || !fn.Children {
+		return nil, nil, nil
+	}
+	for {
+		entry, err := r.Next()
+		if err != nil || entry == nil || entry.Tag == 0 {
+			// the end of the children of the innermost containing entry
+			break
+		}
+		nested := entry.Tag == dwarf.TagInlinedSubroutine || entry.Tag == dwarf.TagLexDwarfBlock
+		if !nested || !index.contains(entry, pc) {
+			if entry.Children {
+				r.SkipChildren()
+			}
+			continue
+		}
+		if entry.Tag == dwarf.TagInlinedSubroutine {
+			chain = append(chain, entry)
+		}
+		if !entry.Children {
+			break
+		}
+	}
+	return fn, sub.unit, chain
+}
+
+// contains checks whether the ranges of entry contain pc.
+func (index *inlines) contains(entry *dwarf.Entry, pc uint64) bool {
+	ranges, err := index.data.Ranges(entry)
+	if err != nil {
+		return false
+	}
+	for _, r := range ranges {
+		if r[0] <= pc && pc < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// name returns the name of the func of entry, which is in the abstract
+// origin for the inlined and the concrete instances.
+func (index *inlines) name(entry *dwarf.Entry) string {
+	if name, ok := entry.Val(dwarf.AttrName).(string); ok {
+		return name
+	}
+	origin, ok := entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+	if !ok {
+		return "?"
+	}
+	r := index.data.Reader()
+	r.Seek(origin)
+	abstract, err := r.Next()
+	if err != nil || abstract == nil {
+		return "?"
+	}
+	if name, ok := abstract.Val(dwarf.AttrName).(string); ok {
+		return name
+	}
+	return "?"
+}
+
+// callFile returns the name of the file of the call of the inlined entry.
+func (index *inlines) callFile(unit, entry *dwarf.Entry) string {
+	n, ok := entry.Val(dwarf.AttrCallFile).(int64)
+	if !ok {
+		return ""
+	}
+
+	index.mu.Lock()
+	files, ok := index.files[unit.Offset]
+	if !ok {
+		if lines, err := index.data.LineReader(unit); err == nil && lines != nil {
+			files = lines.Files()
+		}
+		index.files[unit.Offset] = files
+	}
+	index.mu.Unlock()
+
+	if n < 0 || int(n) >= len(files) || files[n] == nil {
+		return ""
+	}
+	return files[n].Name
+}
+
+// InlineStack returns the inlined calls of the instruction at pc from
+// DWARF, see disasm.InlineStacker. The first call starts indexing the
+// subprograms in the background, until then the stacks are pending.
+func (file *File) InlineStack(pc uint64) (frames []disasm.InlineFrame, pending bool) {
+	index := &file.inlines
+	index.once.Do(func() {
+		index.loaded = make(chan struct{})
+		go func() {
+			defer close(index.loaded)
+			if data, err := file.objfile.DWARF(); err == nil {
+				index.load(data)
+			}
+		}()
+	})
+	select {
+	case <-index.loaded:
+	default:
+		return nil, true
+	}
+	if index.data == nil {
+		return nil, false
+	}
+
+	fn, unit, chain := index.calls(pc)
+	if len(chain) == 0 {
+		return nil, false
+	}
+
+	innerFile, innerLine, _ := file.disasm.PCLN().PCToLine(pc)
+	frames = []disasm.InlineFrame{{
+		Func: index.name(chain[len(chain)-1]),
+		File: innerFile,
+		Line: innerLine,
+	}}
+	for k := len(chain) - 1; k >= 0; k-- {
+		caller := fn
+		if k > 0 {
+			caller = chain[k-1]
+		}
+		line, _ := chain[k].Val(dwarf.AttrCallLine).(int64)
+		frames = append(frames, disasm.InlineFrame{
+			Func: index.name(caller),
+			File: index.callFile(unit, chain[k]),
+			Line: int(line),
+		})
+	}
+
+	for i := range frames {
+		frames[i].Text = file.sourceLine(frames[i].File, frames[i].Line)
+	}
+	return frames, false
+}
+
+// sourceLine returns the trimmed text of the line in the source file.
+func (file *File) sourceLine(name string, line int) string {
+	if name == "" || line <= 0 {
+		return ""
+	}
+	data, err := file.readSource(name)
+	if err != nil {
+		return ""
+	}
+	for n := 1; n < line; n++ {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return ""
+		}
+		data = data[i+1:]
+	}
+	text, _, _ := bytes.Cut(data, []byte{'\n'})
+	return strings.TrimSpace(string(text))
+}
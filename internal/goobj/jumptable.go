@@ -0,0 +1,88 @@
+package goobj
+
+import (
+	"encoding/binary"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"loov.dev/lensm/internal/go/src/objfile"
+)
+
+var (
+	rxTableBound = regexp.MustCompile(`^CMP[QL]\s+\$(0x[\da-fA-F]+), (\w+)$`)
+	rxTableAddr  = regexp.MustCompile(`^LEAQ\s+(-?0x[\da-fA-F]+)\(IP\), (\w+)$`)
+	rxTableJump  = regexp.MustCompile(`^JMP\s+0\((\w+)\)\((\w+)\*8\)$`)
+)
+
+// maxJumpTable is the maximum number of entries read from a jump table.
+const maxJumpTable = 1 << 12
+
+// jumpTableDetector detects amd64 jump tables in the form:
+//
+//	CMPQ $0x9, AX
+//	JA 0x48304a
+//	LEAQ 0xd79d(IP), CX
+//	JMP 0(CX)(AX*8)
+//
+// The instructions must be adjacent, otherwise the registers may have
+// been changed in between.
+type jumpTableDetector struct {
+	// step is the number of the instructions of the form seen so far.
+	step  int
+	bound struct {
+		reg   string
+		count uint64
+	}
+	table struct {
+		reg  string
+		addr uint64
+	}
+}
+
+// Next processes the next instruction and returns the addresses
+// of the jump table targets, when it's an indirect jump using a table.
+func (det *jumpTableDetector) Next(obj *objfile.File, pc, size uint64, text string) []uint64 {
+	step := det.step
+	det.step = 0
+	if match := rxTableBound.FindStringSubmatch(text); match != nil {
+		if bound, err := strconv.ParseUint(match[1][2:], 16, 64); err == nil && bound < maxJumpTable {
+			det.bound.reg, det.bound.count = match[2], bound+1
+			det.step = 1
+		}
+		return nil
+	}
+	switch step {
+	case 1:
+		// the jump to the default case
+		if strings.HasPrefix(text, "J") && !strings.HasPrefix(text, "JMP") {
+			det.step = 2
+		}
+		return nil
+	case 2:
+		if match := rxTableAddr.FindStringSubmatch(text); match != nil {
+			if off, err := strconv.ParseInt(match[1], 0, 64); err == nil {
+				det.table.reg, det.table.addr = match[2], uint64(int64(pc+size)+off)
+				det.step = 3
+			}
+		}
+		return nil
+	}
+	if step != 3 {
+		return nil
+	}
+	match := rxTableJump.FindStringSubmatch(text)
+	if match == nil || match[1] != det.table.reg || match[2] != det.bound.reg {
+		return nil
+	}
+
+	data := make([]byte, det.bound.count*8)
+	if err := obj.ReadData(det.table.addr, data); err != nil {
+		return nil
+	}
+	targets := make([]uint64, det.bound.count)
+	for i := range targets {
+		targets[i] = binary.LittleEndian.Uint64(data[i*8:])
+	}
+	return targets
+}
@@ -0,0 +1,30 @@
+package goobj
+
+import "testing"
+
+func TestJumpTableDetectorInterrupted(t *testing.T) {
+	// the table isn't read, so the file isn't needed
+	tests := []struct {
+		name  string
+		insts []string
+	}{
+		{"bound reloaded", []string{"CMPQ $0x9, AX", "JA 0x482fd9", "MOVQ 0x8(SP), AX", "LEAQ 0xd77d(IP), CX", "JMP 0(CX)(AX*8)"}},
+		{"table reloaded", []string{"CMPQ $0x9, AX", "JA 0x482fd9", "LEAQ 0xd77d(IP), CX", "ADDQ $0x8, CX", "JMP 0(CX)(AX*8)"}},
+		{"without bound", []string{"LEAQ 0xd77d(IP), CX", "JMP 0(CX)(AX*8)"}},
+		{"without default", []string{"CMPQ $0x9, AX", "LEAQ 0xd77d(IP), CX", "JMP 0(CX)(AX*8)"}},
+		{"other registers", []string{"CMPQ $0x9, AX", "JA 0x482fd9", "LEAQ 0xd77d(IP), CX", "JMP 0(DX)(AX*8)"}},
+		{"too large", []string{"CMPQ $0x10000, AX", "JA 0x482fd9", "LEAQ 0xd77d(IP), CX", "JMP 0(CX)(AX*8)"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var det jumpTableDetector
+			pc := uint64(0x482e92)
+			for _, text := range test.insts {
+				if targets := det.Next(nil, pc, 4, text); targets != nil {
+					t.Errorf("%q: got %v", text, targets)
+				}
+				pc += 4
+			}
+		})
+	}
+}
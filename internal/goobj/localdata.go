@@ -0,0 +1,127 @@
+package goobj
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+const (
+	// maxPreviewString is the number of bytes of a string shown in a preview.
+	maxPreviewString = 48
+	// previewBytes is the number of bytes shown of the other data.
+	previewBytes = 16
+)
+
+// rxStringLength matches the length of a string moved to a register after
+// its address, e.g. "MOVL $0xd, BX".
+var rxStringLength = regexp.MustCompile(`^MOV[LQ] \$(0x[\da-fA-F]+|\d+), ([A-Z0-9]+)$`)
+
+// nextIntRegister is the register of the length of a string in the amd64
+// register ABI, which follows the register of the pointer.
+var nextIntRegister = map[string]string{
+	"AX": "BX", "BX": "CX", "CX": "DI", "DI": "SI", "SI": "R8",
+	"R8": "R9", "R9": "R10", "R10": "R11",
+}
+
+// isLocalData checks whether the data at addr doesn't have a symbol of its
+// own, which is the case for the string literals and the static tables of
+// the funcs that the linker places between the symbols. The type
+// descriptors and the GC bitmaps are excluded.
+func (file *File) isLocalData(addr uint64) bool {
+	syms := file.disasm.Syms()
+	i := sort.Search(len(syms), func(i int) bool { return addr < syms[i].Addr }) - 1
+	if i < 0 || syms[i].Addr == 0 {
+		return false
+	}
+	sym := syms[i]
+	return addr >= sym.Addr+uint64(sym.Size) && sym.Code != 'T' && !strings.HasPrefix(sym.Name, "type:") && !strings.HasPrefix(sym.Name, "runtime.gcbits.")
+}
+
+// previewLocalData formats the local data referenced by insts[i], either as
+// a quoted string, when the following instructions load its length, or as
+// the first bytes, which are quoted when they are text.
+func (file *File) previewLocalData(insts []disasm.Inst, i int) string {
+	addr := insts[i].DataPC
+	if addr == 0 || insts[i].PCRelative == "" || !file.isLocalData(addr) {
+		return ""
+	}
+
+	_, dst, _ := strings.Cut(insts[i].Text, ", ")
+	lengthRegister := nextIntRegister[dst]
+	for _, next := range insts[i+1 : min(i+4, len(insts))] {
+		match := rxStringLength.FindStringSubmatch(next.Text)
+		if match == nil || match[2] != lengthRegister {
+			continue
+		}
+		length, err := strconv.ParseUint(match[1], 0, 64)
+		if err != nil || length == 0 || length > 1<<16 {
+			break
+		}
+		data := make([]byte, min(length, maxPreviewString))
+		if file.ReadData(addr, data) != nil || !printable(data) {
+			break
+		}
+		text := strconv.Quote(string(data))
+		if length > maxPreviewString {
+			text += "…"
+		}
+		return text
+	}
+
+	data := make([]byte, previewBytes)
+	if file.ReadData(addr, data) != nil {
+		return ""
+	}
+	if printable(data) {
+		return strconv.Quote(string(data)) + "…"
+	}
+	if text, ok := file.stringHeader(data); ok {
+		return "→ " + text
+	}
+	return fmt.Sprintf("[% x …]", data)
+}
+
+// stringHeader formats the string that data points to, when it starts with
+// the pointer and the length of a string on a 64 bit little endian arch,
+// e.g. the static temps of string values.
+func (file *File) stringHeader(data []byte) (string, bool) {
+	switch file.disasm.GoArch() {
+	case "amd64", "arm64":
+	default:
+		return "", false
+	}
+	ptr, length := binary.LittleEndian.Uint64(data[0:8]), binary.LittleEndian.Uint64(data[8:16])
+	if length == 0 || length > 1<<16 || !file.isLocalData(ptr) {
+		return "", false
+	}
+	text := make([]byte, min(length, maxPreviewString))
+	if file.ReadData(ptr, text) != nil || !printable(text) {
+		return "", false
+	}
+	quoted := strconv.Quote(string(text))
+	if length > maxPreviewString {
+		quoted += "…"
+	}
+	return quoted, true
+}
+
+// printable checks whether data is text.
+func printable(data []byte) bool {
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size <= 1 || !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			// the string may be cut in the middle of a rune
+			return len(data) < utf8.UTFMax && !utf8.FullRune(data)
+		}
+		data = data[size:]
+	}
+	return true
+}
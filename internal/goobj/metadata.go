@@ -0,0 +1,39 @@
+package goobj
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// Metadata returns the build ID and the build information embedded by the
+// Go toolchain, which includes the main module, the VCS info and the build
+// settings, e.g. -ldflags. Files built without module support only have
+// the build ID.
+func (file *File) Metadata() []disasm.Property {
+	var props []disasm.Property
+	add := func(key, value string) {
+		if value != "" {
+			props = append(props, disasm.Property{Key: key, Value: value})
+		}
+	}
+
+	add("build id", file.objfile.BuildID())
+
+	info, err := buildinfo.ReadFile(file.path)
+	if err != nil {
+		return props
+	}
+	add("go", info.GoVersion)
+	add("path", info.Path)
+	add("mod", strings.TrimSpace(info.Main.Path+" "+info.Main.Version+" "+info.Main.Sum))
+	if len(info.Deps) > 0 {
+		add("deps", fmt.Sprintf("%d modules", len(info.Deps)))
+	}
+	for _, setting := range info.Settings {
+		add(setting.Key, setting.Value)
+	}
+	return props
+}
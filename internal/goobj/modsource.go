@@ -0,0 +1,143 @@
+package goobj
+
+import (
+	"archive/zip"
+	"debug/buildinfo"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// module is a module version that the executable was built with.
+type module struct {
+	path    string
+	version string
+}
+
+// moduleSources reads the sources that aren't available at their recorded
+// path from the module cache, using the module versions from the
+// build info, e.g. for released binaries built elsewhere or with -trimpath.
+type moduleSources struct {
+	// dir is the module cache, e.g. $GOPATH/pkg/mod.
+	dir string
+	// modules are sorted by descending path length, so that nested modules
+	// are found before their parents.
+	modules []module
+}
+
+// UseModuleCache reads the sources that are missing at their recorded path
+// from the module cache dir, e.g. the output of "go env GOMODCACHE". Both
+// the extracted modules and the downloaded zips are used.
+func (file *File) UseModuleCache(dir string) error {
+	info, err := buildinfo.ReadFile(file.path)
+	if err != nil {
+		return err
+	}
+
+	sources := &moduleSources{dir: dir}
+	add := func(mod *debug.Module) {
+		for mod.Replace != nil {
+			mod = mod.Replace
+		}
+		// local replacements and builds don't have a version in the cache
+		if mod.Version != "" && mod.Version != "(devel)" {
+			sources.modules = append(sources.modules, module{path: mod.Path, version: mod.Version})
+		}
+	}
+	add(&info.Main)
+	for _, dep := range info.Deps {
+		add(dep)
+	}
+	sort.SliceStable(sources.modules, func(i, k int) bool {
+		return len(sources.modules[i].path) > len(sources.modules[k].path)
+	})
+
+	file.mu.Lock()
+	defer file.mu.Unlock()
+	file.modules = sources
+	return nil
+}
+
+// readSource reads the source file, falling back to the module cache.
+func (file *File) readSource(name string) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	if err == nil || file.modules == nil {
+		return data, err
+	}
+	if cached, ok := file.modules.read(name); ok {
+		return cached, nil
+	}
+	return nil, err
+}
+
+// read finds name in the module cache. The name is either within a
+// versioned module directory, e.g. "/home/x/go/pkg/mod/example.com/m@v1.0.0/a.go",
+// or starts with the module path, e.g. "example.com/m/a.go" with -trimpath.
+func (sources *moduleSources) read(name string) ([]byte, bool) {
+	name = filepath.ToSlash(name)
+	for _, mod := range sources.modules {
+		rest, ok := "", false
+		for _, dir := range []string{mod.path + "@" + mod.version, escapeModule(mod.path) + "@" + escapeModule(mod.version)} {
+			if _, after, found := strings.Cut(name, dir+"/"); found {
+				rest, ok = after, true
+				break
+			}
+		}
+		if !ok && strings.HasPrefix(name, mod.path+"/") {
+			rest, ok = strings.TrimPrefix(name, mod.path+"/"), true
+		}
+		if !ok {
+			continue
+		}
+		if data, ok := sources.readModule(mod, rest); ok {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// readModule reads the file at rest within the module from the extracted
+// module or from its downloaded zip.
+func (sources *moduleSources) readModule(mod module, rest string) ([]byte, bool) {
+	escaped := escapeModule(mod.path)
+	version := escapeModule(mod.version)
+
+	extracted := filepath.Join(sources.dir, filepath.FromSlash(escaped+"@"+version), filepath.FromSlash(rest))
+	if data, err := os.ReadFile(extracted); err == nil {
+		return data, true
+	}
+
+	archive := filepath.Join(sources.dir, "cache", "download", filepath.FromSlash(escaped), "@v", version+".zip")
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, false
+	}
+	defer func() { _ = zr.Close() }()
+
+	f, err := zr.Open(path.Join(mod.path+"@"+mod.version, rest))
+	if err != nil {
+		return nil, false
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	return data, err == nil
+}
+
+// escapeModule escapes the upper case letters of a module path or version
+// like the module cache, e.g. "github.com/!burnt!sushi/toml".
+func escapeModule(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
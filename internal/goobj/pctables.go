@@ -0,0 +1,124 @@
+package goobj
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// Offsets of the fields in the _func struct of the pclntab.
+const (
+	funcPCSPOffset      = 16
+	funcPCFileOffset    = 20
+	funcPCLineOffset    = 24
+	funcCUOffset        = 32
+	funcStartLineOffset = 36
+)
+
+// PCTables decodes the pcsp, pcfile and pcline tables of the func from
+// the pclntab.
+func (fn *Function) PCTables() (*disasm.PCTables, error) {
+	header, ok := readPCLNHeader(fn.obj.objfile)
+	if !ok {
+		return nil, errors.New("pclntab layout before Go 1.18 is not supported")
+	}
+	entry, info, ok := header.findFunc(fn.sym.Addr)
+	if !ok || len(info) < funcStartLineOffset+4 {
+		return nil, fmt.Errorf("%s not found in pclntab", fn.Name())
+	}
+
+	order := header.order
+	tables := &disasm.PCTables{
+		Entry:      entry,
+		Quantum:    header.quantum,
+		SPOffset:   order.Uint32(info[funcPCSPOffset:]),
+		FileOffset: order.Uint32(info[funcPCFileOffset:]),
+		LineOffset: order.Uint32(info[funcPCLineOffset:]),
+		Files:      map[int32]string{},
+	}
+	if header.magic == pclntabGo120 {
+		tables.StartLine = int(int32(order.Uint32(info[funcStartLineOffset:])))
+	}
+	tables.SP = header.decodePCValues(tables.SPOffset, entry)
+	tables.File = header.decodePCValues(tables.FileOffset, entry)
+	tables.Line = header.decodePCValues(tables.LineOffset, entry)
+
+	cuOffset := order.Uint32(info[funcCUOffset:])
+	for _, value := range tables.File {
+		if _, ok := tables.Files[value.Value]; !ok {
+			tables.Files[value.Value] = header.fileName(cuOffset, value.Value)
+		}
+	}
+	return tables, nil
+}
+
+// findFunc returns the entry and the _func struct of the func at addr.
+func (header *pclnHeader) findFunc(addr uint64) (entry uint64, info []byte, ok bool) {
+	order, funcTab := header.order, header.funcTab
+	n := min(header.nfunc, len(funcTab)/8)
+	entryAt := func(i int) uint64 { return header.textStart + uint64(order.Uint32(funcTab[i*8:])) }
+	i := sort.Search(n, func(i int) bool { return entryAt(i) >= addr })
+	if i >= n || entryAt(i) != addr {
+		return 0, nil, false
+	}
+	funcOff := int(order.Uint32(funcTab[i*8+4:]))
+	if funcOff >= len(funcTab) {
+		return 0, nil, false
+	}
+	return addr, funcTab[funcOff:], true
+}
+
+// decodePCValues decodes the pcvalue table at off in the pctab, where
+// each entry is a zig-zag encoded value delta followed by a pc delta.
+func (header *pclnHeader) decodePCValues(off uint32, entry uint64) []disasm.PCValue {
+	if off == 0 || int(off) >= len(header.pcTab) {
+		return nil
+	}
+	p := header.pcTab[off:]
+
+	var values []disasm.PCValue
+	pc, value := entry, int32(-1)
+	for first := true; ; first = false {
+		uvdelta, n := binary.Uvarint(p)
+		if n <= 0 || uvdelta == 0 && !first {
+			break
+		}
+		p = p[n:]
+		vdelta := int32(uvdelta >> 1)
+		if uvdelta&1 != 0 {
+			vdelta = ^vdelta
+		}
+		pcdelta, n := binary.Uvarint(p)
+		if n <= 0 {
+			break
+		}
+		p = p[n:]
+
+		from := pc
+		pc += pcdelta * uint64(header.quantum)
+		value += vdelta
+		values = append(values, disasm.PCValue{From: from, To: pc, Value: value})
+	}
+	return values
+}
+
+// fileName returns the name of the file index of the compilation unit.
+func (header *pclnHeader) fileName(cuOffset uint32, index int32) string {
+	at := int(cuOffset+uint32(index)) * 4
+	if index < 0 || at+4 > len(header.cuTab) {
+		return fmt.Sprintf("<file %d>", index)
+	}
+	off := header.order.Uint32(header.cuTab[at:])
+	if off == ^uint32(0) || int(off) >= len(header.fileTab) {
+		return fmt.Sprintf("<file %d>", index)
+	}
+	name := header.fileTab[off:]
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+	return string(name)
+}
@@ -0,0 +1,70 @@
+package goobj
+
+import (
+	"reflect"
+	"testing"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+func TestDecodePCValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		quantum int
+		table   []byte
+		want    []disasm.PCValue
+	}{
+		{
+			name:    "deltas",
+			quantum: 1,
+			table:   []byte{0x02, 0x04, 0x10, 0x02, 0x0f, 0x03, 0x00},
+			want: []disasm.PCValue{
+				{From: 0x1000, To: 0x1004, Value: 0},
+				{From: 0x1004, To: 0x1006, Value: 8},
+				{From: 0x1006, To: 0x1009, Value: 0},
+			},
+		},
+		{
+			name:    "quantum",
+			quantum: 4,
+			table:   []byte{0x02, 0x01, 0x02, 0x03, 0x00},
+			want: []disasm.PCValue{
+				{From: 0x1000, To: 0x1004, Value: 0},
+				{From: 0x1004, To: 0x1010, Value: 1},
+			},
+		},
+		{
+			name:    "first value unchanged",
+			quantum: 1,
+			table:   []byte{0x00, 0x04, 0x00},
+			want:    []disasm.PCValue{{From: 0x1000, To: 0x1004, Value: -1}},
+		},
+		{
+			name:    "varints",
+			quantum: 1,
+			table:   []byte{0x80, 0x01, 0x80, 0x02, 0x00},
+			want:    []disasm.PCValue{{From: 0x1000, To: 0x1100, Value: 63}},
+		},
+		{
+			name:    "truncated",
+			quantum: 1,
+			table:   []byte{0x02, 0x04, 0x10},
+			want:    []disasm.PCValue{{From: 0x1000, To: 0x1004, Value: 0}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the offset 0 means that the func doesn't have the table
+			header := &pclnHeader{quantum: test.quantum, pcTab: append([]byte{0}, test.table...)}
+			if got := header.decodePCValues(1, 0x1000); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+			if got := header.decodePCValues(0, 0x1000); got != nil {
+				t.Errorf("offset 0: got %v, want nil", got)
+			}
+			if got := header.decodePCValues(uint32(len(header.pcTab)), 0x1000); got != nil {
+				t.Errorf("offset past the end: got %v, want nil", got)
+			}
+		})
+	}
+}
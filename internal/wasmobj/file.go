@@ -93,9 +93,16 @@ func (file *File) LoadCode(fn *Func, opts disasm.Options) *disasm.Code {
 	for i, b := range fn.code.Body {
 		code.Insts = append(code.Insts, disasm.Inst{
 			PC:   uint64(i),
+			Size: 1,
 			Text: fmt.Sprintf("BYTE 0x%0x2", b),
 		})
 	}
+	if opts.AnnotateInstruction != nil {
+		code.Annotate(opts.AnnotateInstruction)
+	}
+	if opts.Coverage != nil {
+		code.Cover(opts.Coverage)
+	}
 	return code
 }
 
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// JumpTargets defines how the target operands of the jumps are displayed.
+type JumpTargets int
+
+const (
+	// JumpTargetsDecoded shows the targets as decoded, which is the
+	// address on x86 and the offset in instructions, e.g. 15(PC), on arm64.
+	JumpTargetsDecoded JumpTargets = iota
+	// JumpTargetsAbsolute shows the address of the targets.
+	JumpTargetsAbsolute
+	// JumpTargetsRelative shows the signed displacement as encoded, which
+	// is relative to the end of the instruction on x86 and to its start on
+	// the other archs.
+	JumpTargetsRelative
+)
+
+var jumpTargetsNames = [...]string{
+	JumpTargetsDecoded:  "decoded",
+	JumpTargetsAbsolute: "abs",
+	JumpTargetsRelative: "rel",
+}
+
+func (mode JumpTargets) String() string { return jumpTargetsNames[mode] }
+
+// Set implements flag.Value.
+func (mode *JumpTargets) Set(value string) error {
+	for m, name := range jumpTargetsNames {
+		if name == value {
+			*mode = JumpTargets(m)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown jump targets %q, expected decoded, abs or rel", value)
+}
+
+// Next returns the following mode for cycling through them.
+func (mode JumpTargets) Next() JumpTargets {
+	return (mode + 1) % JumpTargets(len(jumpTargetsNames))
+}
+
+// rxJumpOperand matches the target of a jump at the end of the text,
+// either an address or an offset in instructions.
+var rxJumpOperand = regexp.MustCompile(`\s(0x[\da-fA-F]+|-?\d+\(PC\))$`)
+
+// Format rewrites the target operand of the jump within the func, the other
+// instructions are returned as is. The displacement of x86 is relative
+// to the end of the instruction.
+func (mode JumpTargets) Format(ix *disasm.Inst, x86 bool) string {
+	if mode == JumpTargetsDecoded || ix.RefOffset == 0 || ix.Call != "" {
+		return ix.Text
+	}
+	var target string
+	switch mode {
+	case JumpTargetsAbsolute:
+		target = fmt.Sprintf("%#x", ix.RefPC)
+	case JumpTargetsRelative:
+		from := ix.PC
+		if x86 {
+			from += uint64(ix.Size)
+		}
+		target = fmt.Sprintf("%+#x", int64(ix.RefPC-from))
+	}
+	return rxJumpOperand.ReplaceAllString(ix.Text, " "+target)
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gioui.org/io/clipboard"
+	"gioui.org/io/key"
+	"gioui.org/layout"
+)
+
+// fileUIActions are the actions of the main window that can be bound to keys.
+var fileUIActions = map[string]func(ui *FileUI, gtx layout.Context){
+	"reload": func(ui *FileUI, gtx layout.Context) { ui.requestReload() },
+	"copy-name": func(ui *FileUI, gtx layout.Context) {
+		if ui.Funcs.Selected != "" {
+			clipboard.WriteOp{Text: ui.Funcs.Selected}.Add(gtx.Ops)
+		}
+	},
+	"copy-address": func(ui *FileUI, gtx layout.Context) {
+		if ui.Code.DataPC == 0 {
+			ui.Funcs.Status = "no address"
+			return
+		}
+		address := formatAddress(ui.Code.DataPC)
+		clipboard.WriteOp{Text: address}.Add(gtx.Ops)
+		ui.Funcs.Status = "copied " + address
+	},
+	"open-in-new": func(ui *FileUI, gtx layout.Context) {
+		if ui.Code.Loaded() {
+			ui.openInNew(gtx)
+		}
+	},
+	"toggle-vector-lanes":   func(ui *FileUI, gtx layout.Context) { ui.Config.VectorLanes = !ui.Config.VectorLanes },
+	"cycle-addresses":       func(ui *FileUI, gtx layout.Context) { ui.Config.Addresses = ui.Config.Addresses.Next() },
+	"cycle-immediates":      func(ui *FileUI, gtx layout.Context) { ui.Config.Immediates = ui.Config.Immediates.Next() },
+	"cycle-long-lines":      func(ui *FileUI, gtx layout.Context) { ui.Config.LongLines = ui.Config.LongLines.Next() },
+	"cycle-jump-targets":    func(ui *FileUI, gtx layout.Context) { ui.Config.JumpTargets = ui.Config.JumpTargets.Next() },
+	"toggle-opt-hints":      func(ui *FileUI, gtx layout.Context) { ui.Config.OptHints = !ui.Config.OptHints },
+	"toggle-patterns":       func(ui *FileUI, gtx layout.Context) { ui.Config.CollapsePatterns = !ui.Config.CollapsePatterns },
+	"toggle-changes-only":   func(ui *FileUI, gtx layout.Context) { ui.Config.ChangesOnly = !ui.Config.ChangesOnly },
+	"toggle-data-view":      func(ui *FileUI, gtx layout.Context) { ui.Config.DataView = !ui.Config.DataView },
+	"toggle-symbol-list":    func(ui *FileUI, gtx layout.Context) { ui.Config.HideSymbolList = !ui.Config.HideSymbolList },
+	"toggle-compact":        func(ui *FileUI, gtx layout.Context) { ui.Config.Compact = !ui.Config.Compact },
+	"toggle-inst-frequency": func(ui *FileUI, gtx layout.Context) { ui.Config.InstFrequency = !ui.Config.InstFrequency },
+	"toggle-packages":       func(ui *FileUI, gtx layout.Context) { ui.Config.ShowPackages = !ui.Config.ShowPackages },
+	"toggle-listing":        func(ui *FileUI, gtx layout.Context) { ui.Config.Listing = !ui.Config.Listing },
+	"toggle-audit":          func(ui *FileUI, gtx layout.Context) { ui.Config.Audit = !ui.Config.Audit },
+	"cycle-instrumentation": func(ui *FileUI, gtx layout.Context) { ui.Config.Instrumentation = ui.Config.Instrumentation.Next() },
+	"toggle-dependencies":   func(ui *FileUI, gtx layout.Context) { ui.Config.Dependencies = !ui.Config.Dependencies },
+	"toggle-encoding":       func(ui *FileUI, gtx layout.Context) { ui.Config.Encoding = !ui.Config.Encoding },
+	"toggle-inline-stack":   func(ui *FileUI, gtx layout.Context) { ui.Config.InlineStack = !ui.Config.InlineStack },
+	"toggle-jumps":          func(ui *FileUI, gtx layout.Context) { ui.Config.NoJumps = !ui.Config.NoJumps },
+	"toggle-text-overview":  func(ui *FileUI, gtx layout.Context) { ui.Config.TextOverview = !ui.Config.TextOverview },
+	"toggle-coverage":       func(ui *FileUI, gtx layout.Context) { ui.Config.ShowCoverage = !ui.Config.ShowCoverage },
+	"toggle-link-scroll": func(ui *FileUI, gtx layout.Context) {
+		ui.Code.LinkScroll, ui.Code.LinkSource = !ui.Code.LinkScroll, false
+	},
+	"toggle-link-source": func(ui *FileUI, gtx layout.Context) {
+		ui.Code.LinkSource, ui.Code.LinkScroll = !ui.Code.LinkSource, false
+		ui.Code.linkDriver = paneSource
+	},
+	"toggle-selected-only": func(ui *FileUI, gtx layout.Context) {
+		ui.Config.SelectedOnly = !ui.Config.SelectedOnly
+		if ui.Config.SelectedOnly && !ui.Code.Selection.Valid(ui.Code.Code) {
+			ui.Funcs.Status = "no source lines selected"
+		}
+	},
+	"rename": func(ui *FileUI, gtx layout.Context) {
+		if ui.Funcs.Selected != "" {
+			ui.rename.Open(ui.Funcs.Selected, ui.Aliases)
+		}
+	},
+	"toggle-mark": func(ui *FileUI, gtx layout.Context) {
+		pc := ui.Code.HoveredPC
+		if !ui.Code.Loaded() || pc == 0 || instAt(ui.Code.Code, pc) < 0 {
+			ui.Funcs.Status = "hover an instruction to mark it"
+			return
+		}
+		if ui.Marks.Toggle(pc) {
+			ui.Funcs.Status = fmt.Sprintf("marked 0x%x", pc)
+		} else {
+			ui.Funcs.Status = fmt.Sprintf("unmarked 0x%x", pc)
+		}
+	},
+	"toggle-notes": func(ui *FileUI, gtx layout.Context) {
+		ui.Config.Notes = !ui.Config.Notes
+		if !ui.Config.Notes && ui.notesPanel.edited {
+			ui.saveNotes()
+		}
+	},
+	"goto-symbol": func(ui *FileUI, gtx layout.Context) {
+		if ui.File != nil {
+			ui.find.Close()
+			ui.gotoSym.Open()
+		}
+	},
+	"next-return": func(ui *FileUI, gtx layout.Context) {
+		if !ui.Code.Loaded() {
+			return
+		}
+		if at, total := ui.Code.NextReturn(); total > 0 {
+			ui.Funcs.Status = fmt.Sprintf("return %d / %d", at, total)
+		} else {
+			ui.Funcs.Status = "no returns"
+		}
+	},
+	"open-summary": func(ui *FileUI, gtx layout.Context) { ui.openSummary() },
+	"open-tab": func(ui *FileUI, gtx layout.Context) {
+		if ui.Funcs.SelectedItem == nil {
+			ui.Funcs.Status = "no func selected"
+			return
+		}
+		ui.tabs.Open(ui.Funcs.SelectedItem)
+	},
+	"close-tab": func(ui *FileUI, gtx layout.Context) {
+		if !ui.tabs.Close(ui.Funcs.Selected) {
+			ui.Funcs.Status = "the selected func has no tab"
+		}
+	},
+	"find": func(ui *FileUI, gtx layout.Context) {
+		if ui.Code.Loaded() {
+			ui.gotoSym.Close()
+			ui.find.Open()
+		}
+	},
+	"toggle-find-global": func(ui *FileUI, gtx layout.Context) {
+		ui.find.Global.Value = !ui.find.Global.Value
+		if ui.Code.Loaded() && !ui.find.Active() {
+			ui.gotoSym.Close()
+			ui.find.Open()
+		}
+	},
+	"find-next":     func(ui *FileUI, gtx layout.Context) { ui.findNext(false) },
+	"find-previous": func(ui *FileUI, gtx layout.Context) { ui.findNext(true) },
+	"open-metadata": func(ui *FileUI, gtx layout.Context) { ui.openMetadata() },
+	"select-main":   func(ui *FileUI, gtx layout.Context) { ui.selectMain() },
+	"open-editor": func(ui *FileUI, gtx layout.Context) {
+		if ui.Code.Loaded() {
+			if err := OpenInEditor(ui.Config.Editor, ui.Code.File, definitionLine(ui.Code.Code)); err != nil {
+				ui.Funcs.Status = err.Error()
+			}
+		}
+	},
+	"write-dot": func(ui *FileUI, gtx layout.Context) {
+		if ui.Code.Loaded() {
+			path := dotFileName(ui.Code.Name)
+			if err := writeDOTFile(path, ui.Code.Code); err != nil {
+				ui.Funcs.Status = err.Error()
+			} else {
+				ui.Funcs.Status = "wrote " + path
+			}
+		}
+	},
+	"write-pcln": func(ui *FileUI, gtx layout.Context) {
+		if ui.Funcs.SelectedItem != nil {
+			path := pcTablesFileName(ui.Funcs.SelectedItem.Name())
+			if err := writePCTablesFile(path, ui.Funcs.SelectedItem); err != nil {
+				ui.Funcs.Status = err.Error()
+			} else {
+				ui.Funcs.Status = "wrote " + path
+			}
+		}
+	},
+	"cancel-grep": func(ui *FileUI, gtx layout.Context) { ui.cancelGrep() },
+}
+
+// KeyBinding binds a key chord to an action.
+type KeyBinding struct {
+	// Chord is a single key combination in the key.Set syntax, e.g. "Ctrl-R".
+	Chord  string
+	Action string
+}
+
+// WhileTyping returns whether the binding is used while a filter is
+// focused, which is the case for function keys and chords with modifiers.
+func (binding KeyBinding) WhileTyping() bool {
+	name := binding.Chord
+	if sep := strings.LastIndex(name, "-"); sep > 0 {
+		return true
+	}
+	return len(name) > 1 && name[0] == 'F'
+}
+
+// KeyBindings is the list of bindings of a window.
+type KeyBindings []KeyBinding
+
+// DefaultKeyBindings returns the default shortcuts of the main window.
+func DefaultKeyBindings() KeyBindings {
+	return KeyBindings{
+		{"Y", "copy-name"},
+		{"Short-Y", "copy-address"},
+		{"R", "reload"},
+		{key.NameF5, "reload"},
+		{"V", "toggle-vector-lanes"},
+		{"A", "cycle-addresses"},
+		{"I", "cycle-immediates"},
+		{"W", "cycle-long-lines"},
+		{"Short-J", "cycle-jump-targets"},
+		{"O", "toggle-opt-hints"},
+		{"P", "toggle-patterns"},
+		{"C", "toggle-changes-only"},
+		{"Short-L", "toggle-selected-only"},
+		{"L", "toggle-link-scroll"},
+		{"J", "toggle-link-source"},
+		{"G", "toggle-coverage"},
+		{"U", "toggle-audit"},
+		{"Short-U", "cycle-instrumentation"},
+		{"Short-D", "toggle-dependencies"},
+		{"Short-E", "toggle-encoding"},
+		{"Short-K", "toggle-inline-stack"},
+		{"B", "toggle-listing"},
+		{"X", "toggle-jumps"},
+		{"S", "toggle-symbol-list"},
+		{"Q", "toggle-compact"},
+		{"K", "toggle-packages"},
+		{"M", "toggle-data-view"},
+		{"F", "toggle-inst-frequency"},
+		{"T", "toggle-text-overview"},
+		{"N", "rename"},
+		{"Short-G", "goto-symbol"},
+		{"F9", "toggle-mark"},
+		{"Short-N", "toggle-notes"},
+		{"Z", "next-return"},
+		{"H", "open-summary"},
+		{"Short-T", "open-tab"},
+		{"Short-W", "close-tab"},
+		{"Short-F", "find"},
+		{"Short-Shift-F", "toggle-find-global"},
+		{key.NameF3, "find-next"},
+		{"Shift-" + key.NameF3, "find-previous"},
+		{"Short-O", "open-recent"},
+		{"Short-I", "open-metadata"},
+		{"Short-M", "select-main"},
+		{"D", "write-dot"},
+		{"Short-P", "write-pcln"},
+		{"E", "open-editor"},
+		{key.NameEscape, "cancel-grep"},
+	}
+}
+
+// Set returns the key set of all the chords.
+func (bindings KeyBindings) Set() key.Set {
+	chords := make([]string, 0, len(bindings))
+	for _, binding := range bindings {
+		chords = append(chords, binding.Chord)
+	}
+	return key.Set(strings.Join(chords, "|"))
+}
+
+// Find returns the binding for the key event.
+func (bindings KeyBindings) Find(ev key.Event) (KeyBinding, bool) {
+	for _, binding := range bindings {
+		if key.Set(binding.Chord).Contains(ev.Name, ev.Modifiers) {
+			return binding, true
+		}
+	}
+	return KeyBinding{}, false
+}
+
+// keyNames are the friendlier names of the keys that Gio names with symbols.
+var keyNames = map[string]string{
+	"Escape": key.NameEscape,
+	"Esc":    key.NameEscape,
+	"Enter":  key.NameReturn,
+	"Return": key.NameReturn,
+	"Tab":    key.NameTab,
+	"Space":  key.NameSpace,
+	"Up":     key.NameUpArrow,
+	"Down":   key.NameDownArrow,
+	"Left":   key.NameLeftArrow,
+	"Right":  key.NameRightArrow,
+}
+
+// LoadKeyBindings reads the bindings from path and applies them over the
+// bindings. Each line of the file is "action = chord, chord...", where the
+// chords replace the defaults of the action. Unknown actions and chords
+// bound to several actions are reported to warn.
+func LoadKeyBindings(path string, bindings KeyBindings, warn io.Writer) (KeyBindings, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	custom := map[string][]string{}
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		action, chords, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected action = chord", path, lineNumber)
+		}
+		action = strings.TrimSpace(action)
+		if _, ok := fileUIActions[action]; !ok {
+			fmt.Fprintf(warn, "%s:%d: unknown action %q\n", path, lineNumber, action)
+			continue
+		}
+		custom[action] = []string{}
+		for _, chord := range strings.Split(chords, ",") {
+			if chord = strings.TrimSpace(chord); chord != "" {
+				custom[action] = append(custom[action], keyChord(chord))
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var result KeyBindings
+	for _, binding := range bindings {
+		if _, ok := custom[binding.Action]; !ok {
+			result = append(result, binding)
+		}
+	}
+	actions := make([]string, 0, len(custom))
+	for action := range custom {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	// the custom bindings are checked first, so they win over the defaults
+	var prepend KeyBindings
+	for _, action := range actions {
+		for _, chord := range custom[action] {
+			prepend = append(prepend, KeyBinding{Chord: chord, Action: action})
+		}
+	}
+	result = append(prepend, result...)
+
+	bound := map[string]string{}
+	for _, binding := range result {
+		if previous, ok := bound[binding.Chord]; ok && previous != binding.Action {
+			fmt.Fprintf(warn, "%s: %q is bound to %q and %q, using %q\n", path, binding.Chord, previous, binding.Action, previous)
+			continue
+		}
+		bound[binding.Chord] = binding.Action
+	}
+	return result, nil
+}
+
+// keyChord replaces the friendlier key name in chord with the Gio name.
+func keyChord(chord string) string {
+	mods, name := "", chord
+	if sep := strings.LastIndex(chord, "-"); sep > 0 {
+		mods, name = chord[:sep+1], chord[sep+1:]
+	}
+	if gio, ok := keyNames[name]; ok {
+		name = gio
+	}
+	if len(name) == 1 {
+		name = strings.ToUpper(name)
+	}
+	return mods + name
+}
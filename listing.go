@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"gioui.org/gesture"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// listingMaxBytes limits the instruction bytes shown in a listing row.
+const listingMaxBytes = 12
+
+// listingLines formats the code like "objdump -d", with the address,
+// the encoded bytes, when reader is not nil, and the text of each
+// instruction. The first line is the name of the func.
+func listingLines(code *disasm.Code, reader disasm.DataReader, immediates ImmediateBase) []string {
+	var last uint64
+	bytesWidth := 0
+	for _, ix := range code.Insts {
+		if ix.Text != "" {
+			last = ix.PC
+			bytesWidth = max(bytesWidth, min(ix.Size, listingMaxBytes)*3-1)
+		}
+	}
+	addrWidth := len(fmt.Sprintf("%x", last))
+
+	lines := []string{fmt.Sprintf("%0*x <%s>:", addrWidth, code.Insts[0].PC, code.Name)}
+	for _, ix := range code.Insts {
+		if ix.Text == "" {
+			continue
+		}
+		text := immediates.Format(ix.Text)
+		if ix.PCRelative != "" {
+			text += "  // " + strings.TrimSpace(ix.PCRelative+" "+ix.Preview)
+		}
+		if reader == nil {
+			lines = append(lines, fmt.Sprintf("%*x:  %s", addrWidth, ix.PC, text))
+			continue
+		}
+		data := make([]byte, min(ix.Size, listingMaxBytes))
+		encoded := ""
+		if err := reader.ReadData(ix.PC, data); err == nil {
+			encoded = fmt.Sprintf("% x", data)
+			if ix.Size > listingMaxBytes {
+				encoded += "…"
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%*x:  %-*s  %s", addrWidth, ix.PC, bytesWidth, encoded, text))
+	}
+	return lines
+}
+
+// layoutListing draws the instructions as a single column listing
+// without the source, scrolled together with the asm of the default view.
+func (ui CodeUIStyle) layoutListing(gtx layout.Context) layout.Dimensions {
+	lineHeight := gtx.Metric.Sp(ui.LineHeight)
+	pad := lineHeight
+
+	if ui.listing.code != ui.Code || ui.listing.immediates != ui.Immediates {
+		ui.listing.code = ui.Code
+		ui.listing.immediates = ui.Immediates
+		ui.listing.lines = nil
+		if len(ui.Code.Insts) > 0 {
+			ui.listing.lines = listingLines(ui.Code, ui.Bytes, ui.Immediates)
+		}
+	}
+	lines := ui.listing.lines
+
+	first := max(int(-ui.asm.scroll)/lineHeight-1, 0)
+	last := min(int(-ui.asm.scroll+float32(gtx.Constraints.Max.Y))/lineHeight+2, len(lines))
+	for i := first; i < last; i++ {
+		SourceLine{
+			TopLeft:    image.Pt(2*pad, int(ui.asm.scroll)+i*lineHeight),
+			Width:      gtx.Constraints.Max.X - 3*pad,
+			Text:       lines[i],
+			TextHeight: ui.TextHeight,
+			Bold:       i == 0,
+			Color:      palette.Foreground,
+			Plain:      ui.NoLigatures,
+		}.Layout(ui.Theme, gtx)
+	}
+
+	overflow := lineHeight
+	contentTop := float32(-overflow)
+	contentBot := float32(len(lines)*lineHeight + overflow)
+	viewTop := -ui.asm.scroll
+	viewBot := -ui.asm.scroll + float32(gtx.Constraints.Max.Y)
+
+	ui.asm.gesture.Add(gtx.Ops, image.Rect(0, -1000, 0, 1000))
+	{
+		stack := op.Offset(image.Pt(pad/2, 0)).Push(gtx.Ops)
+		gtx := gtx
+		gtx.Constraints = layout.Exact(image.Pt(pad, gtx.Constraints.Max.Y))
+		material.Scrollbar(ui.Theme, &ui.asm.bar).Layout(gtx, layout.Vertical,
+			(viewTop-contentTop)/(contentBot-contentTop),
+			(viewBot-contentTop)/(contentBot-contentTop),
+		)
+		stack.Pop()
+	}
+	if distance := ui.asm.bar.ScrollDistance(); distance != 0 {
+		ui.asm.scroll -= distance * (contentBot - contentTop)
+	}
+	if distance := ui.asm.gesture.Scroll(gtx.Metric, gtx, gtx.Now, gesture.Vertical); distance != 0 {
+		ui.asm.scroll -= float32(distance)
+	}
+
+	if -ui.asm.scroll+float32(gtx.Constraints.Max.Y) > contentBot {
+		ui.asm.scroll = float32(gtx.Constraints.Max.Y) - contentBot
+	}
+	if -ui.asm.scroll < contentTop {
+		ui.asm.scroll = -contentTop
+	}
+
+	return layout.Dimensions{Size: gtx.Constraints.Max}
+}
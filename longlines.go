@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// LongLines defines how asm lines wider than the column are displayed.
+type LongLines int
+
+const (
+	// LongLinesScroll clips the lines and allows scrolling them horizontally.
+	LongLinesScroll LongLines = iota
+	// LongLinesTruncate cuts the lines with an ellipsis.
+	LongLinesTruncate
+	// LongLinesWrap continues the lines on indented rows.
+	LongLinesWrap
+)
+
+var longLinesNames = [...]string{
+	LongLinesScroll:   "scroll",
+	LongLinesTruncate: "truncate",
+	LongLinesWrap:     "wrap",
+}
+
+func (mode LongLines) String() string { return longLinesNames[mode] }
+
+// Set implements flag.Value.
+func (mode *LongLines) Set(value string) error {
+	for m, name := range longLinesNames {
+		if name == value {
+			*mode = LongLines(m)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown long lines mode %q, expected scroll, truncate or wrap", value)
+}
+
+// Next returns the following mode for cycling through them.
+func (mode LongLines) Next() LongLines {
+	return (mode + 1) % LongLines(len(longLinesNames))
+}
+
+// wrapIndent is the prefix of continuation rows.
+const wrapIndent = "    "
+
+// wrapLine splits text into rows of at most width runes, preferring to
+// break after operand separators and spaces.
+func wrapLine(text string, width int) []string {
+	if width <= 2*len(wrapIndent) || utf8.RuneCountInString(text) <= width {
+		return []string{text}
+	}
+
+	var rows []string
+	line := []rune(text)
+	for len(line) > width {
+		cut := width
+		for k := width; k > width/2; k-- {
+			if line[k-1] == ' ' || line[k-1] == ',' {
+				cut = k
+				break
+			}
+		}
+		rows = append(rows, strings.TrimRight(string(line[:cut]), " "))
+		line = append([]rune(wrapIndent), []rune(strings.TrimLeft(string(line[cut:]), " "))...)
+	}
+	return append(rows, string(line))
+}
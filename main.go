@@ -4,35 +4,508 @@ import (
 	"flag"
 	"fmt"
 	"image"
-	"image/color"
 	"log"
 	"os"
+	"os/signal"
+	"regexp"
 	"runtime/pprof"
+	"strings"
+	"syscall"
 
 	"gioui.org/app"
 	"gioui.org/text"
 	"gioui.org/unit"
 	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
 )
 
 func main() {
 	cpuprofile := flag.String("cpuprofile", "", "enable cpu profiling")
 	textSize := flag.Int("text-size", 12, "default font size")
-	filter := flag.String("filter", "", "filter the functions by regexp")
+	scale := flag.Float64("scale", 1, "multiply the scaling of the display, e.g. 1.5 when the system reports the wrong DPI")
+	var filters stringsFlag
+	flag.Var(&filters, "filter", "filter the functions by regexp, can be repeated for separate lists")
+	exclude := flag.String("exclude", "", "drop the functions matching regexp from all the filters")
+	filterExact := flag.Bool("filter-exact", false, "match -filter as the full func name instead of a regexp, e.g. 'main.(*Server).Handle'")
+	filterCaseInsensitive := flag.Bool("filter-case-insensitive", false, "ignore the case with -filter-exact, the regexp filters always ignore it")
+	show := flag.Int("show", 0, "list only the first N matched funcs until show more is clicked (0 lists all)")
+	grepAsm := flag.String("grep-asm", "", "list only funcs with instructions matching regexp")
+	followCalls := flag.Int("follow-calls", 0, "also list the funcs called by the matched funcs up to this depth")
+	followPC := flag.String("follow-pc", "", "highlight and scroll to the addresses read from file, one per line, - is stdin")
+	noJumps := flag.Bool("no-jumps", false, "note the jump targets after the instructions instead of drawing jump lines, also in -html and -theme-preview")
+	listing := flag.Bool("listing", false, "show a single column listing with addresses and bytes like objdump instead of the source")
+	showPackages := flag.Bool("show-packages", false, "show the package of each func under its name in the list")
+	noSymbolList := flag.Bool("no-symbol-list", false, "hide the func list, e.g. when combined with -select")
+	compact := flag.Bool("compact", false, "remove most of the padding between the lines of the lists and the code to fit more of them")
+	selectMain := flag.Bool("main", false, "select main.main, or the func at the entry point without it, after loading regardless of the filter")
+	selectFunc := flag.String("select", "", "open the single func matching regexp in a separate window")
+	single := flag.Bool("single", false, "open only the code of the single func matching -filter, without the func lists")
 	watch := flag.Bool("watch", false, "auto reload executable")
 	context := flag.Int("context", 3, "source line context")
+	contextBefore := flag.Int("context-before", -1, "source line context before (defaults to -context)")
+	contextAfter := flag.Int("context-after", -1, "source line context after (defaults to -context)")
+	mergeLines := flag.Bool("merge-lines", false, "merge asm of a source line separated only by blank lines")
+	maxJumpLanes := flag.Int("max-jump-lanes", 0, "maximum number of lanes for jump lines (0 is unlimited)")
+	offset := flag.Uint64("offset", 0, "disassemble only the instructions from this byte offset of the funcs, e.g. 0x200")
+	length := flag.Uint64("length", 0, "disassemble only this many bytes from -offset of the funcs (0 is until the end)")
+	var preselect Preselect
+	flag.Var(&preselect, "preselect", "the func selected initially: first, largest or hottest by -coverage")
+	var addresses AddressMode
+	flag.Var(&addresses, "addr", "show instruction addresses: none, abs or rel")
+	var immediates ImmediateBase
+	flag.Var(&immediates, "imm", "show immediate operands in base: hex, dec or bin")
+	var jumpTargets JumpTargets
+	flag.Var(&jumpTargets, "jump-targets", "show the targets of the jumps: decoded, abs for the address or rel for the displacement as encoded")
+	var longLines LongLines
+	flag.Var(&longLines, "long-lines", "show asm lines wider than the column: scroll, truncate or wrap")
+	var selectScroll SelectScroll
+	flag.Var(&selectScroll, "select-scroll", "scroll the code of a selected func to: top, first-source for the first source line with instructions, or hottest for the most executed instruction in -coverage")
+	vectorLanes := flag.Bool("simd-lanes", false, "annotate vector registers with their lanes")
+	textOverview := flag.Bool("text-overview", false, "show where the selected func is in the text section")
+	instFrequency := flag.Bool("inst-frequency", false, "show the instruction counts of the selected func in a sidebar")
+	dataView := flag.Bool("data-view", false, "show the data referenced by the hovered instruction")
+	optHints := flag.Bool("opt-hints", false, "note arithmetic source lines where the compiler used a different operation")
+	audit := flag.Bool("audit", false, "mark syscalls, indirect calls and jumps, and memory protection changes")
+	var instrumentation Instrumentation
+	flag.Var(&instrumentation, "instrumentation", "show the instructions added by -race, -asan, -msan and -cover: off, dim or highlight")
+	dependencies := flag.Bool("deps", false, "connect the instructions that write a register with the nearby instructions that read it")
+	encoding := flag.Bool("encoding", false, "note the prefixes, opcode, ModRM, SIB, displacement and immediate of the hovered x86 instruction")
+	inlineStack := flag.Bool("inline-stack", false, "show the inlined calls of the hovered instruction with their source lines")
+	notes := flag.Bool("notes", false, "show the notes of the selected func in a panel, which are stored per executable")
+	coverageFile := flag.String("coverage", "", "tint instructions by a coverage profile or a trace of executed addresses")
+	patternsFile := flag.String("patterns", "", "file with labeled instruction sequences to collapse")
+	marksFile := flag.String("marks", "", "mark the addresses read from file, one per line, e.g. the breakpoints of a debugger")
+	sourceJump := flag.Int("source-jump", 0, "mark asm where source lines jump further than this (0 disables)")
+	blame := flag.Bool("blame", false, "note the commit, author and date of the source lines from git blame, when the sources are in a git repository")
+	noLigatures := flag.Bool("no-ligatures", false, "draw the code without the ligatures of -font, e.g. for != and ->")
 	font := flag.String("font", "", "user font")
+	compare := flag.String("compare", "", "show the funcs with the same name from another executable side by side")
+	changesOnly := flag.Bool("changes-only", false, "show only the instructions that differ from -compare")
+	editor := flag.String("editor", "", "command for editing sources, e.g. 'code -g {file}:{line}' (default $EDITOR)")
+	highContrast := flag.Bool("high-contrast", false, "use high contrast colors, thicker lines and larger rows")
+	htmlReport := flag.String("html", "", "write matched funcs to a HTML report and exit")
+	anonymize := flag.Bool("anonymize", false, "replace link dependent addresses with offsets for reproducible output")
+	symbolsOnly := flag.Bool("filter-symbols-only", false, "print the address, size and name of funcs matched by -filter without disassembling and exit")
+	dryRun := flag.Bool("dry-run", false, "print the funcs matched by -filter and exit")
+	snapshot := flag.String("snapshot", "", "save the single func matched by -filter as snapshot name, or diff it against the snapshot, and exit")
+	dump := flag.Bool("dump", false, "print the source and assembly of the funcs matched by -filter as plain text without opening a window and exit")
+	pcln := flag.Bool("pcln", false, "print the raw pcsp, pcfile and pcline tables of the funcs matched by -filter and exit")
+	dotExport := flag.String("dot", "", "write the control-flow graph of the single matched func in DOT format and exit")
+	callGraph := flag.String("callgraph", "", "write the calls between the funcs matched by -filter to file, as JSON for .json and DOT otherwise, and exit")
+	callGraphExternal := flag.Bool("callgraph-external", false, "include the callees that -filter didn't match in -callgraph")
+	exportTheme := flag.String("export-theme", "", "theme for -html and -theme-preview, independent of the window: default or high-contrast")
+	exportGrayscale := flag.Bool("export-grayscale", false, "use shades of gray for -html and -theme-preview")
+	render := flag.String("render", "", "write the first func matched by -filter as a PNG with the display flags and exit, e.g. for comparing with a golden image")
+	themePreview := flag.String("theme-preview", "", "write the first func matched by -filter with each theme as PNGs into dir and exit")
+	verify := flag.Bool("verify", false, "disassemble all matched funcs, print the ones that didn't decode cleanly and exit, with 1 when any failed")
+	bench := flag.Bool("bench", false, "print load timings of matched funcs to stderr and exit")
+	saveSession := flag.String("save-session", "", "write the session to file when the main window is closed")
+	loadSession := flag.String("load-session", "", "restore a session written by -save-session, exePath defaults to the session's")
+	independentWindows := flag.Bool("independent-windows", false, "keep the other windows open when the main window is closed")
+	keysFile := flag.String("keys", "", "file with key bindings, each line is 'action = chord, ...'")
+	debugFile := flag.String("debug-file", "", "read the symbols and DWARF missing from exePath from a separate debug file")
+	moduleCache := flag.String("module-cache", "", "read the sources missing at their recorded path from the module cache dir, using the module versions of the executable")
+	bearerToken := flag.String("bearer-token", "", "bearer token for downloading exePath url (default $LENSM_BEARER_TOKEN)")
+	listArchs := flag.Bool("list-archs", false, "print the architectures that can be disassembled and exit")
 
 	workInProgressWASM = os.Getenv("LENSM_EXPERIMENT_WASM") != ""
 
 	flag.Parse()
-	exePath := flag.Arg(0)
+	args := flag.Args()
+	if *scale <= 0 {
+		fmt.Fprintln(os.Stderr, "invalid -scale: must be positive")
+		os.Exit(1)
+	}
+	uiScale = float32(*scale)
 
-	if exePath == "" {
-		fmt.Fprintln(os.Stderr, "lensm <exePath>")
+	filterMode := FilterMode{
+		Exact:     *filterExact,
+		MatchCase: *filterExact && !*filterCaseInsensitive,
+	}
+
+	if *listArchs {
+		if err := ListArchs(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	var snapshotName string
+	if *snapshot != "" {
+		if *snapshot != "save" && *snapshot != "diff" || len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "lensm -snapshot save|diff <name> <exePath|url|->")
+			os.Exit(1)
+		}
+		snapshotName, args = args[0], args[1:]
+	}
+	exePath := ""
+	if len(args) > 0 {
+		exePath = args[0]
+	}
+
+	var session *Session
+	if *loadSession != "" {
+		var err error
+		session, err = LoadSession(*loadSession)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if exePath == "" {
+			exePath = session.Path
+		}
+		if len(filters) == 0 {
+			filters = session.Filters
+			filterMode = session.FilterMode
+		}
+	}
+
+	if *filterExact || *filterCaseInsensitive {
+		missing := len(filters) == 0
+		for _, filter := range filters {
+			missing = missing || filter == ""
+		}
+		if missing {
+			fmt.Fprintln(os.Stderr, "lensm -filter-exact|-filter-case-insensitive -filter <name> <exePath|url|->")
+			os.Exit(1)
+		}
+	}
+
+	// Without an executable, the recently opened ones are offered, which
+	// needs a window.
+	headless := *symbolsOnly || *dryRun || *snapshot != "" || *dump || *pcln ||
+		*dotExport != "" || *callGraph != "" || *htmlReport != "" || *render != "" ||
+		*themePreview != "" || *verify || *bench
+	var recent []string
+	if exePath == "" && headless {
+		fmt.Fprintln(os.Stderr, "lensm <exePath|url|->")
 		flag.Usage()
 		os.Exit(1)
 	}
+	if exePath == "" {
+		var err error
+		recent, err = LoadRecent()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "loading recent executables:", err)
+		}
+		if len(recent) == 0 {
+			fmt.Fprintln(os.Stderr, "lensm <exePath|url|->")
+			flag.Usage()
+			os.Exit(1)
+		}
+	}
+
+	var excludeRx *regexp.Regexp
+	if *exclude != "" {
+		var err error
+		excludeRx, err = CompileFilter(*exclude, FilterMode{})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid -exclude:", err)
+			os.Exit(1)
+		}
+	}
+
+	var grepAsmRx *regexp.Regexp
+	if *grepAsm != "" {
+		var err error
+		grepAsmRx, err = regexp.Compile("(?i)" + *grepAsm)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid -grep-asm:", err)
+			os.Exit(1)
+		}
+	}
+
+	removeDownload := func() {}
+	fromStdin := IsStdin(exePath)
+	if fromStdin {
+		if *followPC == "-" {
+			fmt.Fprintln(os.Stderr, "-follow-pc - can't be used when the executable is read from stdin")
+			os.Exit(1)
+		}
+		if *saveSession != "" {
+			fmt.Fprintln(os.Stderr, "-save-session can't be used when the executable is read from stdin")
+			os.Exit(1)
+		}
+		if *watch {
+			fmt.Fprintln(os.Stderr, "-watch is ignored when the executable is read from stdin")
+			*watch = false
+		}
+		path, err := ReadStdinExe()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		exePath = path
+		removeDownload = func() { _ = os.Remove(path) }
+	}
+	// the temporary files aren't remembered as recent
+	temporary := fromStdin || IsURL(exePath)
+	origin := ""
+	if IsURL(exePath) {
+		origin = exePath
+		token := *bearerToken
+		if token == "" {
+			token = os.Getenv("LENSM_BEARER_TOKEN")
+		}
+		path, err := DownloadExe(exePath, token)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		exePath = path
+		removeDownload = func() { _ = os.Remove(path) }
+	}
+	exit := func(code int) {
+		removeDownload()
+		os.Exit(code)
+	}
+
+	var patterns []disasm.Pattern
+	if *patternsFile != "" {
+		var err error
+		patterns, err = loadPatterns(*patternsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -patterns: %v\n", err)
+			exit(1)
+		}
+	}
+
+	var marks []uint64
+	if *marksFile != "" {
+		var err error
+		marks, err = LoadMarks(*marksFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -marks: %v\n", err)
+			exit(1)
+		}
+	}
+
+	var coverage *disasm.CoverageProfile
+	if *coverageFile != "" {
+		var err error
+		coverage, err = loadCoverage(*coverageFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -coverage: %v\n", err)
+			exit(1)
+		}
+	}
+
+	if *highContrast {
+		palette = HighContrastPalette
+	}
+
+	exportPalette, previewPalettes := palette, Palettes
+	if *exportTheme != "" {
+		named, err := PaletteByName(*exportTheme)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -export-theme: %v\n", err)
+			exit(1)
+		}
+		exportPalette, previewPalettes = named.Palette, []NamedPalette{named}
+	}
+	if *exportGrayscale {
+		exportPalette = exportPalette.Grayscale()
+		grays := make([]NamedPalette, len(previewPalettes))
+		for i, named := range previewPalettes {
+			grays[i] = NamedPalette{Name: named.Name + "-grayscale", Palette: named.Palette.Grayscale()}
+		}
+		previewPalettes = grays
+	}
+
+	if *contextBefore < 0 {
+		*contextBefore = *context
+	}
+	if *contextAfter < 0 {
+		*contextAfter = *context
+	}
+
+	config := FileUIConfig{
+		Path:          exePath,
+		Watch:         *watch,
+		Origin:        origin,
+		DebugFile:     *debugFile,
+		ModuleCache:   *moduleCache,
+		ContextBefore: *contextBefore,
+		ContextAfter:  *contextAfter,
+		MergeLines:    *mergeLines,
+		SourceJump:    *sourceJump,
+
+		MaxJumpLanes: *maxJumpLanes,
+		Anonymize:    *anonymize,
+		Offset:       *offset,
+		Length:       *length,
+
+		VectorLanes: *vectorLanes,
+		Addresses:   addresses,
+		Immediates:  immediates,
+		JumpTargets: jumpTargets,
+		LongLines:   longLines,
+		OptHints:    *optHints,
+		DataView:    *dataView,
+
+		SelectScroll: selectScroll,
+
+		InstFrequency: *instFrequency,
+		TextOverview:  *textOverview,
+
+		Audit:           *audit,
+		Instrumentation: instrumentation,
+		Dependencies:    *dependencies,
+		Encoding:        *encoding,
+		InlineStack:     *inlineStack,
+		Notes:           *notes,
+		Blame:           *blame,
+		Coverage:        coverage,
+		ShowCoverage:    coverage != nil,
+
+		Patterns: patterns,
+		Marks:    marks,
+
+		Listing:        *listing,
+		NoJumps:        *noJumps,
+		NoLigatures:    *noLigatures,
+		ShowPackages:   *showPackages,
+		HideSymbolList: *noSymbolList,
+		Compact:        *compact,
+
+		FilterMode: filterMode,
+		Exclude:    excludeRx,
+		Select:     *selectFunc,
+		SelectMain: *selectMain,
+		GrepAsm:    grepAsmRx,
+
+		Show:        *show,
+		Preselect:   preselect,
+		FollowCalls: *followCalls,
+		Editor:      *editor,
+		Compare:     *compare,
+		ChangesOnly: *changesOnly,
+
+		FollowPC:    *followPC,
+		SaveSession: *saveSession,
+	}
+	if session != nil {
+		if err := session.ApplySettings(&config); err != nil {
+			fmt.Fprintln(os.Stderr, "invalid session:", err)
+			exit(1)
+		}
+	}
+
+	if *symbolsOnly {
+		matched, err := ListSymbols(os.Stdout, config, UnionFilter(filters, filterMode))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		if matched == 0 {
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if *dump {
+		if err := DumpFuncs(os.Stdout, config, UnionFilter(filters, filterMode)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if *pcln {
+		if err := DumpPCTables(os.Stdout, config, UnionFilter(filters, filterMode)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if *verify {
+		failed, err := VerifyFuncs(os.Stdout, config, UnionFilter(filters, filterMode))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		if failed > 0 {
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if *bench {
+		if err := RunBenchmark(os.Stderr, config, UnionFilter(filters, filterMode)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	switch *snapshot {
+	case "save":
+		if err := SaveSnapshot(snapshotName, config, UnionFilter(filters, filterMode)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	case "diff":
+		changed, err := DiffSnapshot(os.Stdout, snapshotName, config, UnionFilter(filters, filterMode))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(2)
+		}
+		if changed {
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if *dotExport != "" {
+		if err := ExportDOT(*dotExport, config, UnionFilter(filters, filterMode)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if *callGraph != "" {
+		if err := ExportCallGraph(*callGraph, config, UnionFilter(filters, filterMode), *callGraphExternal); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if *htmlReport != "" {
+		palette = exportPalette
+		if err := ExportHTMLReport(*htmlReport, config, UnionFilter(filters, filterMode)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	// the window uses the file loaded for the preflight
+	var preloaded disasm.File
+	if exePath != "" && (len(filters) > 0 || *dryRun) {
+		file, err := config.Load()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		matched, err := PreflightFilters(os.Stderr, file, config, filters)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		if *dryRun {
+			if matched == 0 {
+				exit(1)
+			}
+			exit(0)
+		}
+		preloaded = file
+	}
 
 	windows := &Windows{}
 
@@ -40,29 +513,127 @@ func main() {
 	theme.Shaper = text.NewShaper(text.WithCollection(LoadFonts(*font)))
 	theme.TextSize = unit.Sp(*textSize)
 
-	ui := NewExeUI(windows, theme)
-	ui.Config = FileUIConfig{
-		Path:    exePath,
-		Watch:   *watch,
-		Context: *context,
+	if *render != "" {
+		if err := WriteRender(*render, config, UnionFilter(filters, filterMode), theme); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if *themePreview != "" {
+		if err := WriteThemePreview(*themePreview, config, UnionFilter(filters, filterMode), theme, previewPalettes); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	keys := DefaultKeyBindings()
+	if *keysFile != "" {
+		var err error
+		keys, err = LoadKeyBindings(*keysFile, keys, os.Stderr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
 	}
-	ui.Funcs.SetFilter(*filter)
 
-	windows.Open("lensm", image.Pt(1400, 900), ui.Run)
+	if *single {
+		if exePath == "" {
+			fmt.Fprintln(os.Stderr, "lensm -single -filter <regexp> <exePath|url|->")
+			exit(1)
+		}
+		ui := NewExeUIWith(windows, theme, config, keys, filters)
+		ui.Preloaded, preloaded = preloaded, nil
+		if err := ui.OpenSingle(UnionFilter(filters, filterMode)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+	}
+
+	openExe := func(path string) {
+		config.Path = path
+		ui := NewExeUIWith(windows, theme, config, keys, filters)
+		ui.Restore = session
+		ui.Preloaded, preloaded = preloaded, nil
+		windows.Open("lensm", image.Pt(1400, 900), func(w *app.Window) error {
+			err := ui.Run(w)
+			if !*independentWindows {
+				windows.CloseAll()
+			}
+			return err
+		})
+	}
+	if *single {
+		// The code window was opened above.
+	} else if exePath == "" {
+		launcher := &RecentUI{
+			Theme: theme,
+			Paths: recent,
+			Open: func(path string) {
+				RememberRecent(path)
+				openExe(path)
+			},
+		}
+		windows.Open("lensm", image.Pt(800, 400), launcher.Run)
+	} else {
+		if !temporary {
+			RememberRecent(exePath)
+		}
+		openExe(exePath)
+	}
+
+	// Close the windows on interrupt, so that they can save their state,
+	// another interrupt or one without windows exits right away.
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupt
+		signal.Stop(interrupt)
+		if !windows.CloseAll() {
+			exit(1)
+		}
+	}()
 
 	go func() {
 		profile(*cpuprofile, windows.Wait)
-		os.Exit(0)
+		exit(0)
 	}()
 
 	// This starts Gio main.
 	app.Main()
 }
 
-var (
-	secondaryBackground = color.NRGBA{R: 0xF0, G: 0xF0, B: 0xF0, A: 0xFF}
-	splitterColor       = color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xFF}
-)
+// stringsFlag collects the values of a repeated flag.
+type stringsFlag []string
+
+func (values *stringsFlag) String() string { return strings.Join(*values, ", ") }
+
+func (values *stringsFlag) Set(value string) error {
+	*values = append(*values, value)
+	return nil
+}
+
+// loadPatterns reads the instruction patterns from path.
+func loadPatterns(path string) ([]disasm.Pattern, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return disasm.ParsePatterns(f)
+}
+
+// loadCoverage reads the coverage profile or trace from path.
+func loadCoverage(path string) (*disasm.CoverageProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return disasm.ParseCoverage(f)
+}
 
 func profile(cpuprofile string, fn func()) {
 	if cpuprofile != "" {
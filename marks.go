@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// Marks are the addresses marked in the gutter of the code, e.g. the
+// breakpoints of a debugger session.
+type Marks struct {
+	pcs map[uint64]bool
+}
+
+// NewMarks returns marks that contain pcs.
+func NewMarks(pcs []uint64) *Marks {
+	marks := &Marks{pcs: map[uint64]bool{}}
+	for _, pc := range pcs {
+		marks.pcs[pc] = true
+	}
+	return marks
+}
+
+// Toggle marks pc or removes its mark and returns whether it's marked.
+func (marks *Marks) Toggle(pc uint64) bool {
+	if marks.pcs[pc] {
+		delete(marks.pcs, pc)
+		return false
+	}
+	marks.pcs[pc] = true
+	return true
+}
+
+// Add marks the pcs.
+func (marks *Marks) Add(pcs []uint64) {
+	for _, pc := range pcs {
+		marks.pcs[pc] = true
+	}
+}
+
+// Contains returns whether a marked address is within the instruction.
+func (marks *Marks) Contains(ix *disasm.Inst) bool {
+	if marks == nil || len(marks.pcs) == 0 || ix.Text == "" {
+		return false
+	}
+	for pc := ix.PC; pc < ix.PC+uint64(max(ix.Size, 1)); pc++ {
+		if marks.pcs[pc] {
+			return true
+		}
+	}
+	return false
+}
+
+// PCs returns the marked addresses in ascending order.
+func (marks *Marks) PCs() []uint64 {
+	if marks == nil || len(marks.pcs) == 0 {
+		return nil
+	}
+	pcs := make([]uint64, 0, len(marks.pcs))
+	for pc := range marks.pcs {
+		pcs = append(pcs, pc)
+	}
+	sort.Slice(pcs, func(i, k int) bool { return pcs[i] < pcs[k] })
+	return pcs
+}
+
+// LoadMarks reads the addresses for -marks, one per line. The addresses
+// are hex with an optional "0x" prefix, the rest of the line after the
+// address is ignored, e.g. the description of a breakpoint, and the lines
+// starting with # are comments.
+func LoadMarks(path string) ([]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var pcs []uint64
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		pc, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(fields[0]), "0x"), 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid address %q", path, lineNumber, fields[0])
+		}
+		pcs = append(pcs, pc)
+	}
+	return pcs, scanner.Err()
+}
@@ -0,0 +1,28 @@
+package main
+
+import "loov.dev/lensm/internal/disasm"
+
+// MemorySlot is a memory location of a func selected by clicking an
+// instruction, which highlights the instructions accessing it.
+type MemorySlot struct {
+	// Func is the name of the func, the slot is ignored for others.
+	Func    string
+	Operand disasm.MemoryOperand
+}
+
+// Valid checks whether the slot is selected in the func.
+func (slot MemorySlot) Valid(code *disasm.Code) bool {
+	return slot.Func != "" && code != nil && slot.Func == code.Name
+}
+
+// SelectMemory selects the first memory operand of the instruction ix in
+// code, or clears the selection when ix already accesses the slot or
+// doesn't access memory.
+func (ui *CodeUI) SelectMemory(code *disasm.Code, ix *disasm.Inst) {
+	operands := disasm.MemoryOperands(ix)
+	if len(operands) == 0 || ui.Memory.Valid(code) && ui.Memory.Operand.Accesses(ix) {
+		ui.Memory = MemorySlot{}
+		return
+	}
+	ui.Memory = MemorySlot{Func: code.Name, Operand: operands[0]}
+}
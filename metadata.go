@@ -0,0 +1,105 @@
+package main
+
+import (
+	"image"
+
+	"gioui.org/app"
+	"gioui.org/font"
+	"gioui.org/io/system"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// fileMetadata returns the build properties of file, when it has them.
+func fileMetadata(file disasm.File) []disasm.Property {
+	if described, ok := file.(disasm.Described); ok {
+		return described.Metadata()
+	}
+	return nil
+}
+
+// MetadataUI shows the build ID, the VCS info and the build settings of
+// the executable, which can be selected for copying.
+type MetadataUI struct {
+	Theme    *material.Theme
+	Path     string
+	Metadata []disasm.Property
+
+	list   widget.List
+	values []widget.Selectable
+}
+
+// Run draws the metadata until the window is closed.
+func (ui *MetadataUI) Run(w *app.Window) error {
+	var ops op.Ops
+
+	ui.list.Axis = layout.Vertical
+	ui.values = make([]widget.Selectable, len(ui.Metadata))
+	for {
+		e := <-w.Events()
+		switch e := e.(type) {
+		case system.FrameEvent:
+			gtx := newContext(&ops, e)
+			ui.Layout(gtx)
+			e.Frame(gtx.Ops)
+
+		case system.DestroyEvent:
+			return e.Err
+		}
+	}
+}
+
+// Layout draws the properties as a two column table.
+func (ui *MetadataUI) Layout(gtx layout.Context) layout.Dimensions {
+	th := ui.Theme
+	return layout.UniformInset(8).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				txt := material.Body1(th, ui.Path)
+				txt.TextSize *= 1.2
+				return layout.Inset{Bottom: 8}.Layout(gtx, txt.Layout)
+			}),
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				if len(ui.Metadata) == 0 {
+					return material.Body1(th, "The executable doesn't contain build information.").Layout(gtx)
+				}
+				keyWidth := gtx.Sp(th.TextSize * 10)
+				return material.List(th, &ui.list).Layout(gtx, len(ui.Metadata), func(gtx layout.Context, index int) layout.Dimensions {
+					prop := ui.Metadata[index]
+					return layout.Inset{Bottom: 4}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{}.Layout(gtx,
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								gtx.Constraints.Min.X, gtx.Constraints.Max.X = keyWidth, keyWidth
+								key := material.Body1(th, prop.Key)
+								key.Font.Weight = font.Bold
+								return key.Layout(gtx)
+							}),
+							layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+								value := material.Body1(th, prop.Value)
+								value.State = &ui.values[index]
+								return value.Layout(gtx)
+							}),
+						)
+					})
+				})
+			}),
+		)
+	})
+}
+
+// openMetadata opens a window with the build information of the executable.
+func (ui *FileUI) openMetadata() {
+	if ui.File == nil {
+		return
+	}
+	metadata := &MetadataUI{
+		Theme:    ui.Theme,
+		Path:     ui.Config.Path,
+		Metadata: fileMetadata(ui.File),
+	}
+	ui.Windows.Open("Build info", image.Pt(900, 600), metadata.Run)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"image"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// noteMark indicates the funcs with notes in the lists.
+const noteMark = "¶"
+
+// notesColumns is the width of the notes panel in characters.
+const notesColumns = 40
+
+// Notes are the free text notes of the user about the symbols, which are
+// stored per executable.
+type Notes struct {
+	texts map[string]string
+}
+
+// NewNotes returns notes that contain texts, which maps the symbol names
+// to their notes.
+func NewNotes(texts map[string]string) *Notes {
+	notes := &Notes{texts: map[string]string{}}
+	for name, text := range texts {
+		notes.Set(name, text)
+	}
+	return notes
+}
+
+// Set replaces the notes of the symbol name, empty text removes them.
+func (notes *Notes) Set(name, text string) {
+	if strings.TrimSpace(text) == "" {
+		delete(notes.texts, name)
+	} else {
+		notes.texts[name] = text
+	}
+}
+
+// Get returns the notes of the symbol name.
+func (notes *Notes) Get(name string) string {
+	if notes == nil {
+		return ""
+	}
+	return notes.texts[name]
+}
+
+// Has returns whether the symbol name has notes.
+func (notes *Notes) Has(name string) bool {
+	return notes.Get(name) != ""
+}
+
+// Texts returns a copy of the notes keyed by the symbol name.
+func (notes *Notes) Texts() map[string]string {
+	if notes == nil || len(notes.texts) == 0 {
+		return nil
+	}
+	texts := make(map[string]string, len(notes.texts))
+	for name, text := range notes.texts {
+		texts[name] = text
+	}
+	return texts
+}
+
+// LoadNotes reads the notes stored for the executable.
+func LoadNotes(exePath string) (*Notes, error) {
+	texts, err := loadExecutableStore("notes.json", exePath)
+	return NewNotes(texts), err
+}
+
+// SaveNotes stores the notes of the executable.
+func SaveNotes(exePath string, notes *Notes) error {
+	return saveExecutableStore("notes.json", exePath, notes.Texts())
+}
+
+// NotesPanel is the editor for the notes of the selected func.
+type NotesPanel struct {
+	// Name is the func whose notes are edited.
+	Name   string
+	Editor widget.Editor
+	// edited is set when the notes changed since they were saved.
+	edited bool
+}
+
+// Layout draws the editor for the notes of the func name, which are
+// updated in notes while typing. It reports whether the notes should be
+// saved, which is after the editor loses the focus or the func changes.
+func (panel *NotesPanel) Layout(th *material.Theme, gtx layout.Context, name string, notes *Notes) (save bool, dims layout.Dimensions) {
+	if panel.Name != name {
+		save = panel.edited
+		panel.Name, panel.edited = name, false
+		panel.Editor.SetText(notes.Get(name))
+	}
+	for _, ev := range panel.Editor.Events() {
+		if _, ok := ev.(widget.ChangeEvent); ok && panel.Editor.Text() != notes.Get(name) {
+			notes.Set(name, panel.Editor.Text())
+			panel.edited = true
+		}
+	}
+	if panel.edited && !panel.Editor.Focused() {
+		save, panel.edited = true, false
+	}
+
+	advance := monospaceAdvance(th, gtx, th.TextSize)
+	size := image.Pt(notesColumns*advance, gtx.Constraints.Max.Y)
+	gtx.Constraints = layout.Exact(size)
+	paint.FillShape(gtx.Ops, palette.SecondaryBackground, clip.Rect{Max: size}.Op())
+
+	layout.UniformInset(4).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				label := material.Body2(th, "Notes for "+name)
+				label.MaxLines = 1
+				return layout.Inset{Bottom: 4}.Layout(gtx, label.Layout)
+			}),
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				return FocusBorder(th, panel.Editor.Focused()).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints.Min = gtx.Constraints.Max
+					return material.Editor(th, &panel.Editor, "What does this func do?").Layout(gtx)
+				})
+			}),
+		)
+	})
+	return save, layout.Dimensions{Size: size}
+}
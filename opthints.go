@@ -0,0 +1,95 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+var (
+	rxSourceLiteral  = regexp.MustCompile("//.*$|\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`|'(?:[^'\\\\]|\\\\.)*'")
+	rxSourceMultiply = regexp.MustCompile(`[\w)\]](?: \* |\*)[\w(]`)
+	rxSourceDivide   = regexp.MustCompile(`[\w)\]](?: / |/)[\w(]`)
+	rxSourceModulo   = regexp.MustCompile(`[\w)\]](?: % |%)[\w(]`)
+	rxSourceAdd      = regexp.MustCompile(`[\w)\]](?: \+ |\+)[\w(]`)
+	rxSourceShift    = regexp.MustCompile(`<<|>>`)
+)
+
+// asmOps describes which kinds of operations the instructions use.
+type asmOps struct {
+	mul, div, shift, lea, add, and bool
+}
+
+func (ops *asmOps) include(ix *disasm.Inst) {
+	name := strings.TrimLeft(mnemonicOf(ix.Text), "V")
+	has := func(prefixes ...string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	ops.mul = ops.mul || has("IMUL", "MUL", "MADD", "MSUB", "SMULL", "UMULL", "SMULH", "UMULH")
+	ops.div = ops.div || has("DIV", "IDIV", "SDIV", "UDIV")
+	ops.shift = ops.shift || has("SHL", "SAL", "SHR", "SAR", "LSL", "LSR", "ASR")
+	ops.lea = ops.lea || has("LEA")
+	ops.add = ops.add || has("ADD", "INC")
+	ops.and = ops.and || has("AND")
+}
+
+// mnemonicOf returns the first word of the instruction text.
+func mnemonicOf(text string) string {
+	name, _, _ := strings.Cut(text, " ")
+	return name
+}
+
+// OptimizationHint describes where the compiler used a different operation
+// than the arithmetic in the source line suggests, e.g. a shift instead of
+// a multiply. The ranges are the instructions compiled from the line.
+// It's a heuristic and returns an empty string when nothing stands out.
+func OptimizationHint(line string, code *disasm.Code, ranges []disasm.LineRange) string {
+	if len(ranges) == 0 {
+		return ""
+	}
+	line = rxSourceLiteral.ReplaceAllString(line, `""`)
+
+	var ops asmOps
+	for _, r := range ranges {
+		for i := r.From; i < r.To; i++ {
+			ops.include(&code.Insts[i])
+		}
+	}
+	sourceShift := rxSourceShift.MatchString(line)
+
+	var hints []string
+	if rxSourceMultiply.MatchString(line) && !ops.mul {
+		switch {
+		case ops.shift && !sourceShift:
+			hints = append(hints, "multiply as shift")
+		case ops.lea:
+			hints = append(hints, "multiply as LEA")
+		}
+	}
+	if rxSourceDivide.MatchString(line) && !ops.div {
+		switch {
+		case ops.mul:
+			hints = append(hints, "divide as multiply by reciprocal")
+		case ops.shift && !sourceShift:
+			hints = append(hints, "divide as shift")
+		}
+	}
+	if rxSourceModulo.MatchString(line) && !ops.div {
+		switch {
+		case ops.mul:
+			hints = append(hints, "modulo as multiply by reciprocal")
+		case ops.and:
+			hints = append(hints, "modulo as mask")
+		}
+	}
+	if rxSourceAdd.MatchString(line) && !ops.add && ops.lea {
+		hints = append(hints, "add as LEA")
+	}
+	return strings.Join(hints, ", ")
+}
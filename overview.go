@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// overviewNameLength limits the names of the neighbors on the buttons.
+const overviewNameLength = 40
+
+// TextOverview is a strip that represents the text section with a marker
+// at the selected func, which shows where the func is in the binary.
+// Clicking the strip or the buttons of the neighbors picks another func.
+type TextOverview struct {
+	Prev widget.Clickable
+	Next widget.Clickable
+}
+
+// Layout draws the overview of the funcs of file in index around selected
+// and returns the func that was picked, if any.
+func (ui *TextOverview) Layout(th *material.Theme, gtx layout.Context, index *pcIndex, file disasm.File, selected disasm.Func) (disasm.Func, layout.Dimensions) {
+	funcs := index.sorted(file)
+	sym, ok := selected.(disasm.Symbol)
+	if !ok || len(funcs) == 0 {
+		return nil, layout.Dimensions{}
+	}
+
+	start := funcs[0].(disasm.Symbol).Addr()
+	last := funcs[len(funcs)-1].(disasm.Symbol)
+	span := float32(last.Addr() + last.Size() - start)
+	if span <= 0 {
+		return nil, layout.Dimensions{}
+	}
+
+	at := sort.Search(len(funcs), func(i int) bool {
+		return funcs[i].(disasm.Symbol).Addr() >= sym.Addr()
+	})
+	var prev, next disasm.Func
+	if at > 0 {
+		prev = funcs[at-1]
+	}
+	if at+1 < len(funcs) {
+		next = funcs[at+1]
+	}
+
+	var picked disasm.Func
+	if ui.Prev.Clicked() && prev != nil {
+		picked = prev
+	}
+	if ui.Next.Clicked() && next != nil {
+		picked = next
+	}
+
+	neighbor := func(click *widget.Clickable, fn disasm.Func, format string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if fn == nil {
+				return layout.Dimensions{}
+			}
+			button := material.Button(th, click, fmt.Sprintf(format, truncateName(fn.Name(), overviewNameLength)))
+			button.TextSize *= 0.8
+			button.Inset = layout.UniformInset(4)
+			button.Background = palette.SecondaryBackground
+			button.Color = palette.Foreground
+			return button.Layout(gtx)
+		})
+	}
+
+	offset := float32(sym.Addr()-start) / span
+	dims := layout.Inset{Left: 4, Right: 4, Bottom: 2}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+			neighbor(&ui.Prev, prev, "◀ %s"),
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				size := image.Pt(gtx.Constraints.Max.X, gtx.Metric.Sp(th.TextSize))
+				return layout.Inset{Left: 4, Right: 4}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					size := image.Pt(gtx.Constraints.Max.X, size.Y)
+					defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
+
+					pointer.InputOp{Tag: ui, Types: pointer.Press}.Add(gtx.Ops)
+					pointer.CursorPointer.Add(gtx.Ops)
+					for _, ev := range gtx.Events(ui) {
+						if ev, ok := ev.(pointer.Event); ok && ev.Type == pointer.Press && size.X > 0 {
+							pc := start + uint64(ev.Position.X/float32(size.X)*span)
+							if fn := index.Lookup(file, pc); fn != nil {
+								picked = fn
+							}
+						}
+					}
+
+					paint.FillShape(gtx.Ops, palette.Gutter, clip.Rect{Max: size}.Op())
+					from := int(offset * float32(size.X))
+					width := max(int(float32(sym.Size())/span*float32(size.X)), gtx.Dp(palette.LineWidth)*2)
+					paint.FillShape(gtx.Ops, palette.Foreground, clip.Rect{
+						Min: image.Pt(from, 0),
+						Max: image.Pt(from+width, size.Y),
+					}.Op())
+					return layout.Dimensions{Size: size}
+				})
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				caption := material.Caption(th, fmt.Sprintf("%.1f%% ", offset*100))
+				caption.Color = palette.Foreground
+				return caption.Layout(gtx)
+			}),
+			neighbor(&ui.Next, next, "%s ▶"),
+		)
+	})
+	return picked, dims
+}
+
+// truncateName shortens name to n runes.
+func truncateName(name string, n int) string {
+	runes := []rune(name)
+	if len(runes) <= n {
+		return name
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// WritePCTables writes the raw pcsp, pcfile and pcline tables of fn, with
+// the program counter range and the value of each entry.
+func WritePCTables(w io.Writer, fn disasm.Func) error {
+	tabled, ok := fn.(disasm.Tabled)
+	if !ok {
+		return fmt.Errorf("%s: pcln tables not supported", fn.Name())
+	}
+	tables, err := tabled.PCTables()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s: entry 0x%x, start line %d, pc quantum %d\n", fn.Name(), tables.Entry, tables.StartLine, tables.Quantum)
+	writeTable := func(name string, off uint32, values []disasm.PCValue, describe func(int32) string) {
+		fmt.Fprintf(bw, "%s at pctab+0x%x:\n", name, off)
+		for _, value := range values {
+			fmt.Fprintf(bw, "  0x%x-0x%x  %d", value.From, value.To, value.Value)
+			if describe != nil {
+				fmt.Fprintf(bw, " %s", describe(value.Value))
+			}
+			fmt.Fprintln(bw)
+		}
+	}
+	writeTable("pcsp", tables.SPOffset, tables.SP, nil)
+	writeTable("pcfile", tables.FileOffset, tables.File, func(index int32) string { return tables.Files[index] })
+	writeTable("pcline", tables.LineOffset, tables.Line, nil)
+	return bw.Flush()
+}
+
+// DumpPCTables writes the pcln tables of the funcs matching filter to w.
+func DumpPCTables(w io.Writer, config FileUIConfig, filter string) error {
+	file, matches, err := loadMatches(config, filter)
+	if err != nil {
+		return fmt.Errorf("-pcln: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	for i, fn := range matches {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if err := WritePCTables(w, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePCTablesFile writes the pcln tables of fn to path.
+func writePCTablesFile(path string, fn disasm.Func) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WritePCTables(out, fn); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// pcTablesFileName returns a file name for the pcln tables of the func.
+func pcTablesFileName(funcname string) string {
+	return rxUnsafeFileName.ReplaceAllString(funcname, "_") + ".pcln.txt"
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// TestGoPlugin builds testdata/go-plugin with -buildmode=plugin and checks
+// that its exported func disassembles with the source of plugin.go.
+func TestGoPlugin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the plugin is slow")
+	}
+	switch runtime.GOOS {
+	case "linux", "darwin", "freebsd":
+	default:
+		t.Skipf("-buildmode=plugin is not supported on %s", runtime.GOOS)
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	dir, err := filepath.Abs(filepath.Join("testdata", "go-plugin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pluginPath := filepath.Join(t.TempDir(), "plugin.so")
+	build := exec.Command(goTool, "build", "-buildmode=plugin", "-o", pluginPath, ".")
+	build.Dir = dir
+	if out, err := build.CombinedOutput(); err != nil {
+		if strings.Contains(string(out), "not supported") {
+			t.Skipf("-buildmode=plugin is not supported: %s", out)
+		}
+		t.Fatalf("building the plugin failed: %v\n%s", err, out)
+	}
+
+	file, err := LoadFile(pluginPath, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	// the func is named by the import path of the plugin package,
+	// e.g. "loov.dev/lensm/testdata/go-plugin.Fibonacci"
+	var fibonacci disasm.Func
+	for _, fn := range file.Funcs() {
+		if strings.HasSuffix(fn.Name(), ".Fibonacci") {
+			if fibonacci != nil {
+				t.Fatalf("both %s and %s are listed", fibonacci.Name(), fn.Name())
+			}
+			fibonacci = fn
+		}
+	}
+	if fibonacci == nil {
+		t.Fatal("Fibonacci is not listed")
+	}
+
+	code := fibonacci.Load(disasm.Options{})
+	if code == nil || len(code.Insts) == 0 {
+		t.Fatalf("%s has no instructions", fibonacci.Name())
+	}
+	if n := code.Unsupported(); n > 0 {
+		t.Errorf("%s has %d bad instructions", fibonacci.Name(), n)
+	}
+
+	source := filepath.Join(dir, "plugin.go")
+	lines := map[int]bool{}
+	for _, src := range code.Source {
+		if src.File != source {
+			continue
+		}
+		for _, block := range src.Blocks {
+			for off, ranges := range block.Related {
+				if len(ranges) > 0 {
+					lines[block.From+off] = true
+				}
+			}
+		}
+	}
+	// the declaration, the condition and the recursive calls
+	for _, line := range []int{3, 4, 7} {
+		if !lines[line] {
+			t.Errorf("plugin.go:%d has no instructions, got lines %v", line, lines)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// preflightNames is the number of matched names printed for each filter.
+const preflightNames = 5
+
+// PreflightFilters writes the number of funcs of file each filter matches
+// and the first few names to w. It returns the total number of matches.
+func PreflightFilters(w io.Writer, file disasm.File, config FileUIConfig, filters []string) (int, error) {
+	if len(filters) == 0 {
+		filters = []string{""}
+	}
+
+	funcs := file.Funcs()
+	total := 0
+	for _, filter := range filters {
+		rx, err := CompileFilter(filter, config.FilterMode)
+		if err != nil {
+			return total, fmt.Errorf("invalid -filter %q: %w", filter, err)
+		}
+		matches := FilterItems(nil, funcs, rx, config.Exclude)
+		total += len(matches)
+
+		names := make([]string, 0, preflightNames)
+		for _, fn := range matches {
+			if len(names) >= preflightNames {
+				break
+			}
+			names = append(names, fn.Name())
+		}
+		if len(matches) > len(names) {
+			names = append(names, "...")
+		}
+
+		fmt.Fprintf(w, "-filter %q matches %d / %d funcs", filter, len(matches), len(funcs))
+		if len(names) > 0 {
+			fmt.Fprintf(w, ": %s", strings.Join(names, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+	return total, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// Preselect defines which of the listed funcs is selected initially.
+type Preselect int
+
+const (
+	// PreselectFirst selects the first func.
+	PreselectFirst Preselect = iota
+	// PreselectLargest selects the func with the most bytes of code.
+	PreselectLargest
+	// PreselectHottest selects the func whose instructions were executed
+	// the most times according to -coverage, or the largest one without it.
+	PreselectHottest
+)
+
+var preselectNames = [...]string{
+	PreselectFirst:   "first",
+	PreselectLargest: "largest",
+	PreselectHottest: "hottest",
+}
+
+func (mode Preselect) String() string { return preselectNames[mode] }
+
+// Set implements flag.Value.
+func (mode *Preselect) Set(value string) error {
+	for m, name := range preselectNames {
+		if name == value {
+			*mode = Preselect(m)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown preselect %q, expected first, largest or hottest", value)
+}
+
+// Pick returns the index of the func to select, the earliest one wins
+// ties. It doesn't load the funcs, so PreselectHottest picks the largest
+// func until HottestFunc has found the hottest one.
+func (mode Preselect) Pick(funcs []disasm.Func) int {
+	if mode == PreselectFirst {
+		return 0
+	}
+
+	best, bestSize := 0, -1
+	for i, fn := range funcs {
+		size := 0
+		if sym, ok := fn.(disasm.Symbol); ok {
+			size = int(sym.Size())
+		}
+		if size > bestSize {
+			best, bestSize = i, size
+		}
+	}
+	return best
+}
+
+// HottestFunc returns the index of the func whose instructions have the
+// most Hits according to opts.Coverage, the earliest one wins ties. It
+// disassembles all the funcs and returns -1 when ctx is cancelled.
+func HottestFunc(ctx context.Context, funcs []disasm.Func, opts disasm.Options) int {
+	best, bestHits := -1, -1
+	for i, fn := range funcs {
+		if ctx.Err() != nil {
+			return -1
+		}
+		hits := 0
+		if code := disasm.Disassemble(fn, opts); code != nil {
+			for _, ix := range code.Insts {
+				hits += ix.Hits
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = i, hits
+		}
+	}
+	return best
+}
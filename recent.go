@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gioui.org/app"
+	"gioui.org/io/system"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// recentLimit is the number of remembered executables.
+const recentLimit = 10
+
+func init() {
+	// The action is added here, because it opens a main window whose
+	// key handling refers back to fileUIActions.
+	fileUIActions["open-recent"] = func(ui *FileUI, gtx layout.Context) { ui.openRecent() }
+}
+
+// recentPath returns the file that lists the recently opened executables.
+func recentPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lensm", "recent.json"), nil
+}
+
+// LoadRecent returns the recently opened executables, the most recent first.
+func LoadRecent() ([]string, error) {
+	path, err := recentPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// AddRecent moves the executable at exePath to the front of the
+// recently opened executables.
+func AddRecent(exePath string) error {
+	abs, err := filepath.Abs(exePath)
+	if err != nil {
+		return err
+	}
+	paths, err := LoadRecent()
+	if err != nil {
+		return err
+	}
+
+	recent := []string{abs}
+	for _, path := range paths {
+		if path != abs && len(recent) < recentLimit {
+			recent = append(recent, path)
+		}
+	}
+
+	path, err := recentPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(recent, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// RememberRecent adds the executable to the recently opened ones and
+// reports the failure without stopping.
+func RememberRecent(exePath string) {
+	if err := AddRecent(exePath); err != nil {
+		fmt.Fprintln(os.Stderr, "remembering recent executable:", err)
+	}
+}
+
+// RecentUI lists the recently opened executables. Picking one calls
+// Open with its path and closes the window.
+type RecentUI struct {
+	Theme *material.Theme
+	Paths []string
+	Open  func(path string)
+
+	list   widget.List
+	clicks []widget.Clickable
+}
+
+// Run draws the list until an executable is picked or the window is closed.
+func (ui *RecentUI) Run(w *app.Window) error {
+	var ops op.Ops
+
+	ui.list.Axis = layout.Vertical
+	ui.clicks = make([]widget.Clickable, len(ui.Paths))
+	picked := false
+	for {
+		e := <-w.Events()
+		switch e := e.(type) {
+		case system.FrameEvent:
+			gtx := newContext(&ops, e)
+			for i := range ui.clicks {
+				if ui.clicks[i].Clicked() && !picked {
+					picked = true
+					ui.Open(ui.Paths[i])
+					w.Perform(system.ActionClose)
+				}
+			}
+			ui.Layout(gtx)
+			e.Frame(gtx.Ops)
+
+		case system.DestroyEvent:
+			return e.Err
+		}
+	}
+}
+
+// Layout draws the recently opened executables.
+func (ui *RecentUI) Layout(gtx layout.Context) layout.Dimensions {
+	th := ui.Theme
+	return layout.UniformInset(8).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		if len(ui.Paths) == 0 {
+			return material.Body1(th, "No recently opened executables, run lensm <exePath|url>").Layout(gtx)
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				txt := material.Body1(th, "Recently opened")
+				txt.TextSize *= 1.2
+				return layout.Inset{Bottom: 8}.Layout(gtx, txt.Layout)
+			}),
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				return material.List(th, &ui.list).Layout(gtx, len(ui.Paths), func(gtx layout.Context, index int) layout.Dimensions {
+					return layout.Inset{Bottom: 4}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						gtx.Constraints.Min.X = gtx.Constraints.Max.X
+						button := material.Button(th, &ui.clicks[index], ui.Paths[index])
+						button.Background = palette.SecondaryBackground
+						button.Color = palette.Foreground
+						return button.Layout(gtx)
+					})
+				})
+			}),
+		)
+	})
+}
+
+// openRecent opens a window for picking a recently opened executable,
+// which is opened in a new main window with the display settings of ui.
+// The settings that refer to the current executable, e.g. -compare, are
+// not carried over.
+func (ui *FileUI) openRecent() {
+	paths, err := LoadRecent()
+	if err != nil {
+		ui.Funcs.Status = err.Error()
+		return
+	}
+
+	config := ui.Config
+	config.Select = ""
+	config.Compare = ""
+	config.ChangesOnly = false
+	config.FollowPC = ""
+	config.SaveSession = ""
+	config.Origin = ""
+	config.DebugFile = ""
+	config.Coverage = nil
+	config.ShowCoverage = false
+	windows, theme, keys := ui.Windows, ui.Theme, ui.Keys
+
+	recent := &RecentUI{
+		Theme: theme,
+		Paths: paths,
+		Open: func(path string) {
+			config.Path = path
+			RememberRecent(path)
+			exe := NewExeUIWith(windows, theme, config, keys, nil)
+			windows.Open("lensm", image.Pt(1400, 900), exe.Run)
+		},
+	}
+	windows.Open("Recent", image.Pt(800, 400), recent.Run)
+}
+
+// NewExeUIWith creates the ui for the executable at config.Path with
+// the aliases stored for it.
+func NewExeUIWith(windows *Windows, theme *material.Theme, config FileUIConfig, keys KeyBindings, filters []string) *FileUI {
+	ui := NewExeUI(windows, theme)
+	ui.Config = config
+	ui.Keys = keys
+	ui.Marks = NewMarks(config.Marks)
+	if aliases, err := LoadAliases(config.Path); err != nil {
+		fmt.Fprintln(os.Stderr, "loading aliases:", err)
+	} else {
+		ui.Aliases = aliases
+	}
+	if notes, err := LoadNotes(config.Path); err != nil {
+		fmt.Fprintln(os.Stderr, "loading notes:", err)
+	} else {
+		ui.Notes = notes
+	}
+	ui.SetFilters(filters)
+	return ui
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"gioui.org/gpu/headless"
+	"gioui.org/io/event"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+	"loov.dev/lensm/internal/f32color"
+)
+
+// renderSize is the size of the images written by -render in pixels.
+var renderSize = image.Pt(1200, 800)
+
+// renderTime is the frame time of the offscreen renders. It's fixed,
+// so that the animations and the time dependent notes, e.g. "(reloaded)",
+// look the same in every render.
+var renderTime = time.Unix(0, 0)
+
+// RenderCode draws the code with the style of config into an image of
+// the specified size in pixels. The result only depends on the arguments,
+// with 1 pixel per dp and the scroll at the top, so that it can be compared
+// to a golden image for testing. The fonts of theme must be fixed as well,
+// e.g. the default Go fonts.
+func RenderCode(theme *material.Theme, code *disasm.Code, config FileUIConfig, size image.Point) (*image.RGBA, error) {
+	window, err := headless.NewWindow(size.X, size.Y)
+	if err != nil {
+		return nil, fmt.Errorf("unable to render offscreen: %w", err)
+	}
+	defer window.Release()
+
+	state := &CodeUI{Code: code}
+	state.ResetScroll()
+	return renderFrame(window, exportStyle(theme, config, state).Layout)
+}
+
+// exportStyle returns the style of config for drawing the code of state
+// outside of a window, e.g. for RenderCode and the theme previews.
+func exportStyle(theme *material.Theme, config FileUIConfig, state *CodeUI) CodeUIStyle {
+	ui := &FileUI{Theme: theme, Config: config, Aliases: NewAliases(nil)}
+	style := ui.codeStyle(state)
+	style.TryOpen = nil
+	return style
+}
+
+// renderFrame draws a single frame of widget on a white background
+// and returns a copy of the pixels.
+func renderFrame(window *headless.Window, widget layout.Widget) (*image.RGBA, error) {
+	size := window.Size()
+	var ops op.Ops
+	gtx := layout.Context{
+		Ops:         &ops,
+		Now:         renderTime,
+		Queue:       noEvents{},
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(size),
+	}
+	paint.FillShape(gtx.Ops, f32color.White, clip.Rect{Max: size}.Op())
+	widget(gtx)
+
+	if err := window.Frame(&ops); err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	if err := window.Screenshot(img); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// WriteRender renders the first func matching filter with RenderCode
+// into a PNG at path.
+func WriteRender(path string, config FileUIConfig, filter string, theme *material.Theme) error {
+	file, matches, err := loadMatches(config, filter)
+	if err != nil {
+		return fmt.Errorf("-render: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	img, err := RenderCode(theme, matches[0].Load(config.LoadOptions()), config, renderSize)
+	if err != nil {
+		return err
+	}
+	return writePNG(path, img)
+}
+
+// noEvents is an event queue for rendering without a window.
+type noEvents struct{}
+
+func (noEvents) Events(event.Tag) []event.Event { return nil }
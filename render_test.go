@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gioui.org/gpu/headless"
+	"gioui.org/text"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+var update = flag.Bool("update", false, "write the renders as the golden images in testdata/render")
+
+// goldenSize is the size of the golden images in pixels.
+var goldenSize = image.Pt(800, 360)
+
+const (
+	// goldenTolerance is the difference of a color channel that is
+	// ignored, which absorbs the differences in antialiasing.
+	goldenTolerance = 8
+	// goldenMaxDiff is the fraction of the pixels that may differ by more
+	// than goldenTolerance.
+	goldenMaxDiff = 0.001
+)
+
+// goldenCode is a fixed func, so that the golden images don't depend on
+// the compiler that built the test. The tabs of the source are expanded
+// like LoadSources does.
+func goldenCode() *disasm.Code {
+	const file = "/src/fib/main.go"
+	inst := func(pc uint64, line int, text string) disasm.Inst {
+		return disasm.Inst{PC: pc, Size: 4, Text: text, File: file, Line: line}
+	}
+	insts := []disasm.Inst{
+		inst(0x1000, 3, "CMPQ 0x10(R14), SP"),
+		inst(0x1004, 3, "JBE 0x1040"),
+		inst(0x1008, 4, "CMPQ AX, $0x2"),
+		inst(0x100c, 4, "JL 0x103c"),
+		inst(0x1010, 7, "MOVQ AX, 0x10(SP)"),
+		inst(0x1014, 7, "LEAQ -0x1(AX), AX"),
+		inst(0x1018, 7, "CALL main.Fibonacci(SB)"),
+		inst(0x101c, 7, "MOVQ AX, 0x8(SP)"),
+		inst(0x1020, 7, "MOVQ 0x10(SP), CX"),
+		inst(0x1024, 7, "LEAQ -0x2(CX), AX"),
+		inst(0x1028, 7, "CALL main.Fibonacci(SB)"),
+		inst(0x102c, 7, "MOVQ 0x8(SP), CX"),
+		inst(0x1030, 7, "ADDQ CX, AX"),
+		inst(0x1034, 7, "RET"),
+		inst(0x103c, 5, "RET"),
+		inst(0x1040, 3, "CALL runtime.morestack_noctxt.abi0(SB)"),
+	}
+	insts[1].RefPC, insts[1].RefOffset, insts[1].RefStack = 0x1040, 14, 1
+	insts[3].RefPC, insts[3].RefOffset, insts[3].RefStack = 0x103c, 11, 2
+	insts[6].RefPC, insts[6].Call = 0x1000, "main.Fibonacci"
+	insts[10].RefPC, insts[10].Call = 0x1000, "main.Fibonacci"
+	insts[15].Call = "runtime.morestack_noctxt.abi0"
+
+	related := func(from, to int) []disasm.LineRange { return []disasm.LineRange{{From: from, To: to}} }
+	return &disasm.Code{
+		Name:    "main.Fibonacci",
+		File:    file,
+		Insts:   insts,
+		MaxJump: 2,
+		Source: []disasm.Source{{
+			File: file,
+			Blocks: []disasm.SourceBlock{{
+				LineRange: disasm.LineRange{From: 2, To: 9},
+				Lines: []string{
+					"",
+					"func Fibonacci(n int) int {",
+					"    if n < 2 {",
+					"        return n",
+					"    }",
+					"    return Fibonacci(n-1) + Fibonacci(n-2)",
+					"}",
+				},
+				Related: [][]disasm.LineRange{
+					nil,
+					append(related(0, 2), related(15, 16)...),
+					related(2, 4),
+					related(14, 15),
+					nil,
+					related(4, 14),
+					nil,
+				},
+			}},
+		}},
+	}
+}
+
+// TestRenderGolden compares the renders of goldenCode with the golden
+// images in testdata/render, which are written with -update. The renders
+// depend on the GPU driver and the fonts, so the images aren't committed
+// and the test only runs with -update or LENSM_RENDER_GOLDEN set, e.g.
+// before and after changing the drawing on the same machine.
+func TestRenderGolden(t *testing.T) {
+	if !*update && os.Getenv("LENSM_RENDER_GOLDEN") == "" {
+		t.Skip("set LENSM_RENDER_GOLDEN to compare with the images written by -update")
+	}
+	window, err := headless.NewWindow(1, 1)
+	if err != nil {
+		t.Skipf("unable to render offscreen: %v", err)
+	}
+	window.Release()
+
+	theme := material.NewTheme()
+	theme.Shaper = text.NewShaper(text.WithCollection(LoadFonts("")))
+
+	tests := []struct {
+		name   string
+		config FileUIConfig
+	}{
+		{"fib", FileUIConfig{}},
+		{"fib-no-jumps", FileUIConfig{NoJumps: true}},
+		{"fib-addresses", FileUIConfig{Addresses: AddressAbsolute}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img, err := RenderCode(theme, goldenCode(), test.config, goldenSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "render", test.name+".png")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := writePNG(golden, img); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := readPNG(golden)
+			if err != nil {
+				t.Fatalf("%v, run with -update to write it", err)
+			}
+			if want.Rect != img.Rect {
+				t.Fatalf("render is %v, %s is %v", img.Rect, golden, want.Rect)
+			}
+			if diff := differentPixels(want, img); diff > goldenMaxDiff {
+				// the temporary dirs of the test are removed after it
+				failed := filepath.Join(os.TempDir(), "lensm-"+test.name+".png")
+				if err := writePNG(failed, img); err != nil {
+					t.Fatal(err)
+				}
+				t.Errorf("%.2f%% of the pixels differ from %s, wrote the render to %s", diff*100, golden, failed)
+			}
+		})
+	}
+}
+
+// differentPixels returns the fraction of the pixels of a and b of the
+// same size that differ by more than goldenTolerance in any channel.
+func differentPixels(a, b *image.RGBA) float64 {
+	differ := 0
+	for i := 0; i < len(a.Pix); i += 4 {
+		for c := i; c < i+4; c++ {
+			d := int(a.Pix[c]) - int(b.Pix[c])
+			if d > goldenTolerance || d < -goldenTolerance {
+				differ++
+				break
+			}
+		}
+	}
+	return float64(differ) / float64(len(a.Pix)/4)
+}
+
+// readPNG reads the image at path as RGBA.
+func readPNG(path string) (*image.RGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Rect, img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
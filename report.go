@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/image/font/gofont/gomono"
+
+	"loov.dev/lensm/internal/disasm"
+	"loov.dev/lensm/internal/f32color"
+)
+
+const (
+	// reportLineHeight is the line height in pixels in the HTML report.
+	reportLineHeight = 16
+	// reportJumpStep is the distance between jump lines in the HTML report.
+	reportJumpStep = reportLineHeight / 2
+)
+
+// ExportHTMLReport loads the funcs that match filter from the executable
+// and writes them as a single HTML file to path.
+func ExportHTMLReport(path string, config FileUIConfig, filter string) error {
+	file, matches, err := loadMatches(config, filter)
+	if err != nil {
+		return fmt.Errorf("-html: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var codes []*disasm.Code
+	for _, fn := range matches {
+		codes = append(codes, fn.Load(config.LoadOptions()))
+	}
+
+	w, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	out := &HTMLOutput{
+		Title:    config.Path,
+		Metadata: fileMetadata(file),
+		Matches:  codes,
+		Config:   config,
+	}
+	if err := ExportHTML(out, nil, w); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// HTMLOutput is the executable and its funcs matched for an HTML export.
+type HTMLOutput struct {
+	// Title is the title of the page, e.g. the path of the executable.
+	Title string
+	// Metadata is the build info of the executable.
+	Metadata []disasm.Property
+	// Matches are the loaded funcs.
+	Matches []*disasm.Code
+	// Config defines how the funcs are shown, e.g. Config.NoJumps.
+	Config FileUIConfig
+}
+
+// ExportHTML writes match as a self-contained HTML page to w, with the
+// source on the left and the assembly on the right. When match is nil,
+// the page contains a section for each of the matches of out with links
+// to them at the top.
+func ExportHTML(out *HTMLOutput, match *disasm.Code, w io.Writer) error {
+	matches := out.Matches
+	if match != nil {
+		matches = []*disasm.Code{match}
+	}
+	report := htmlReport{
+		Title:    out.Title,
+		Style:    reportStyle(),
+		Metadata: out.Metadata,
+		Index:    len(matches) > 1,
+	}
+	for i, code := range matches {
+		report.Codes = append(report.Codes, newHTMLCode(fmt.Sprintf("sym-%d", i), code, out.Config))
+	}
+	return reportTemplate.Execute(w, report)
+}
+
+type htmlReport struct {
+	Title    string
+	Style    template.CSS
+	Metadata []disasm.Property
+	// Index links to the sections of the codes.
+	Index bool
+	Codes []htmlCode
+}
+
+type htmlCode struct {
+	ID   string
+	Code *disasm.Code
+	// Requires describes the CPU features that the code needs.
+	Requires string
+
+	JumpWidth int
+	Height    int
+	Jumps     template.HTML
+	// Notes contains the jump targets of each instruction, when the jump
+	// lines aren't drawn.
+	Notes []string
+
+	// Related contains the instruction ranges of each source line by file,
+	// e.g. "3-7 12-14", which are highlighted when the line is clicked.
+	Related map[string]map[int]string
+}
+
+func newHTMLCode(id string, code *disasm.Code, config FileUIConfig) htmlCode {
+	view := htmlCode{
+		ID:        id,
+		Code:      code,
+		JumpWidth: reportJumpStep * (code.MaxJump + 1),
+		Height:    reportLineHeight * len(code.Insts),
+		Related:   map[string]map[int]string{},
+	}
+	view.Requires = disasm.DescribeFeatures(code.Features())
+
+	for _, src := range code.Source {
+		related := map[int]string{}
+		for _, block := range src.Blocks {
+			for off, ranges := range block.Related {
+				for _, r := range ranges {
+					line := block.From + off
+					related[line] = strings.TrimSpace(fmt.Sprintf("%s %d-%d", related[line], r.From, r.To))
+				}
+			}
+		}
+		view.Related[src.File] = related
+	}
+
+	if config.NoJumps {
+		view.JumpWidth = 0
+		view.Notes = make([]string, len(code.Insts))
+		for i := range code.Insts {
+			view.Notes[i] = jumpNote(code, i)
+		}
+		return view
+	}
+
+	var svg strings.Builder
+	right := float32(view.JumpWidth)
+	const lh = float32(reportLineHeight)
+	const step = float32(reportJumpStep)
+	for i, ix := range code.Insts {
+		if ix.RefOverflow {
+			continue
+		}
+		top := float32(i) * lh
+		x := right - step*float32(ix.RefStack)
+		jumpColor := palette.JumpColor(ix.PC, 0.7)
+		for _, off := range ix.Targets() {
+			target := top + lh/3 + float32(off)*lh
+			fmt.Fprintf(&svg, `<path d="M%.1f %.1f H%.1f V%.1f H%.1f v%.1f l%.1f %.1f l%.1f %.1f v%.1f" stroke="%s"/>`,
+				right, top+lh*2/3, x, target, right-step/2,
+				lh/4, lh/3, -lh/4, -lh/3, -lh/4, lh/4,
+				cssColor(jumpColor))
+		}
+	}
+	view.Jumps = template.HTML(svg.String())
+
+	return view
+}
+
+// cssColor formats the color for use in CSS.
+func cssColor(c color.NRGBA) string {
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3f)", c.R, c.G, c.B, float32(c.A)/0xFF)
+}
+
+func reportStyle() template.CSS {
+	font := base64.StdEncoding.EncodeToString(gomono.TTF)
+	return template.CSS(fmt.Sprintf(`
+@font-face { font-family: "Go Mono"; src: url(data:font/ttf;base64,%[1]s); }
+body { margin: 0; font-family: "Go Mono", monospace; font-size: 12px; display: flex; }
+nav { position: sticky; top: 0; height: 100vh; overflow-y: auto; width: 20em; flex-shrink: 0; background: %[2]s; border-right: 1px solid %[3]s; }
+nav a { display: block; padding: 1px 4px; color: inherit; text-decoration: none; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; }
+nav a:hover { background: %[4]s; }
+main { flex-grow: 1; overflow-x: auto; }
+section { border-bottom: 1px solid %[3]s; padding: 4px; }
+h2 { font-size: 14px; margin: 4px 0; }
+.metadata td { padding: 0 8px 0 0; vertical-align: top; word-break: break-all; }
+.metadata td:first-child { font-weight: bold; white-space: nowrap; }
+.file { font-style: italic; margin-bottom: 8px; }
+.code { display: flex; gap: %[5]dpx; }
+.asm { position: relative; flex-shrink: 0; }
+.asm svg { position: absolute; left: 0; top: 0; fill: none; }
+.line { height: %[5]dpx; line-height: %[5]dpx; white-space: pre; }
+.call { font-style: italic; }
+.note { font-style: italic; color: %[3]s; }
+.bad { font-style: italic; color: %[7]s; }
+.source { border-left: %[5]dpx solid %[6]s; padding-left: %[5]dpx; }
+.srcfile { margin-top: %[5]dpx; font-weight: bold; }
+.block { margin-bottom: %[5]dpx; }
+.mapped { background: %[4]s; cursor: pointer; }
+.selected { background: %[8]s; }
+`, font,
+		cssColor(palette.SecondaryBackground),
+		cssColor(palette.Splitter),
+		cssColor(f32color.HSLA(0.6, 0.9, 0.8, 0.4)),
+		reportLineHeight,
+		cssColor(palette.Gutter),
+		cssColor(palette.Bad),
+		cssColor(palette.Highlight),
+	))
+}
+
+var reportTemplate = template.Must(template.New("").Funcs(template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>{{.Style}}</style>
+</head>
+<body>
+{{if .Index}}<nav>
+{{with .Metadata}}<a href="#metadata">Build info</a>
+{{end}}{{range .Codes}}<a href="#{{.ID}}">{{.Code.Name}}</a>
+{{end}}</nav>
+{{end}}
+<main>
+{{with .Metadata}}<section id="metadata">
+<h2>Build info</h2>
+<table class="metadata">
+{{range .}}<tr><td>{{.Key}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{range .Codes}}{{$id := .ID}}<section id="{{.ID}}">
+<h2>{{.Code.Name}}</h2>
+<div class="file">file: {{.Code.File}}{{with .Requires}} {{.}}{{end}}{{with .Code.Unsupported}} <span class="bad">⚠ {{.}} unsupported, shown as raw bytes</span>{{end}}</div>
+<div class="code">
+<div class="asm" style="padding-left: {{.JumpWidth}}px">
+<svg width="{{.JumpWidth}}" height="{{.Height}}">{{.Jumps}}</svg>
+{{$notes := .Notes}}{{range $i, $ix := .Code.Insts}}<div class="line{{if .Call}} call{{end}}{{if .Bad}} bad{{end}}" id="{{$id}}-{{$i}}">{{.Text}}{{if $notes}}{{with index $notes $i}} <span class="note">{{.}}</span>{{end}}{{end}}</div>
+{{end}}</div>
+<div class="source">
+{{$related := .Related}}{{range .Code.Source}}{{$lines := index $related .File}}<div class="srcfile">{{.File}}</div>
+{{range .Blocks}}<div class="block">
+{{$from := .From}}{{range $off, $line := .Lines}}{{$insts := index $lines (add $from $off)}}<div class="line{{if $insts}} mapped{{end}}"{{with $insts}} data-insts="{{.}}"{{end}}>{{printf "%-4d" (add $from $off)}} {{$line}}</div>
+{{end}}</div>
+{{end}}{{end}}</div>
+</div>
+</section>
+{{end}}</main>
+<script>
+// clicking a source line highlights the instructions compiled from it
+document.addEventListener("click", function(ev) {
+	var line = ev.target.closest(".source .line[data-insts]");
+	if (!line) return;
+	var section = line.closest("section");
+	var selected = line.classList.contains("selected");
+	section.querySelectorAll(".selected").forEach(function(el) { el.classList.remove("selected"); });
+	if (selected) return;
+	line.classList.add("selected");
+	var first = null;
+	line.dataset.insts.split(" ").forEach(function(r) {
+		var bounds = r.split("-");
+		for (var i = Number(bounds[0]); i < Number(bounds[1]); i++) {
+			var ix = document.getElementById(section.id + "-" + i);
+			if (ix) {
+				ix.classList.add("selected");
+				first = first || ix;
+			}
+		}
+	});
+	if (first) first.scrollIntoView({block: "nearest"});
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"gioui.org/io/system"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+// uiScale multiplies the scaling that the system reports for the display,
+// set with -scale for displays that report the wrong DPI.
+var uiScale float32 = 1
+
+// newContext is layout.NewContext with the metric multiplied by uiScale.
+func newContext(ops *op.Ops, e system.FrameEvent) layout.Context {
+	gtx := layout.NewContext(ops, e)
+	gtx.Metric.PxPerDp *= uiScale
+	gtx.Metric.PxPerSp *= uiScale
+	return gtx
+}
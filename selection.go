@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// SourceSelection is a range of source lines of a func, selected by
+// clicking the lines in the source pane.
+type SourceSelection struct {
+	// Func is the name of the func, the selection is ignored for others.
+	Func string
+	File string
+	// From and To are the first and last selected lines.
+	From, To int
+}
+
+// Valid checks whether the selection is made in the func.
+func (sel SourceSelection) Valid(code *disasm.Code) bool {
+	return sel.File != "" && code != nil && sel.Func == code.Name
+}
+
+// Contains checks whether the line of file is selected.
+func (sel SourceSelection) Contains(file string, line int) bool {
+	return sel.File == file && sel.From <= line && line <= sel.To
+}
+
+// String describes the selection, e.g. "main.go:12-15".
+func (sel SourceSelection) String() string {
+	if sel.From == sel.To {
+		return fmt.Sprintf("%s:%d", filepath.Base(sel.File), sel.From)
+	}
+	return fmt.Sprintf("%s:%d-%d", filepath.Base(sel.File), sel.From, sel.To)
+}
+
+// SelectLine selects the line of file in code. With extend the selection
+// grows to include the line, otherwise clicking the only selected line
+// clears the selection. Without folded, i.e. before SelectedOnly is
+// toggled, the selection is kept only until the next plain click.
+func (ui *CodeUI) SelectLine(code *disasm.Code, file string, line int, extend, folded bool) {
+	sel := &ui.Selection
+	switch {
+	case extend && sel.Valid(code) && sel.File == file:
+		sel.From, sel.To = min(sel.From, line), max(sel.To, line)
+	case !extend && !folded && sel.Valid(code):
+		*sel = SourceSelection{}
+	case sel.Valid(code) && sel.File == file && sel.From == line && sel.To == line:
+		*sel = SourceSelection{}
+	default:
+		*sel = SourceSelection{Func: code.Name, File: file, From: line, To: line}
+	}
+}
+
+// selectedCode returns source with the instructions outside of the
+// selected lines folded.
+func (ui CodeUIStyle) selectedCode(source *disasm.Code) *disasm.Code {
+	selectedOnly := &ui.CodeUI.selectedOnly
+	if selectedOnly.source != source || selectedOnly.selection != ui.Selection || selectedOnly.code == nil {
+		selectedOnly.source = source
+		selectedOnly.selection = ui.Selection
+		selectedOnly.code = source.OnlyLines(ui.Selection.File, ui.Selection.From, ui.Selection.To)
+	}
+	return selectedOnly.code
+}
@@ -38,12 +38,25 @@ type SelectList struct {
 
 	ItemHeight unit.Dp
 
-	focused bool
+	focused      bool
+	requestFocus bool
+	submitted    bool
 }
 
 // Focused returns true when the list is in focus.
 func (list *SelectList) Focused() bool { return list.focused }
 
+// Focus requests the keyboard focus for the list.
+func (list *SelectList) Focus() { list.requestFocus = true }
+
+// Submitted returns whether Enter was pressed within the list since the
+// last call.
+func (list *SelectList) Submitted() bool {
+	submitted := list.submitted
+	list.submitted = false
+	return submitted
+}
+
 // Layout draws the list.
 func (list *SelectList) Layout(th *material.Theme, gtx layout.Context, length int, element layout.ListElement) layout.Dimensions {
 	return FocusBorder(th, list.focused).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
@@ -55,8 +68,13 @@ func (list *SelectList) Layout(th *material.Theme, gtx layout.Context, length in
 			Tag: list,
 			Keys: key.NameUpArrow + "|" + key.NameDownArrow + "|" +
 				key.NameHome + "|" + key.NameEnd + "|" +
-				key.NamePageUp + "|" + key.NamePageDown,
+				key.NamePageUp + "|" + key.NamePageDown + "|" +
+				key.NameReturn + "|" + key.NameEnter,
 		}.Add(gtx.Ops)
+		if list.requestFocus {
+			key.FocusOp{Tag: list}.Add(gtx.Ops)
+			list.requestFocus = false
+		}
 
 		pointer.InputOp{
 			Tag:          list,
@@ -93,6 +111,8 @@ func (list *SelectList) Layout(th *material.Theme, gtx layout.Context, length in
 						offset = -list.List.Position.Count
 					case key.NamePageDown:
 						offset = list.List.Position.Count
+					case key.NameReturn, key.NameEnter:
+						list.submitted = true
 					}
 
 					if offset != 0 {
@@ -213,3 +233,30 @@ func StringListItem(th *material.Theme, state *SelectList, item func(int) string
 		})
 	}
 }
+
+// SubtitledListItem creates an item drawer like StringListItem, which
+// draws a smaller subtitle under the title, e.g. the package of a func.
+func SubtitledListItem(th *material.Theme, state *SelectList, item func(int) (title, subtitle string)) layout.ListElement {
+	return func(gtx layout.Context, index int) layout.Dimensions {
+		title, subtitle := item(index)
+		titleItem := StringListItem(th, state, func(int) string { return title })
+		dims := titleItem(gtx, index)
+
+		fg := th.Fg
+		if state.Selected == index && state.Focused() {
+			fg = th.ContrastFg
+		}
+		fg.A = 0xA0
+		inset := layout.Inset{Top: unit.Dp(th.TextSize * 9 / 10), Right: 4, Left: 12}
+		inset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			label := material.Body1(th, subtitle)
+			label.Color = fg
+			label.MaxLines = 1
+			label.TextSize = th.TextSize * 7 / 10
+			label.Font.Style = font.Italic
+			gtx.Constraints.Max.X = maxLineWidth
+			return label.Layout(gtx)
+		})
+		return dims
+	}
+}
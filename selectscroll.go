@@ -0,0 +1,124 @@
+package main
+
+import "fmt"
+
+// SelectScroll defines where the panes are scrolled to when a func is
+// selected.
+type SelectScroll int
+
+const (
+	// SelectScrollTop shows the start of the asm and the source.
+	SelectScrollTop SelectScroll = iota
+	// SelectScrollSource shows the first source line that has
+	// instructions at the top, aligned with its first instruction.
+	SelectScrollSource
+	// SelectScrollHottest centers the instruction with the most hits in
+	// the coverage profile, aligned with its source line. It shows the top
+	// without a profile.
+	SelectScrollHottest
+)
+
+var selectScrollNames = [...]string{
+	SelectScrollTop:     "top",
+	SelectScrollSource:  "first-source",
+	SelectScrollHottest: "hottest",
+}
+
+func (mode SelectScroll) String() string { return selectScrollNames[mode] }
+
+// Set implements flag.Value.
+func (mode *SelectScroll) Set(value string) error {
+	for m, name := range selectScrollNames {
+		if name == value {
+			*mode = SelectScroll(m)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown select scroll %q, expected top, first-source or hottest", value)
+}
+
+// sourceAnchors returns the source lines that have instructions with
+// their top in the content of the source pane.
+func (ui *CodeUI) sourceAnchors(lineHeight int) []sourceAnchor {
+	var anchors []sourceAnchor
+	top := 0
+	for i, src := range ui.Sources() {
+		if i > 0 {
+			top += lineHeight
+		}
+		top += lineHeight
+		for i, block := range src.Blocks {
+			if i > 0 {
+				top += lineHeight
+			}
+			for off, ranges := range block.Related {
+				if len(ranges) > 0 {
+					anchors = append(anchors, sourceAnchor{top: top, ranges: ranges, relation: (i + 1) * (off + 1)})
+				}
+				top += lineHeight
+			}
+		}
+	}
+	return anchors
+}
+
+// selectionTop returns the top of the first selected source line in the
+// content of the source pane, when it's shown.
+func (ui *CodeUI) selectionTop(lineHeight int) (int, bool) {
+	if !ui.Selection.Valid(ui.Code) {
+		return 0, false
+	}
+	top := 0
+	for i, src := range ui.Sources() {
+		if i > 0 {
+			top += lineHeight
+		}
+		top += lineHeight
+		for i, block := range src.Blocks {
+			if i > 0 {
+				top += lineHeight
+			}
+			for off := range block.Lines {
+				if ui.Selection.Contains(src.File, block.From+off) {
+					return top, true
+				}
+				top += lineHeight
+			}
+		}
+	}
+	return 0, false
+}
+
+// positionSelection scrolls the panes of the newly selected code by mode,
+// the position is clamped to the content afterwards.
+func (ui *CodeUI) positionSelection(mode SelectScroll, rows *asmRows, lineHeight, height int) {
+	// the top of the panes includes the overflow above the first line
+	ui.asm.anim.Stop()
+	ui.asm.scroll, ui.src.scroll = float32(lineHeight), float32(lineHeight)
+
+	switch mode {
+	case SelectScrollSource:
+		anchors := ui.sourceAnchors(lineHeight)
+		if len(anchors) == 0 {
+			return
+		}
+		anchor := anchors[0]
+		ui.src.scroll = float32(lineHeight - anchor.top)
+		ui.asm.scroll = ui.src.scroll + float32(anchor.top-rows.Top(anchor.ranges[0].From)*lineHeight)
+
+	case SelectScrollHottest:
+		hottest := ui.Code.Hottest()
+		if hottest < 0 {
+			return
+		}
+		ui.asm.scroll = float32(height/2 - rows.Top(hottest)*lineHeight)
+		for _, anchor := range ui.sourceAnchors(lineHeight) {
+			for _, r := range anchor.ranges {
+				if r.From <= hottest && hottest < r.To {
+					ui.src.scroll = ui.asm.scroll + float32(rows.Top(hottest)*lineHeight-anchor.top)
+					return
+				}
+			}
+		}
+	}
+}
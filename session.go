@@ -0,0 +1,230 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// Session is the state of the main window that can be written to a
+// file and restored later, e.g. on another computer.
+type Session struct {
+	// Path and SHA256 identify the executable.
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+
+	// Filters contains the filter of each func list.
+	Filters []string `json:"filters"`
+	// FilterMode defines how the filters match.
+	FilterMode FilterMode `json:"filterMode"`
+	// Selected contains the selected func of each func list.
+	Selected []string `json:"selected"`
+	// Active is the index of the focused func list.
+	Active int `json:"active"`
+	// Tabs contains the funcs of the tab strip.
+	Tabs []string `json:"tabs,omitempty"`
+
+	// PinnedFile is the file the source pane is pinned to.
+	PinnedFile string `json:"pinnedFile,omitempty"`
+	// AsmScroll and SourceScroll are the scroll positions of the code.
+	AsmScroll    float32 `json:"asmScroll"`
+	SourceScroll float32 `json:"sourceScroll"`
+
+	HighContrast bool   `json:"highContrast"`
+	Addresses    string `json:"addresses"`
+	Immediates   string `json:"immediates"`
+	LongLines    string `json:"longLines"`
+	JumpTargets  string `json:"jumpTargets,omitempty"`
+	VectorLanes  bool   `json:"vectorLanes"`
+	OptHints     bool   `json:"optHints"`
+
+	// Aliases maps the func names to the names assigned by the user.
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// Marks are the marked addresses.
+	Marks []uint64 `json:"marks,omitempty"`
+	// Notes maps the func names to the notes of the user.
+	Notes map[string]string `json:"notes,omitempty"`
+}
+
+// LoadSession reads a session written by SaveSession.
+func LoadSession(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("invalid session %q: %w", path, err)
+	}
+	return &session, nil
+}
+
+// SaveSession writes the session to path.
+func SaveSession(path string, session *Session) error {
+	data, err := json.MarshalIndent(session, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// hashFile returns the hex encoded SHA-256 of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Session captures the current state of the ui.
+func (ui *FileUI) Session() (*Session, error) {
+	hash, err := hashFile(ui.Config.Path)
+	if err != nil {
+		return nil, err
+	}
+	// the downloaded executable is a temporary file
+	path := ui.Config.Path
+	if ui.Config.Origin != "" {
+		path = ui.Config.Origin
+	}
+	session := &Session{
+		Path:   path,
+		SHA256: hash,
+
+		FilterMode: ui.Config.FilterMode,
+
+		PinnedFile:   ui.Code.PinnedFile,
+		AsmScroll:    ui.Code.asm.scroll,
+		SourceScroll: ui.Code.src.scroll,
+
+		HighContrast: palette == HighContrastPalette,
+		Addresses:    ui.Config.Addresses.String(),
+		Immediates:   ui.Config.Immediates.String(),
+		LongLines:    ui.Config.LongLines.String(),
+		JumpTargets:  ui.Config.JumpTargets.String(),
+		VectorLanes:  ui.Config.VectorLanes,
+		OptHints:     ui.Config.OptHints,
+
+		Aliases: ui.Aliases.Names(),
+		Marks:   ui.Marks.PCs(),
+		Notes:   ui.Notes.Texts(),
+	}
+	for i, group := range ui.Groups {
+		if group == ui.Funcs {
+			session.Active = i
+		}
+		session.Filters = append(session.Filters, group.Filter.Text())
+		session.Selected = append(session.Selected, group.Selected)
+	}
+	for _, tab := range ui.tabs.List {
+		session.Tabs = append(session.Tabs, tab.Func.Name())
+	}
+	return session, nil
+}
+
+// ApplySettings restores the settings that don't depend on the
+// executable being loaded.
+func (session *Session) ApplySettings(config *FileUIConfig) error {
+	if session.HighContrast {
+		palette = HighContrastPalette
+	}
+	if session.Addresses != "" {
+		if err := config.Addresses.Set(session.Addresses); err != nil {
+			return err
+		}
+	}
+	if session.Immediates != "" {
+		if err := config.Immediates.Set(session.Immediates); err != nil {
+			return err
+		}
+	}
+	if session.LongLines != "" {
+		if err := config.LongLines.Set(session.LongLines); err != nil {
+			return err
+		}
+	}
+	if session.JumpTargets != "" {
+		if err := config.JumpTargets.Set(session.JumpTargets); err != nil {
+			return err
+		}
+	}
+	config.VectorLanes = session.VectorLanes
+	config.OptHints = session.OptHints
+	return nil
+}
+
+// restoreSession restores the selection and scroll positions of the
+// session after file has been loaded. The funcs are matched by name,
+// so a session of a different build is restored as well as possible.
+func (ui *FileUI) restoreSession(session *Session, file disasm.File) {
+	if hash, err := hashFile(ui.Config.Path); err != nil || hash != session.SHA256 {
+		fmt.Fprintf(os.Stderr, "session was saved for a different build of %s, restoring funcs by name\n", session.Path)
+	}
+
+	for name, alias := range session.Aliases {
+		ui.Aliases.Set(name, alias)
+	}
+	ui.Marks.Add(session.Marks)
+	for name, text := range session.Notes {
+		if !ui.Notes.Has(name) {
+			ui.Notes.Set(name, text)
+		}
+	}
+
+	byName := make(map[string]disasm.Func, len(file.Funcs()))
+	for _, fn := range file.Funcs() {
+		byName[fn.Name()] = fn
+	}
+	for _, name := range session.Tabs {
+		if fn, ok := byName[name]; ok {
+			ui.tabs.Open(fn)
+		} else {
+			fmt.Fprintf(os.Stderr, "session tab %q not found\n", name)
+		}
+	}
+
+	if InRange(session.Active, len(ui.Groups)) {
+		ui.Funcs = ui.Groups[session.Active]
+	}
+	for i, name := range session.Selected {
+		if name == "" || !InRange(i, len(ui.Groups)) {
+			continue
+		}
+		group := ui.Groups[i]
+		fn, ok := byName[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "session func %q not found\n", name)
+			continue
+		}
+		group.Selected = name
+		group.SelectedItem = fn
+		group.List.Selected = -1
+		for k, item := range group.Filtered {
+			if item == fn {
+				group.List.Selected = k
+				break
+			}
+		}
+	}
+
+	if selected := ui.Funcs.SelectedItem; selected != nil {
+		ui.Code.Code = selected.Load(ui.Config.LoadOptions())
+		ui.Code.PinnedFile = session.PinnedFile
+		ui.Code.asm.scroll = session.AsmScroll
+		ui.Code.src.scroll = session.SourceScroll
+		// keep the restored position instead of -select-scroll
+		ui.Code.scrollToSelection = false
+	}
+}
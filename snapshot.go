@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// snapshotHeader starts the snapshot file and is followed by the func name.
+const snapshotHeader = "# lensm snapshot of "
+
+// snapshotPath returns the file of the named snapshot in the config dir.
+func snapshotPath(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lensm", "snapshots", name+".asm"), nil
+}
+
+// loadSnapshotFunc loads the single func matching filter.
+func loadSnapshotFunc(config FileUIConfig, filter string) (*disasm.Code, error) {
+	rx, err := CompileFilter(filter, FilterMode{})
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	matches := FilterItems(nil, file.Funcs(), rx, config.Exclude)
+	if len(matches) != 1 {
+		return nil, fmt.Errorf("-snapshot needs filter to match a single func, matched %d", len(matches))
+	}
+	return matches[0].Load(config.LoadOptions()), nil
+}
+
+// SaveSnapshot stores the anonymized instructions of the single func
+// matching filter as the named snapshot.
+func SaveSnapshot(name string, config FileUIConfig, filter string) error {
+	path, err := snapshotPath(name)
+	if err != nil {
+		return err
+	}
+	code, err := loadSnapshotFunc(config, filter)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(snapshotHeader + code.Name + "\n")
+	for _, text := range code.AnonymizedTexts() {
+		b.WriteString(text + "\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// readSnapshot reads the func name and instructions of a snapshot.
+func readSnapshot(path string) (name string, texts []string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, snapshotHeader); ok && name == "" && len(texts) == 0 {
+			name = rest
+			continue
+		}
+		texts = append(texts, line)
+	}
+	return name, texts, scanner.Err()
+}
+
+// DiffSnapshot writes the differences between the named snapshot and the
+// single func matching filter to w. The removed instructions are marked
+// with "-" and the added ones with "+". It reports whether they differ.
+func DiffSnapshot(w io.Writer, name string, config FileUIConfig, filter string) (changed bool, err error) {
+	path, err := snapshotPath(name)
+	if err != nil {
+		return false, err
+	}
+	savedName, saved, err := readSnapshot(path)
+	if err != nil {
+		return false, err
+	}
+	code, err := loadSnapshotFunc(config, filter)
+	if err != nil {
+		return false, err
+	}
+	current := code.AnonymizedTexts()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s (snapshot %s)\n+++ %s (%s)\n", savedName, name, code.Name, config.Path)
+	removed, added := disasm.ChangedLines(saved, current)
+	for i, k := 0, 0; i < len(saved) || k < len(current); {
+		switch {
+		case i < len(saved) && removed[i]:
+			b.WriteString("- " + saved[i] + "\n")
+			i++
+			changed = true
+		case k < len(current) && added[k]:
+			b.WriteString("+ " + current[k] + "\n")
+			k++
+			changed = true
+		default:
+			b.WriteString("  " + current[k] + "\n")
+			i, k = i+1, k+1
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return changed, err
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"gioui.org/app"
+	"gioui.org/io/system"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// summaryTopMnemonics is the number of most common instructions shown.
+const summaryTopMnemonics = 30
+
+// Summary contains aggregated statistics about funcs.
+type Summary struct {
+	Funcs int
+	Insts int
+	Bytes int
+
+	// Sizes counts funcs by their size in bytes,
+	// index i contains funcs with size in [2^(i-1), 2^i).
+	Sizes []int
+	// Mnemonics counts instructions by their mnemonic.
+	Mnemonics map[string]int
+}
+
+// Add adds code to the summary.
+func (summary *Summary) Add(code *disasm.Code) {
+	if summary.Mnemonics == nil {
+		summary.Mnemonics = map[string]int{}
+	}
+
+	size := 0
+	for _, ix := range code.Insts {
+		if ix.Text == "" {
+			continue
+		}
+		summary.Insts++
+		size += ix.Size
+		if fields := strings.Fields(ix.Text); len(fields) > 0 {
+			summary.Mnemonics[fields[0]]++
+		}
+	}
+	summary.Funcs++
+	summary.Bytes += size
+
+	bucket := bits.Len(uint(size))
+	for len(summary.Sizes) <= bucket {
+		summary.Sizes = append(summary.Sizes, 0)
+	}
+	summary.Sizes[bucket]++
+}
+
+// SizeBars returns the func size histogram.
+func (summary *Summary) SizeBars() []Bar {
+	bars := make([]Bar, 0, len(summary.Sizes))
+	for bucket, count := range summary.Sizes {
+		label := "0 B"
+		if bucket > 0 {
+			label = "< " + formatBytes(1<<bucket)
+		}
+		bars = append(bars, Bar{Label: label, Value: count})
+	}
+	return bars
+}
+
+// MnemonicBars returns the n most common instructions.
+func (summary *Summary) MnemonicBars(n int) []Bar {
+	bars := make([]Bar, 0, len(summary.Mnemonics))
+	for mnemonic, count := range summary.Mnemonics {
+		bars = append(bars, Bar{Label: mnemonic, Value: count})
+	}
+	sort.Slice(bars, func(i, k int) bool {
+		if bars[i].Value == bars[k].Value {
+			return bars[i].Label < bars[k].Label
+		}
+		return bars[i].Value > bars[k].Value
+	})
+	if len(bars) > n {
+		bars = bars[:n]
+	}
+	return bars
+}
+
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%d MiB", n>>20)
+	case n >= 1<<10:
+		return fmt.Sprintf("%d KiB", n>>10)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// summaryUpdate is a progress update from summarizing funcs.
+type summaryUpdate struct {
+	summary     Summary
+	done, total int
+}
+
+// Summarize disassembles all funcs and sends the aggregated summary to
+// updates after every step until ctx is cancelled.
+func Summarize(ctx context.Context, funcs []disasm.Func, opts disasm.Options, updates chan<- summaryUpdate) {
+	var summary Summary
+	for i, fn := range funcs {
+		if ctx.Err() != nil {
+			return
+		}
+		if code := disasm.Disassemble(fn, opts); code != nil {
+			summary.Add(code)
+		}
+
+		// Only the last update owns the mnemonics map.
+		update := summaryUpdate{summary: summary, done: i + 1, total: len(funcs)}
+		update.summary.Mnemonics = nil
+		if i == len(funcs)-1 {
+			update.summary.Mnemonics = summary.Mnemonics
+		}
+		update.summary.Sizes = append([]int(nil), summary.Sizes...)
+
+		select {
+		case updates <- update:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// SummaryUI shows the statistics of funcs in a separate window.
+type SummaryUI struct {
+	Theme *material.Theme
+	// Total is the number of the summarized funcs.
+	Total int
+	// Updates are the results of Summarize.
+	Updates <-chan summaryUpdate
+	// Stop is closed when the summarized file is closed, which closes the
+	// window, Cancel stops Summarize after the window was closed.
+	Stop   <-chan struct{}
+	Cancel context.CancelFunc
+
+	update summaryUpdate
+	list   widget.List
+}
+
+// Run draws the results of Summarize until the window is closed.
+func (ui *SummaryUI) Run(w *app.Window) error {
+	var ops op.Ops
+	defer ui.Cancel()
+
+	stop := ui.Stop
+	ui.list.Axis = layout.Vertical
+	ui.update.total = ui.Total
+	for {
+		select {
+		case update := <-ui.Updates:
+			ui.update = update
+			w.Invalidate()
+		case <-stop:
+			stop = nil
+			w.Perform(system.ActionClose)
+		case e := <-w.Events():
+			switch e := e.(type) {
+			case system.FrameEvent:
+				gtx := newContext(&ops, e)
+				ui.Layout(gtx)
+				e.Frame(gtx.Ops)
+
+			case system.DestroyEvent:
+				return e.Err
+			}
+		}
+	}
+}
+
+// Layout draws the summary.
+func (ui *SummaryUI) Layout(gtx layout.Context) layout.Dimensions {
+	summary := &ui.update.summary
+	th := ui.Theme
+
+	status := fmt.Sprintf("%d funcs, %d instructions, %s", summary.Funcs, summary.Insts, formatBytes(summary.Bytes))
+	if ui.update.done < ui.update.total {
+		status += fmt.Sprintf(", loading %d / %d", ui.update.done, ui.update.total)
+	}
+
+	heading := func(text string) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			txt := material.Body1(th, text)
+			txt.TextSize *= 1.2
+			return layout.Inset{Top: 8, Bottom: 4}.Layout(gtx, txt.Layout)
+		}
+	}
+
+	widgets := []layout.Widget{
+		material.Body1(th, status).Layout,
+		heading("Func sizes"),
+		BarChart{Bars: summary.SizeBars(), TextHeight: th.TextSize, Color: palette.Splitter}.Widget(th),
+	}
+	if summary.Mnemonics != nil {
+		widgets = append(widgets,
+			heading("Most common instructions"),
+			BarChart{Bars: summary.MnemonicBars(summaryTopMnemonics), TextHeight: th.TextSize, Color: palette.Splitter}.Widget(th),
+		)
+	}
+
+	return layout.UniformInset(8).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return material.List(th, &ui.list).Layout(gtx, len(widgets), func(gtx layout.Context, index int) layout.Dimensions {
+			return widgets[index](gtx)
+		})
+	})
+}
+
+// openSummary opens a window with the statistics of the listed funcs,
+// which is closed when the file changes.
+func (ui *FileUI) openSummary() {
+	if ui.summaries.cancel == nil {
+		ui.summaries.ctx, ui.summaries.cancel = context.WithCancel(context.Background())
+	}
+	ctx, cancel := context.WithCancel(ui.summaries.ctx)
+
+	funcs := append([]disasm.Func(nil), ui.Funcs.Filtered...)
+	opts := ui.Config.LoadOptions()
+	updates := make(chan summaryUpdate)
+	ui.summaries.active.Add(1)
+	go func() {
+		defer ui.summaries.active.Done()
+		Summarize(ctx, funcs, opts, updates)
+	}()
+
+	summary := &SummaryUI{
+		Theme:   ui.Theme,
+		Total:   len(funcs),
+		Updates: updates,
+		Stop:    ui.summaries.ctx.Done(),
+		Cancel:  cancel,
+	}
+	ui.Windows.Open("Summary", image.Pt(600, 800), summary.Run)
+}
+
+// stopSummaries stops summarizing the funcs of the file and closes the
+// summary windows.
+func (ui *FileUI) stopSummaries() {
+	if ui.summaries.cancel != nil {
+		ui.summaries.cancel()
+		ui.summaries.active.Wait()
+		ui.summaries.ctx, ui.summaries.cancel = nil, nil
+	}
+}
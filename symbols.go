@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// ListSymbols writes the address, size and name of the funcs matching
+// filter to w without disassembling them. It returns the number of matches.
+func ListSymbols(w io.Writer, config FileUIConfig, filter string) (int, error) {
+	rx, err := CompileFilter(filter, FilterMode{})
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := config.Load()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	matches := FilterItems(nil, file.Funcs(), rx, config.Exclude)
+	for _, fn := range matches {
+		if sym, ok := fn.(disasm.Symbol); ok {
+			fmt.Fprintf(w, "%#x %8d %s\n", sym.Addr(), sym.Size(), fn.Name())
+		} else {
+			fmt.Fprintln(w, fn.Name())
+		}
+	}
+	return len(matches), nil
+}
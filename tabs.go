@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// tabNameLength is the length of the func names in the tab strip.
+const tabNameLength = 32
+
+// Tab is a func kept open in the tab strip above the code.
+type Tab struct {
+	Func  disasm.Func
+	click widget.Clickable
+	// hits caches the instructions of the func matching the find query.
+	hits findHits
+}
+
+// Tabs are the funcs kept open for switching between them, the tab of
+// the selected func is the active one.
+type Tabs struct {
+	List []*Tab
+	list layout.List
+}
+
+// Index returns the position of the tab of the func named name, or -1.
+func (tabs *Tabs) Index(name string) int {
+	for i, tab := range tabs.List {
+		if tab.Func.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Open adds a tab for fn, unless it already has one.
+func (tabs *Tabs) Open(fn disasm.Func) {
+	if tabs.Index(fn.Name()) < 0 {
+		tabs.List = append(tabs.List, &Tab{Func: fn})
+	}
+}
+
+// Close removes the tab of the func named name and reports whether it
+// had one.
+func (tabs *Tabs) Close(name string) bool {
+	i := tabs.Index(name)
+	if i < 0 {
+		return false
+	}
+	tabs.List = append(tabs.List[:i], tabs.List[i+1:]...)
+	return true
+}
+
+// Resolve replaces the funcs of the tabs with the ones of the same name
+// in funcs, e.g. after reloading the file. The tabs of the funcs that are
+// gone are closed.
+func (tabs *Tabs) Resolve(funcs []disasm.Func) {
+	byName := make(map[string]disasm.Func, len(funcs))
+	for _, fn := range funcs {
+		byName[fn.Name()] = fn
+	}
+	kept := tabs.List[:0]
+	for _, tab := range tabs.List {
+		if fn, ok := byName[tab.Func.Name()]; ok {
+			tab.Func = fn
+			kept = append(kept, tab)
+		}
+	}
+	tabs.List = kept
+}
+
+// Layout draws the strip with the tab of selected highlighted and returns
+// the func of the clicked tab, if any. When hits isn't nil, the tabs
+// note the number of hits of their func.
+func (tabs *Tabs) Layout(th *material.Theme, gtx layout.Context, aliases *Aliases, selected string, hits func(tab *Tab) int) (disasm.Func, layout.Dimensions) {
+	if len(tabs.List) == 0 {
+		return nil, layout.Dimensions{}
+	}
+
+	var picked disasm.Func
+	for _, tab := range tabs.List {
+		if tab.click.Clicked() {
+			picked = tab.Func
+		}
+	}
+
+	tabs.list.Axis = layout.Horizontal
+	dims := layout.Inset{Top: 2, Left: 4, Right: 4, Bottom: 2}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return tabs.list.Layout(gtx, len(tabs.List), func(gtx layout.Context, i int) layout.Dimensions {
+			tab := tabs.List[i]
+			label := truncateName(aliases.Display(tab.Func.Name()), tabNameLength)
+			if hits != nil {
+				label += fmt.Sprintf(" (%d)", hits(tab))
+			}
+			button := material.Button(th, &tab.click, label)
+			button.TextSize *= 0.8
+			button.Inset = layout.UniformInset(4)
+			button.Background = palette.SecondaryBackground
+			button.Color = palette.Foreground
+			if tab.Func.Name() == selected {
+				button.Background = palette.Highlight
+			}
+			return layout.Inset{Right: 2}.Layout(gtx, button.Layout)
+		})
+	})
+	return picked, dims
+}
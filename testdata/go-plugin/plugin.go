@@ -0,0 +1,8 @@
+package main
+
+func Fibonacci(n int) int {
+	if n < 2 {
+		return n
+	}
+	return Fibonacci(n-1) + Fibonacci(n-2)
+}
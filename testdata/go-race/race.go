@@ -0,0 +1,13 @@
+package main
+
+var counter int
+
+//go:noinline
+func Add(p *int, n int) int {
+	*p += n
+	return *p
+}
+
+func main() {
+	println(Add(&counter, 2))
+}
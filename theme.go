@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"gioui.org/unit"
+
+	"loov.dev/lensm/internal/disasm"
+	"loov.dev/lensm/internal/f32color"
+)
+
+// Palette contains the colors and sizes used for drawing.
+type Palette struct {
+	Foreground          color.NRGBA
+	SecondaryBackground color.NRGBA
+	Gutter              color.NRGBA
+	Splitter            color.NRGBA
+	Highlight           color.NRGBA
+	// Bad is used for bytes that couldn't be decoded.
+	Bad color.NRGBA
+	// Warning tints the security relevant instructions.
+	Warning color.NRGBA
+	// Covered and NotCovered tint the instructions from -coverage.
+	Covered, NotCovered color.NRGBA
+	// Mark is the glyph of the marked instructions.
+	Mark color.NRGBA
+	// Instrumented tints the instructions added by the instrumentation,
+	// Dimmed is their text when they are faded.
+	Instrumented color.NRGBA
+	Dimmed       color.NRGBA
+
+	// RelationSaturation and RelationLightness are used for the
+	// shapes between source and assembly.
+	RelationSaturation, RelationLightness float32
+	// JumpSaturation and JumpLightness are used for the jump lines.
+	JumpSaturation, JumpLightness float32
+	// AlphaBoost is added to the alpha of relations and jumps.
+	AlphaBoost float32
+
+	// LineWidth is the width of splitters and jump lines.
+	LineWidth unit.Dp
+	// RowPadding is added to the height of the list rows.
+	RowPadding unit.Dp
+}
+
+// DefaultPalette is the default look.
+var DefaultPalette = Palette{
+	Foreground:          f32color.Black,
+	SecondaryBackground: color.NRGBA{R: 0xF0, G: 0xF0, B: 0xF0, A: 0xFF},
+	Gutter:              f32color.Gray8(0xE8),
+	Splitter:            color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xFF},
+	Highlight:           color.NRGBA{R: 0xFF, G: 0xF0, B: 0x80, A: 0xFF},
+	Bad:                 color.NRGBA{R: 0xC0, G: 0x20, B: 0x20, A: 0xFF},
+	Warning:             color.NRGBA{R: 0xFF, G: 0xD8, B: 0xA8, A: 0xFF},
+	Covered:             color.NRGBA{R: 0xD8, G: 0xF5, B: 0xD0, A: 0xFF},
+	NotCovered:          color.NRGBA{R: 0xF8, G: 0xD8, B: 0xD8, A: 0xFF},
+	Mark:                color.NRGBA{R: 0xD0, G: 0x30, B: 0x30, A: 0xFF},
+	Instrumented:        color.NRGBA{R: 0xD8, G: 0xE4, B: 0xF8, A: 0xFF},
+	Dimmed:              f32color.Gray8(0xA8),
+
+	RelationSaturation: 0.9,
+	RelationLightness:  0.8,
+	JumpSaturation:     0.8,
+	JumpLightness:      0.4,
+
+	LineWidth:  1,
+	RowPadding: 4,
+}
+
+// HighContrastPalette uses strong contrast, thicker lines and larger rows.
+var HighContrastPalette = Palette{
+	Foreground:          f32color.Black,
+	SecondaryBackground: f32color.White,
+	Gutter:              f32color.Gray8(0xD0),
+	Splitter:            f32color.Black,
+	Highlight:           color.NRGBA{R: 0xFF, G: 0xFF, B: 0x00, A: 0xFF},
+	Bad:                 color.NRGBA{R: 0xD0, G: 0x00, B: 0x00, A: 0xFF},
+	Warning:             color.NRGBA{R: 0xFF, G: 0xA0, B: 0x40, A: 0xFF},
+	Covered:             color.NRGBA{R: 0x90, G: 0xF0, B: 0x90, A: 0xFF},
+	NotCovered:          color.NRGBA{R: 0xFF, G: 0xA0, B: 0xA0, A: 0xFF},
+	Mark:                color.NRGBA{R: 0xE0, G: 0x00, B: 0x00, A: 0xFF},
+	Instrumented:        color.NRGBA{R: 0xA0, G: 0xC8, B: 0xFF, A: 0xFF},
+	Dimmed:              f32color.Gray8(0x68),
+
+	RelationSaturation: 1,
+	RelationLightness:  0.65,
+	JumpSaturation:     1,
+	JumpLightness:      0.3,
+	AlphaBoost:         0.3,
+
+	LineWidth:  2,
+	RowPadding: 12,
+}
+
+// NamedPalette is a palette that can be selected by name.
+type NamedPalette struct {
+	Name    string
+	Palette Palette
+}
+
+// Palettes lists the available palettes by name.
+var Palettes = []NamedPalette{
+	{"default", DefaultPalette},
+	{"high-contrast", HighContrastPalette},
+}
+
+// PaletteByName returns the palette from Palettes with the name.
+func PaletteByName(name string) (NamedPalette, error) {
+	var names []string
+	for _, named := range Palettes {
+		if named.Name == name {
+			return named, nil
+		}
+		names = append(names, named.Name)
+	}
+	return NamedPalette{}, fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// Grayscale returns the palette with the colors converted to shades of
+// gray, e.g. for printing.
+func (p Palette) Grayscale() Palette {
+	for _, c := range []*color.NRGBA{
+		&p.Foreground, &p.SecondaryBackground, &p.Gutter, &p.Splitter,
+		&p.Highlight, &p.Bad, &p.Warning, &p.Covered, &p.NotCovered, &p.Mark,
+		&p.Instrumented, &p.Dimmed,
+	} {
+		*c = grayColor(*c)
+	}
+	p.RelationSaturation = 0
+	p.JumpSaturation = 0
+	return p
+}
+
+// grayColor returns the luma of c as a gray color.
+func grayColor(c color.NRGBA) color.NRGBA {
+	y := uint8((299*uint32(c.R) + 587*uint32(c.G) + 114*uint32(c.B)) / 1000)
+	return color.NRGBA{R: y, G: y, B: y, A: c.A}
+}
+
+// palette is the active palette.
+var palette = DefaultPalette
+
+// RelationColor returns the color for the relation shape with the specified seed.
+func (p *Palette) RelationColor(seed int, alpha float32) color.NRGBA {
+	return f32color.HSLA(float32(math.Mod(float64(seed)*math.Phi, 1)), p.RelationSaturation, p.RelationLightness, p.alpha(alpha))
+}
+
+// JumpColor returns the color for the jump line starting at pc.
+func (p *Palette) JumpColor(pc uint64, alpha float32) color.NRGBA {
+	return f32color.HSLA(float32(math.Mod(float64(pc)*math.Phi, 1)), p.JumpSaturation, p.JumpLightness, p.alpha(alpha))
+}
+
+// CoverageColor returns the tint for instructions with the coverage.
+func (p *Palette) CoverageColor(coverage disasm.Coverage) (color.NRGBA, bool) {
+	switch coverage {
+	case disasm.Covered:
+		return p.Covered, true
+	case disasm.NotCovered:
+		return p.NotCovered, true
+	default:
+		return color.NRGBA{}, false
+	}
+}
+
+func (p *Palette) alpha(alpha float32) float32 {
+	return float32(math.Min(float64(alpha+p.AlphaBoost), 1))
+}
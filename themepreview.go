@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"gioui.org/gpu/headless"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/widget/material"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// themePreviewSize is the size of a single preview in pixels.
+var themePreviewSize = image.Pt(1200, 800)
+
+// WriteThemePreview renders the first func matching filter with each of
+// the palettes and writes them as labeled PNGs into dir, together with
+// a contact sheet "themes.png" that combines them side by side.
+func WriteThemePreview(dir string, config FileUIConfig, filter string, theme *material.Theme, palettes []NamedPalette) error {
+	file, matches, err := loadMatches(config, filter)
+	if err != nil {
+		return fmt.Errorf("-theme-preview: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	code := matches[0].Load(config.LoadOptions())
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	window, err := headless.NewWindow(themePreviewSize.X, themePreviewSize.Y)
+	if err != nil {
+		return fmt.Errorf("unable to render offscreen: %w", err)
+	}
+	defer window.Release()
+
+	active := palette
+	defer func() { palette = active }()
+
+	sheet := image.NewRGBA(image.Rect(0, 0, themePreviewSize.X*len(palettes), themePreviewSize.Y))
+	for i, named := range palettes {
+		palette = named.Palette
+		img, err := renderThemePreview(window, theme, config, code, named.Name)
+		if err != nil {
+			return fmt.Errorf("rendering %s: %w", named.Name, err)
+		}
+		if err := writePNG(filepath.Join(dir, "theme-"+named.Name+".png"), img); err != nil {
+			return err
+		}
+		offset := image.Pt(themePreviewSize.X*i, 0)
+		draw.Draw(sheet, img.Bounds().Add(offset), img, image.Point{}, draw.Src)
+	}
+	return writePNG(filepath.Join(dir, "themes.png"), sheet)
+}
+
+// renderThemePreview draws code with the style of config and the active
+// palette labeled with name.
+func renderThemePreview(window *headless.Window, theme *material.Theme, config FileUIConfig, code *disasm.Code, name string) (*image.RGBA, error) {
+	return renderFrame(window, func(gtx layout.Context) layout.Dimensions {
+		lineHeight := int(theme.TextSize * 1.2)
+		SourceLine{
+			TopLeft:    image.Pt(lineHeight/2, 0),
+			Width:      themePreviewSize.X - lineHeight,
+			Text:       name + " — " + code.Name,
+			TextHeight: theme.TextSize,
+			Bold:       true,
+			Color:      palette.Foreground,
+		}.Layout(theme, gtx)
+
+		state := &CodeUI{Code: code}
+		state.ResetScroll()
+		header := op.Offset(image.Pt(0, lineHeight)).Push(gtx.Ops)
+		gtx.Constraints = layout.Exact(themePreviewSize.Sub(image.Pt(0, lineHeight)))
+		exportStyle(theme, config, state).Layout(gtx)
+		header.Pop()
+		return layout.Dimensions{Size: themePreviewSize}
+	})
+}
+
+func writePNG(path string, img image.Image) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(out, img); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// ReadPCs parses a program counter from each line of r and sends them to pcs.
+// The addresses are hex with an optional "0x" prefix, the rest of the line
+// after the address is ignored.
+func ReadPCs(r io.Reader, pcs chan<- uint64) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		text := strings.TrimPrefix(strings.ToLower(fields[0]), "0x")
+		pc, err := strconv.ParseUint(text, 16, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "-follow-pc: invalid address %q\n", fields[0])
+			continue
+		}
+		pcs <- pc
+	}
+	return scanner.Err()
+}
+
+// openPCs opens the source of program counters, "-" is stdin.
+func openPCs(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// pcIndex finds the func containing an address.
+type pcIndex struct {
+	file  disasm.File
+	funcs []disasm.Func
+}
+
+// sorted returns the funcs of file with an address sorted by the address.
+func (index *pcIndex) sorted(file disasm.File) []disasm.Func {
+	if index.file != file {
+		index.file = file
+		index.funcs = index.funcs[:0]
+		for _, fn := range file.Funcs() {
+			if _, ok := fn.(disasm.Symbol); ok {
+				index.funcs = append(index.funcs, fn)
+			}
+		}
+		sort.SliceStable(index.funcs, func(i, k int) bool {
+			return index.funcs[i].(disasm.Symbol).Addr() < index.funcs[k].(disasm.Symbol).Addr()
+		})
+	}
+	return index.funcs
+}
+
+// Lookup returns the func of file that contains pc.
+func (index *pcIndex) Lookup(file disasm.File, pc uint64) disasm.Func {
+	funcs := index.sorted(file)
+	at := sort.Search(len(funcs), func(i int) bool {
+		return funcs[i].(disasm.Symbol).Addr() > pc
+	}) - 1
+	if at < 0 {
+		return nil
+	}
+	sym := funcs[at].(disasm.Symbol)
+	if pc >= sym.Addr()+sym.Size() {
+		return nil
+	}
+	return funcs[at]
+}
+
+// followPC selects the func containing pc and scrolls to the instruction.
+func (ui *FileUI) followPC(pc uint64) {
+	if ui.File == nil {
+		return
+	}
+	fn := ui.pcs.Lookup(ui.File, pc)
+	if fn == nil {
+		ui.Funcs.Status = fmt.Sprintf("pc 0x%x not found", pc)
+		return
+	}
+	if fn != ui.Funcs.SelectedItem {
+		ui.selectFunc(fn)
+	}
+	ui.Code.FollowPC(pc)
+}
+
+// instAt returns the index of the instruction containing pc, or -1.
+func instAt(code *disasm.Code, pc uint64) int {
+	for i, ix := range code.Insts {
+		if ix.Text != "" && ix.PC <= pc && pc < ix.PC+uint64(max(ix.Size, 1)) {
+			return i
+		}
+	}
+	return -1
+}
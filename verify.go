@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"loov.dev/lensm/internal/disasm"
+)
+
+// verifyAddrs is the number of undecodable addresses printed for each func.
+const verifyAddrs = 5
+
+// VerifyFuncs disassembles all the funcs that match filter and writes the
+// ones that didn't decode cleanly to w, followed by a summary. It returns
+// the number of failed funcs.
+func VerifyFuncs(w io.Writer, config FileUIConfig, filter string) (failed int, err error) {
+	rx, err := CompileFilter(filter, FilterMode{})
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := config.Load()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	matched := FilterItems(nil, file.Funcs(), rx, config.Exclude)
+	instructions := 0
+	for _, fn := range matched {
+		code, problem := verifyFunc(fn, config.LoadOptions())
+		if code != nil {
+			instructions += len(code.Insts)
+		}
+		if problem != "" {
+			failed++
+			if _, err := fmt.Fprintf(w, "%s: %s\n", fn.Name(), problem); err != nil {
+				return failed, err
+			}
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "verified %d funcs with %d instructions, %d failed\n", len(matched), instructions, failed)
+	return failed, err
+}
+
+// verifyFunc disassembles fn and describes the problem, when it didn't
+// decode cleanly.
+func verifyFunc(fn disasm.Func, opts disasm.Options) (code *disasm.Code, problem string) {
+	defer func() {
+		if r := recover(); r != nil {
+			problem = fmt.Sprintf("panic: %v", r)
+		}
+	}()
+
+	code = fn.Load(opts)
+	if code == nil {
+		return nil, "not loaded"
+	}
+
+	var addrs []string
+	for _, ix := range code.Insts {
+		if !ix.Bad {
+			continue
+		}
+		if len(addrs) == verifyAddrs {
+			addrs = append(addrs, "...")
+			break
+		}
+		addrs = append(addrs, fmt.Sprintf("0x%x", ix.PC))
+	}
+	if n := code.Unsupported(); n > 0 {
+		return code, fmt.Sprintf("%d unsupported at %s", n, strings.Join(addrs, ", "))
+	}
+	return code, ""
+}
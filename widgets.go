@@ -3,7 +3,9 @@ package main
 import (
 	"image"
 	"image/color"
+	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"gioui.org/font"
 	"gioui.org/layout"
@@ -24,6 +26,13 @@ type SourceLine struct {
 	Italic     bool
 	Bold       bool
 	Color      color.NRGBA
+	// Truncate cuts the text with an ellipsis to fit Width.
+	Truncate bool
+	// Offset scrolls the text horizontally within Width.
+	Offset int
+	// Plain draws each character separately at the monospace advance,
+	// so that the font can't combine them into ligatures.
+	Plain bool
 }
 
 // Layout draws the text.
@@ -37,6 +46,12 @@ func (line SourceLine) Layout(th *material.Theme, gtx layout.Context) {
 	if line.Width > 0 {
 		maxSize := image.Pt(line.Width, gtx.Metric.Sp(line.TextHeight))
 		defer clip.Rect{Max: maxSize}.Push(gtx.Ops).Pop()
+		if line.Truncate {
+			gtx.Constraints.Max.X = line.Width
+		}
+	}
+	if line.Offset != 0 {
+		defer op.Offset(image.Pt(-line.Offset, 0)).Push(gtx.Ops).Pop()
 	}
 
 	f := font.Font{Typeface: "override-monospace,Go,monospace", Weight: font.Normal}
@@ -47,9 +62,86 @@ func (line SourceLine) Layout(th *material.Theme, gtx layout.Context) {
 		f.Weight = font.Black
 	}
 	paint.ColorOp{Color: line.Color}.Add(gtx.Ops)
+	if line.Plain {
+		line.layoutPlain(th, gtx, f)
+		return
+	}
 	widget.Label{MaxLines: 1}.Layout(gtx, th.Shaper, f, line.TextHeight, line.Text, op.CallOp{})
 }
 
+// layoutPlain draws the characters of the text one by one.
+func (line SourceLine) layoutPlain(th *material.Theme, gtx layout.Context, f font.Font) {
+	advance := monospaceAdvance(th, gtx, line.TextHeight)
+	text := line.Text
+	if n := line.Width / advance; line.Truncate && n > 0 && utf8.RuneCountInString(text) > n {
+		text = string([]rune(text)[:n-1]) + "…"
+	}
+	x := 0
+	for _, r := range text {
+		if line.Width > 0 && x-line.Offset > line.Width {
+			break
+		}
+		if r != ' ' && x+advance > line.Offset {
+			stack := op.Offset(image.Pt(x, 0)).Push(gtx.Ops)
+			widget.Label{MaxLines: 1}.Layout(gtx, th.Shaper, f, line.TextHeight, string(r), op.CallOp{})
+			stack.Pop()
+		}
+		x += advance
+	}
+}
+
+// monospaceAdvance returns the width of a single character of the
+// monospace font used by SourceLine.
+func monospaceAdvance(th *material.Theme, gtx layout.Context, size unit.Sp) int {
+	gtx.Constraints = layout.Exact(image.Pt(maxLineWidth, maxLineWidth))
+	gtx.Constraints.Min = image.Point{}
+	const sample = "0000000000"
+	macro := op.Record(gtx.Ops)
+	f := font.Font{Typeface: "override-monospace,Go,monospace", Weight: font.Normal}
+	dims := widget.Label{MaxLines: 1}.Layout(gtx, th.Shaper, f, size, sample, op.CallOp{})
+	macro.Stop()
+	return max(1, (dims.Size.X+len(sample)-1)/len(sample))
+}
+
+// Tooltip is a small box of text that is shown near a position.
+type Tooltip struct {
+	Position   image.Point
+	Text       string
+	TextHeight unit.Sp
+	Color      color.NRGBA
+	Background color.NRGBA
+}
+
+// Layout draws the tooltip, keeping it inside the constraints.
+func (tip Tooltip) Layout(th *material.Theme, gtx layout.Context) {
+	bounds := gtx.Constraints.Max
+	gtx.Constraints.Min = image.Point{}
+	gtx.Constraints.Max = image.Pt(maxLineWidth, maxLineWidth)
+
+	pad := gtx.Metric.Dp(2)
+	f := font.Font{Typeface: "override-monospace,Go,monospace", Weight: font.Normal}
+
+	macro := op.Record(gtx.Ops)
+	paint.ColorOp{Color: tip.Color}.Add(gtx.Ops)
+	dims := widget.Label{MaxLines: 1}.Layout(gtx, th.Shaper, f, tip.TextHeight, tip.Text, op.CallOp{})
+	text := macro.Stop()
+
+	size := dims.Size.Add(image.Pt(2*pad, 2*pad))
+	pos := tip.Position
+	if pos.X+size.X > bounds.X {
+		pos.X = bounds.X - size.X
+	}
+	if pos.Y+size.Y > bounds.Y {
+		pos.Y = bounds.Y - size.Y
+	}
+
+	defer op.Offset(pos).Push(gtx.Ops).Pop()
+	paint.FillShape(gtx.Ops, tip.Background, clip.Rect{Max: size}.Op())
+	paint.FillShape(gtx.Ops, tip.Color, clip.Stroke{Path: clip.Rect{Max: size}.Path(), Width: 1}.Op())
+	defer op.Offset(image.Pt(pad, pad)).Push(gtx.Ops).Pop()
+	text.Add(gtx.Ops)
+}
+
 type VerticalLine struct {
 	Width unit.Dp
 	Color color.NRGBA
@@ -125,3 +217,69 @@ func easeInOutCubic(t float32) float32 {
 	}
 	return (t-1)*(2*t-2)*(2*t-2) + 1
 }
+
+// Bar is a single labeled value in a BarChart.
+type Bar struct {
+	Label string
+	Value int
+}
+
+// BarChart draws horizontal bars with their labels and values.
+type BarChart struct {
+	Bars       []Bar
+	TextHeight unit.Sp
+	Color      color.NRGBA
+}
+
+// Widget returns the chart as a layout.Widget.
+func (chart BarChart) Widget(th *material.Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions { return chart.Layout(th, gtx) }
+}
+
+// Layout draws the chart.
+func (chart BarChart) Layout(th *material.Theme, gtx layout.Context) layout.Dimensions {
+	maxValue := 0
+	for _, bar := range chart.Bars {
+		if bar.Value > maxValue {
+			maxValue = bar.Value
+		}
+	}
+
+	lineHeight := gtx.Metric.Sp(chart.TextHeight * 14 / 10)
+	labelWidth := gtx.Metric.Sp(chart.TextHeight * 8)
+	barWidth := gtx.Constraints.Max.X - 2*labelWidth
+	if barWidth < 0 {
+		barWidth = 0
+	}
+
+	for i, bar := range chart.Bars {
+		top := i * lineHeight
+		SourceLine{
+			TopLeft:    image.Pt(0, top),
+			Width:      labelWidth,
+			Text:       bar.Label,
+			TextHeight: chart.TextHeight,
+			Color:      palette.Foreground,
+		}.Layout(th, gtx)
+
+		width := 0
+		if maxValue > 0 {
+			width = barWidth * bar.Value / maxValue
+		}
+		paint.FillShape(gtx.Ops, chart.Color, clip.Rect{
+			Min: image.Pt(labelWidth, top+lineHeight/8),
+			Max: image.Pt(labelWidth+width, top+lineHeight*7/8),
+		}.Op())
+
+		SourceLine{
+			TopLeft:    image.Pt(labelWidth+width+lineHeight/4, top),
+			Text:       strconv.Itoa(bar.Value),
+			TextHeight: chart.TextHeight,
+			Color:      palette.Foreground,
+		}.Layout(th, gtx)
+	}
+
+	return layout.Dimensions{
+		Size: image.Pt(gtx.Constraints.Max.X, len(chart.Bars)*lineHeight),
+	}
+}
@@ -19,6 +19,9 @@ import (
 
 type Windows struct {
 	active sync.WaitGroup
+
+	mu   sync.Mutex
+	open map[*app.Window]struct{}
 }
 
 func (windows *Windows) Open(title string, sizeDp image.Point, run func(*app.Window) error) {
@@ -28,14 +31,45 @@ func (windows *Windows) Open(title string, sizeDp image.Point, run func(*app.Win
 
 		window := app.NewWindow(
 			app.Title(title),
-			app.Size(unit.Dp(sizeDp.X), unit.Dp(sizeDp.Y)),
+			// the system scales the size, but not by uiScale
+			app.Size(unit.Dp(float32(sizeDp.X)*uiScale), unit.Dp(float32(sizeDp.Y)*uiScale)),
 		)
+		windows.add(window)
+		defer windows.remove(window)
+
 		if err := run(window); err != nil {
 			log.Println(err)
 		}
 	}()
 }
 
+// CloseAll asks all the open windows to close and reports whether there
+// were any. The windows receive a DestroyEvent, which allows them to save
+// their state.
+func (windows *Windows) CloseAll() bool {
+	windows.mu.Lock()
+	defer windows.mu.Unlock()
+	for window := range windows.open {
+		window.Perform(system.ActionClose)
+	}
+	return len(windows.open) > 0
+}
+
+func (windows *Windows) add(window *app.Window) {
+	windows.mu.Lock()
+	defer windows.mu.Unlock()
+	if windows.open == nil {
+		windows.open = map[*app.Window]struct{}{}
+	}
+	windows.open[window] = struct{}{}
+}
+
+func (windows *Windows) remove(window *app.Window) {
+	windows.mu.Lock()
+	defer windows.mu.Unlock()
+	delete(windows.open, window)
+}
+
 func (windows *Windows) Wait() {
 	windows.active.Wait()
 }
@@ -48,7 +82,7 @@ func WidgetWindow(widget layout.Widget) func(*app.Window) error {
 			case e := <-w.Events():
 				switch e := e.(type) {
 				case system.FrameEvent:
-					gtx := layout.NewContext(&ops, e)
+					gtx := newContext(&ops, e)
 					widget(gtx)
 					e.Frame(gtx.Ops)
 